@@ -1,6 +1,7 @@
 package api
 
 import (
+	"macro_strategy/internal/calendar"
 	"macro_strategy/internal/models"
 	"macro_strategy/internal/services"
 	"net/http"
@@ -116,18 +117,30 @@ func (h *Handlers) GetIndexData(c *gin.Context) {
 
 // BacktestRequestJSON represents the JSON structure for backtest requests
 type BacktestRequestJSON struct {
-	IndexID     string             `json:"index_id" binding:"required"`
-	Strategy    StrategyConfigJSON `json:"strategy" binding:"required"`
-	StartDate   string             `json:"start_date" binding:"required"`
-	EndDate     string             `json:"end_date" binding:"required"`
-	InitialCash float64            `json:"initial_cash" binding:"required"`
+	IndexID         string                 `json:"index_id"`
+	AssetIDs        []string               `json:"asset_ids,omitempty"`
+	DateAlignment   string                 `json:"date_alignment,omitempty"`
+	Strategy        StrategyConfigJSON     `json:"strategy" binding:"required"`
+	StartDate       string                 `json:"start_date" binding:"required"`
+	EndDate         string                 `json:"end_date" binding:"required"`
+	InitialCash     float64                `json:"initial_cash" binding:"required"`
+	Benchmark       string                 `json:"benchmark,omitempty"`
+	Costs           *models.CostConfig     `json:"costs,omitempty"`
+	ExecutionTiming string                 `json:"execution_timing,omitempty"`
+	Margin          *models.MarginConfig   `json:"margin,omitempty"`
+	PriceAdjustment string                 `json:"price_adjustment,omitempty"`
+	BaseCurrency    string                 `json:"base_currency,omitempty"`
+	RiskFree        *models.RiskFreeConfig `json:"risk_free,omitempty"`
+	VaRConfidence   float64                `json:"var_confidence,omitempty"`
+	DrawdownTopN    int                    `json:"drawdown_top_n,omitempty"`
 }
 
 // StrategyConfigJSON represents the JSON structure for strategy configuration
 type StrategyConfigJSON struct {
-	Type        string                 `json:"type" binding:"required"`
-	Parameters  map[string]interface{} `json:"parameters"`
-	Description string                 `json:"description"`
+	Type           string                       `json:"type" binding:"required"`
+	Parameters     map[string]interface{}       `json:"parameters"`
+	Description    string                       `json:"description"`
+	RiskManagement *models.RiskManagementConfig `json:"risk_management,omitempty"`
 }
 
 // RunBacktest handles backtest execution requests
@@ -161,17 +174,37 @@ func (h *Handlers) RunBacktest(c *gin.Context) {
 		return
 	}
 
+	if requestJSON.IndexID == "" && len(requestJSON.AssetIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format: index_id or asset_ids is required",
+		})
+		return
+	}
+
 	// Convert to internal request format
 	request := models.BacktestRequest{
-		IndexID: requestJSON.IndexID,
+		IndexID:       requestJSON.IndexID,
+		AssetIDs:      requestJSON.AssetIDs,
+		DateAlignment: models.DateAlignment(requestJSON.DateAlignment),
 		Strategy: models.StrategyConfig{
-			Type:        models.StrategyType(requestJSON.Strategy.Type),
-			Parameters:  requestJSON.Strategy.Parameters,
-			Description: requestJSON.Strategy.Description,
+			Type:           models.StrategyType(requestJSON.Strategy.Type),
+			Parameters:     requestJSON.Strategy.Parameters,
+			Description:    requestJSON.Strategy.Description,
+			RiskManagement: requestJSON.Strategy.RiskManagement,
 		},
-		StartDate:   startDate,
-		EndDate:     endDate,
-		InitialCash: requestJSON.InitialCash,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		InitialCash:     requestJSON.InitialCash,
+		Benchmark:       requestJSON.Benchmark,
+		Costs:           requestJSON.Costs,
+		ExecutionTiming: models.ExecutionTiming(requestJSON.ExecutionTiming),
+		Margin:          requestJSON.Margin,
+		PriceAdjustment: models.PriceAdjustment(requestJSON.PriceAdjustment),
+		BaseCurrency:    models.Currency(requestJSON.BaseCurrency),
+		RiskFree:        requestJSON.RiskFree,
+		VaRConfidence:   requestJSON.VaRConfidence,
+		DrawdownTopN:    requestJSON.DrawdownTopN,
 	}
 
 	// Run backtest
@@ -267,16 +300,102 @@ func (h *Handlers) GetSupportedStrategies(c *gin.Context) {
 	})
 }
 
+// GetTradingCalendar handles requests to get the trading days and holidays of a market.
+// The range defaults to the current calendar year.
+func (h *Handlers) GetTradingCalendar(c *gin.Context) {
+	marketType := models.MarketType(c.Param("market_type"))
+
+	cal, err := calendar.ForMarket(marketType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	year := time.Now().Year()
+	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		if startDate, err = time.Parse("2006-01-02", startDateStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid start_date format, use YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		if endDate, err = time.Parse("2006-01-02", endDateStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid end_date format, use YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if endDate.Before(startDate) || endDate.After(startDate.AddDate(10, 0, 0)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "end_date must be on or after start_date and within 10 years of it",
+		})
+		return
+	}
+	if err := cal.Validate(startDate, endDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	holidays := make([]gin.H, 0)
+	for _, holiday := range cal.Holidays(startDate, endDate) {
+		holidays = append(holidays, gin.H{
+			"date": holiday.Date.Format("2006-01-02"),
+			"name": holiday.Name,
+		})
+	}
+	tradingDays := make([]string, 0)
+	for _, day := range cal.TradingDays(startDate, endDate) {
+		tradingDays = append(tradingDays, day.Format("2006-01-02"))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"market_type":       marketType,
+			"timezone":          cal.Timezone(),
+			"weekend_days":      cal.WeekendDays(),
+			"start_date":        startDate.Format("2006-01-02"),
+			"end_date":          endDate.Format("2006-01-02"),
+			"holidays":          holidays,
+			"trading_days":      tradingDays,
+			"trading_day_count": len(tradingDays),
+		},
+	})
+}
+
 // MultiStrategyBacktestRequestJSON represents the JSON structure for multi-strategy backtest requests
 type MultiStrategyBacktestRequestJSON struct {
-	AssetID       string                 `json:"asset_id" binding:"required"`
-	Strategies    []StrategyConfigJSON   `json:"strategies" binding:"required"`
-	StartDate     string                 `json:"start_date" binding:"required"`
-	EndDate       string                 `json:"end_date" binding:"required"`
-	InitialCash   float64                `json:"initial_cash" binding:"required"`
-	Benchmark     string                 `json:"benchmark,omitempty"`
-	DataSource    string                 `json:"data_source,omitempty"`
-	ComparisonOpt *ComparisonOptionsJSON `json:"comparison_opt,omitempty"`
+	AssetID         string                 `json:"asset_id" binding:"required"`
+	Strategies      []StrategyConfigJSON   `json:"strategies" binding:"required"`
+	StartDate       string                 `json:"start_date" binding:"required"`
+	EndDate         string                 `json:"end_date" binding:"required"`
+	InitialCash     float64                `json:"initial_cash" binding:"required"`
+	Benchmark       string                 `json:"benchmark,omitempty"`
+	DataSource      string                 `json:"data_source,omitempty"`
+	Costs           *models.CostConfig     `json:"costs,omitempty"`
+	ExecutionTiming string                 `json:"execution_timing,omitempty"`
+	Margin          *models.MarginConfig   `json:"margin,omitempty"`
+	PriceAdjustment string                 `json:"price_adjustment,omitempty"`
+	BaseCurrency    string                 `json:"base_currency,omitempty"`
+	RiskFree        *models.RiskFreeConfig `json:"risk_free,omitempty"`
+	VaRConfidence   float64                `json:"var_confidence,omitempty"`
+	DrawdownTopN    int                    `json:"drawdown_top_n,omitempty"`
+	ComparisonOpt   *ComparisonOptionsJSON `json:"comparison_opt,omitempty"`
 }
 
 // ComparisonOptionsJSON represents the JSON structure for comparison options
@@ -324,9 +443,10 @@ func (h *Handlers) RunMultiStrategyBacktest(c *gin.Context) {
 	var strategies []models.StrategyConfig
 	for _, strategyJSON := range requestJSON.Strategies {
 		strategy := models.StrategyConfig{
-			Type:        models.StrategyType(strategyJSON.Type),
-			Parameters:  strategyJSON.Parameters,
-			Description: strategyJSON.Description,
+			Type:           models.StrategyType(strategyJSON.Type),
+			Parameters:     strategyJSON.Parameters,
+			Description:    strategyJSON.Description,
+			RiskManagement: strategyJSON.RiskManagement,
 		}
 		strategies = append(strategies, strategy)
 	}
@@ -346,14 +466,22 @@ func (h *Handlers) RunMultiStrategyBacktest(c *gin.Context) {
 
 	// Convert to internal request format
 	request := models.MultiStrategyBacktestRequest{
-		AssetID:       requestJSON.AssetID,
-		Strategies:    strategies,
-		StartDate:     startDate,
-		EndDate:       endDate,
-		InitialCash:   requestJSON.InitialCash,
-		Benchmark:     requestJSON.Benchmark,
-		DataSource:    requestJSON.DataSource,
-		ComparisonOpt: comparisonOpt,
+		AssetID:         requestJSON.AssetID,
+		Strategies:      strategies,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		InitialCash:     requestJSON.InitialCash,
+		Benchmark:       requestJSON.Benchmark,
+		DataSource:      requestJSON.DataSource,
+		Costs:           requestJSON.Costs,
+		ExecutionTiming: models.ExecutionTiming(requestJSON.ExecutionTiming),
+		Margin:          requestJSON.Margin,
+		PriceAdjustment: models.PriceAdjustment(requestJSON.PriceAdjustment),
+		BaseCurrency:    models.Currency(requestJSON.BaseCurrency),
+		RiskFree:        requestJSON.RiskFree,
+		VaRConfidence:   requestJSON.VaRConfidence,
+		DrawdownTopN:    requestJSON.DrawdownTopN,
+		ComparisonOpt:   comparisonOpt,
 	}
 
 	// Run multi-strategy backtest
@@ -41,6 +41,7 @@ func SetupRouter() *gin.Engine {
 		v1.GET("/assets/market/:market_type", handlers.GetAssetsByMarketType)
 		v1.GET("/assets/data/:id", handlers.GetAssetData)
 		v1.GET("/markets", handlers.GetSupportedMarkets) // New: get all supported markets
+		v1.GET("/calendar/:market_type", handlers.GetTradingCalendar)
 
 		// Strategy endpoints
 		v1.GET("/strategies", handlers.GetSupportedStrategies) // New: get all supported strategies
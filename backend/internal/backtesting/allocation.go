@@ -0,0 +1,423 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+)
+
+// weightBounds limits each asset's weight; infinite when unbounded
+type weightBounds struct {
+	lower float64
+	upper float64
+}
+
+// allocator computes fully invested weights from a covariance matrix
+type allocator func(covariance [][]float64, bounds weightBounds) ([]float64, bool)
+
+// allocationStrategy rebalances its universe to weights computed from the
+// covariance of daily returns over a rolling lookback. Risk parity and
+// minimum variance differ only in their allocator.
+type allocationStrategy struct {
+	params   *models.AllocationParams
+	allocate allocator
+	bounds   weightBounds
+	closes   [][]float64 // Closes of all assets on the days they all traded
+	history  []models.WeightSnapshot
+}
+
+// parseAllocationParams parses and validates allocation strategy parameters
+func parseAllocationParams(parameters map[string]interface{}) (*models.AllocationParams, error) {
+	lookback, err := intParam(parameters, "lookback_period", 60)
+	if err != nil {
+		return nil, err
+	}
+	frequency, err := stringParam(parameters, "rebalance_frequency", "monthly")
+	if err != nil {
+		return nil, err
+	}
+	longOnly, err := boolParam(parameters, "long_only", true)
+	if err != nil {
+		return nil, err
+	}
+	minWeight, err := floatParam(parameters, "min_weight", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxWeight, err := floatParam(parameters, "max_weight", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if lookback < 10 {
+		return nil, fmt.Errorf("lookback_period must be at least 10")
+	}
+	if maxWeight < 0 {
+		return nil, fmt.Errorf("max_weight must not be negative")
+	}
+	if maxWeight > 0 && minWeight > maxWeight {
+		return nil, fmt.Errorf("min_weight must not exceed max_weight")
+	}
+
+	return &models.AllocationParams{
+		LookbackPeriod:     lookback,
+		RebalanceFrequency: frequency,
+		LongOnly:           longOnly,
+		MinWeight:          minWeight,
+		MaxWeight:          maxWeight,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *allocationStrategy) Init(ctx *StrategyContext) error {
+	s.bounds = weightBounds{lower: math.Inf(-1), upper: math.Inf(1)}
+	switch {
+	case s.params.LongOnly:
+		s.bounds.lower = math.Max(0, s.params.MinWeight)
+	case s.params.MinWeight != 0:
+		s.bounds.lower = s.params.MinWeight
+	}
+	if s.params.MaxWeight > 0 {
+		s.bounds.upper = s.params.MaxWeight
+	}
+
+	n := float64(len(ctx.Assets))
+	if s.bounds.lower*n > 1 || s.bounds.upper*n < 1 {
+		return fmt.Errorf("weight bounds cannot hold %d assets fully invested", len(ctx.Assets))
+	}
+	if margin := ctx.Portfolio.margin; s.bounds.lower < 0 && (margin == nil || !margin.AllowShort) {
+		return fmt.Errorf("negative weights need a margin account that allows shorting")
+	}
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *allocationStrategy) OnBar(ctx *StrategyContext) error {
+	// Returns are measured between the days every asset traded
+	closes := make([]float64, len(ctx.Assets))
+	for i, asset := range ctx.Assets {
+		bar, ok := ctx.AssetBar(asset)
+		if !ok || bar.Close <= 0 {
+			return nil
+		}
+		closes[i] = bar.Close
+	}
+	s.closes = append(s.closes, closes)
+	if len(s.closes) <= s.params.LookbackPeriod {
+		return nil
+	}
+
+	// Allocate as soon as the lookback is filled, then on the schedule
+	if len(s.history) > 0 && !ctx.IsPeriodStart(s.params.RebalanceFrequency) {
+		return nil
+	}
+
+	covariance, ok := returnCovariance(s.closes[len(s.closes)-s.params.LookbackPeriod-1:], ctx.PeriodsPerYear())
+	if !ok {
+		return nil
+	}
+	weights, ok := s.allocate(covariance, s.bounds)
+	if !ok {
+		return nil
+	}
+
+	snapshot := models.WeightSnapshot{
+		Date:              ctx.Date(),
+		Weights:           make(map[string]float64, len(ctx.Assets)),
+		RiskContributions: make(map[string]float64, len(ctx.Assets)),
+	}
+	variance, contributions := riskContributions(covariance, weights)
+	snapshot.Volatility = math.Sqrt(variance)
+	for i, asset := range ctx.Assets {
+		snapshot.Weights[asset] = weights[i]
+		snapshot.RiskContributions[asset] = contributions[i]
+	}
+
+	ctx.OrderTargetWeights(snapshot.Weights)
+	s.history = append(s.history, snapshot)
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *allocationStrategy) Finalize(ctx *StrategyContext) error {
+	return nil
+}
+
+// ReportResult implements ResultReporter
+func (s *allocationStrategy) ReportResult(result *models.BacktestResult) {
+	result.WeightHistory = s.history
+}
+
+// returnCovariance returns the sample covariance of the daily returns
+// between consecutive rows of closes, annualized over periodsPerYear
+func returnCovariance(closes [][]float64, periodsPerYear float64) ([][]float64, bool) {
+	if len(closes) < 3 {
+		return nil, false
+	}
+	n := len(closes[0])
+	returns := make([][]float64, len(closes)-1)
+	means := make([]float64, n)
+	for t := range returns {
+		returns[t] = make([]float64, n)
+		for i := 0; i < n; i++ {
+			returns[t][i] = closes[t+1][i]/closes[t][i] - 1
+			means[i] += returns[t][i] / float64(len(returns))
+		}
+	}
+
+	covariance := make([][]float64, n)
+	for i := range covariance {
+		covariance[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			sum := 0.0
+			for _, r := range returns {
+				sum += (r[i] - means[i]) * (r[j] - means[j])
+			}
+			covariance[i][j] = sum / float64(len(returns)-1) * periodsPerYear
+			covariance[j][i] = covariance[i][j]
+		}
+	}
+	return covariance, true
+}
+
+// riskContributions returns the portfolio variance and each asset's share of it
+func riskContributions(covariance [][]float64, weights []float64) (float64, []float64) {
+	marginal := multiply(covariance, weights)
+	variance := 0.0
+	for i := range weights {
+		variance += weights[i] * marginal[i]
+	}
+
+	contributions := make([]float64, len(weights))
+	if variance <= 0 {
+		return 0, contributions
+	}
+	for i := range weights {
+		contributions[i] = weights[i] * marginal[i] / variance
+	}
+	return variance, contributions
+}
+
+// equalRiskWeights returns the equal risk contribution weights, where every
+// asset adds the same share of portfolio variance, by cyclical coordinate
+// descent. The solution is long only; weights outside the bounds are
+// projected back onto them, so their risk contributions are then unequal.
+func equalRiskWeights(covariance [][]float64, bounds weightBounds) ([]float64, bool) {
+	n := len(covariance)
+	budget := 1 / float64(n)
+	y := make([]float64, n)
+	for i := range y {
+		if covariance[i][i] <= 0 {
+			return nil, false
+		}
+		y[i] = 1 / math.Sqrt(covariance[i][i])
+	}
+
+	for sweep := 0; sweep < 1000; sweep++ {
+		change := 0.0
+		for i := range y {
+			c := 0.0
+			for j := range y {
+				if j != i {
+					c += covariance[i][j] * y[j]
+				}
+			}
+			updated := (-c + math.Sqrt(c*c+4*covariance[i][i]*budget)) / (2 * covariance[i][i])
+			change = math.Max(change, math.Abs(updated-y[i])/y[i])
+			y[i] = updated
+		}
+		if change < 1e-10 {
+			break
+		}
+	}
+
+	total := 0.0
+	for _, value := range y {
+		total += value
+	}
+	for i := range y {
+		y[i] /= total
+	}
+	if !bounds.contains(y) {
+		return projectWeights(y, bounds), true
+	}
+	return y, true
+}
+
+// minVarianceWeights returns the global minimum variance weights. Without
+// binding bounds they have the closed form inverse(Σ)·1 / 1'·inverse(Σ)·1;
+// otherwise they are found by projected gradient descent.
+func minVarianceWeights(covariance [][]float64, bounds weightBounds) ([]float64, bool) {
+	n := len(covariance)
+	ones := make([]float64, n)
+	for i := range ones {
+		ones[i] = 1
+	}
+	if x, ok := solveLinear(covariance, ones); ok {
+		total := 0.0
+		for _, value := range x {
+			total += value
+		}
+		if total > 0 {
+			for i := range x {
+				x[i] /= total
+			}
+			if bounds.contains(x) {
+				return x, true
+			}
+		}
+	}
+
+	// The gradient 2Σw is Lipschitz with twice the largest eigenvalue of Σ
+	lipschitz := 2 * largestEigenvalue(covariance)
+	if lipschitz <= 0 {
+		return nil, false
+	}
+	weights := projectWeights(ones, bounds)
+	for i := 0; i < 10000; i++ {
+		gradient := multiply(covariance, weights)
+		step := make([]float64, n)
+		for j := range step {
+			step[j] = weights[j] - 2*gradient[j]/lipschitz
+		}
+		next := projectWeights(step, bounds)
+
+		change := 0.0
+		for j := range next {
+			change = math.Max(change, math.Abs(next[j]-weights[j]))
+		}
+		weights = next
+		if change < 1e-12 {
+			break
+		}
+	}
+	return weights, true
+}
+
+// contains reports whether every weight lies within the bounds
+func (b weightBounds) contains(weights []float64) bool {
+	for _, weight := range weights {
+		if weight < b.lower-1e-9 || weight > b.upper+1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// projectWeights returns the closest weights to values that sum to one within
+// the bounds: each value shifted by a common amount and clamped, with the
+// shift found by bisection
+func projectWeights(values []float64, bounds weightBounds) []float64 {
+	total := func(shift float64) float64 {
+		sum := 0.0
+		for _, value := range values {
+			sum += math.Min(bounds.upper, math.Max(bounds.lower, value-shift))
+		}
+		return sum
+	}
+
+	low, high := -1.0, 1.0
+	for _, value := range values {
+		low = math.Min(low, value-1)
+		high = math.Max(high, value+1)
+	}
+	for i := 0; i < 60 && total(low) < 1; i++ {
+		low -= high - low
+	}
+	for i := 0; i < 60 && total(high) > 1; i++ {
+		high += high - low
+	}
+	for i := 0; i < 200; i++ {
+		middle := (low + high) / 2
+		if total(middle) > 1 {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+
+	shift := (low + high) / 2
+	weights := make([]float64, len(values))
+	for i, value := range values {
+		weights[i] = math.Min(bounds.upper, math.Max(bounds.lower, value-shift))
+	}
+	return weights
+}
+
+// multiply returns the matrix-vector product
+func multiply(matrix [][]float64, vector []float64) []float64 {
+	product := make([]float64, len(matrix))
+	for i := range matrix {
+		for j := range vector {
+			product[i] += matrix[i][j] * vector[j]
+		}
+	}
+	return product
+}
+
+// largestEigenvalue estimates the largest eigenvalue of a symmetric positive
+// semi-definite matrix by power iteration
+func largestEigenvalue(matrix [][]float64) float64 {
+	vector := make([]float64, len(matrix))
+	for i := range vector {
+		vector[i] = 1
+	}
+
+	eigenvalue := 0.0
+	for i := 0; i < 100; i++ {
+		product := multiply(matrix, vector)
+		norm := 0.0
+		for _, value := range product {
+			norm += value * value
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			return 0
+		}
+		for j := range product {
+			vector[j] = product[j] / norm
+		}
+		eigenvalue = norm
+	}
+	return eigenvalue
+}
+
+// solveLinear solves matrix·x = vector by Gaussian elimination with partial
+// pivoting, failing on a singular matrix
+func solveLinear(matrix [][]float64, vector []float64) ([]float64, bool) {
+	n := len(matrix)
+	augmented := make([][]float64, n)
+	for i := range matrix {
+		augmented[i] = append(append([]float64{}, matrix[i]...), vector[i])
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(augmented[row][col]) > math.Abs(augmented[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(augmented[pivot][col]) < 1e-14 {
+			return nil, false
+		}
+		augmented[col], augmented[pivot] = augmented[pivot], augmented[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := augmented[row][col] / augmented[col][col]
+			for k := col; k <= n; k++ {
+				augmented[row][k] -= factor * augmented[col][k]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := augmented[row][n]
+		for k := row + 1; k < n; k++ {
+			sum -= augmented[row][k] * x[k]
+		}
+		x[row] = sum / augmented[row][row]
+	}
+	return x, true
+}
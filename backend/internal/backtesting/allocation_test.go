@@ -0,0 +1,177 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+	"testing"
+)
+
+func TestSolveLinear(t *testing.T) {
+	// Needs a row swap: the first pivot is zero
+	matrix := [][]float64{{0, 2, 1}, {1, 1, 1}, {2, 1, 3}}
+	want := []float64{1, 2, 3}
+	x, ok := solveLinear(matrix, multiply(matrix, want))
+	if !ok {
+		t.Fatal("solveLinear failed on a regular matrix")
+	}
+	for i := range want {
+		if math.Abs(x[i]-want[i]) > 1e-9 {
+			t.Errorf("x = %v, want %v", x, want)
+			break
+		}
+	}
+
+	if _, ok := solveLinear([][]float64{{1, 2}, {2, 4}}, []float64{1, 2}); ok {
+		t.Error("solveLinear solved a singular matrix")
+	}
+}
+
+func TestProjectWeights(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		bounds weightBounds
+		want   []float64
+	}{
+		{"already feasible", []float64{0.2, 0.3, 0.5}, weightBounds{0, 1}, []float64{0.2, 0.3, 0.5}},
+		{"equal shift", []float64{1, 1}, weightBounds{0, 1}, []float64{0.5, 0.5}},
+		{"clamped at zero", []float64{1, -1, 0.5}, weightBounds{0, 1}, []float64{0.75, 0, 0.25}},
+		{"capped", []float64{0.9, 0.05, 0.05}, weightBounds{0, 0.4}, []float64{0.4, 0.3, 0.3}},
+		{"floored", []float64{1, 0, 0, 0}, weightBounds{0.1, 1}, []float64{0.7, 0.1, 0.1, 0.1}},
+	}
+
+	for _, tt := range tests {
+		got := projectWeights(tt.values, tt.bounds)
+		for i := range tt.want {
+			if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+				t.Errorf("%s: projectWeights = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEqualRiskWeights(t *testing.T) {
+	covariance := [][]float64{
+		{0.04, 0.006, 0.002},
+		{0.006, 0.09, 0.012},
+		{0.002, 0.012, 0.01},
+	}
+
+	weights, ok := equalRiskWeights(covariance, weightBounds{0, 1})
+	if !ok {
+		t.Fatal("equalRiskWeights failed")
+	}
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("weights %v sum to %v", weights, total)
+	}
+	_, contributions := riskContributions(covariance, weights)
+	for i, contribution := range contributions {
+		if math.Abs(contribution-1.0/3) > 1e-6 {
+			t.Errorf("asset %d contributes %v of the risk, want 1/3", i, contribution)
+		}
+	}
+
+	// A cap binds on the low volatility asset
+	capped, _ := equalRiskWeights(covariance, weightBounds{0, 0.4})
+	if !(weightBounds{0, 0.4}).contains(capped) {
+		t.Errorf("capped weights %v break the bounds", capped)
+	}
+}
+
+func TestMinVarianceWeights(t *testing.T) {
+	// Uncorrelated assets weigh inversely to their variance
+	covariance := [][]float64{{0.01, 0}, {0, 0.04}}
+	weights, ok := minVarianceWeights(covariance, weightBounds{0, 1})
+	if !ok || math.Abs(weights[0]-0.8) > 1e-9 || math.Abs(weights[1]-0.2) > 1e-9 {
+		t.Errorf("weights = %v, want [0.8 0.2]", weights)
+	}
+
+	weights, _ = minVarianceWeights(covariance, weightBounds{0, 0.6})
+	if math.Abs(weights[0]-0.6) > 1e-6 || math.Abs(weights[1]-0.4) > 1e-6 {
+		t.Errorf("capped weights = %v, want [0.6 0.4]", weights)
+	}
+}
+
+func TestReturnCovariance(t *testing.T) {
+	closes := [][]float64{{100, 50}, {101, 49}, {100, 50}, {102, 49}}
+	daily, ok := returnCovariance(closes, 1)
+	if !ok {
+		t.Fatal("returnCovariance failed")
+	}
+	annual, _ := returnCovariance(closes, 252)
+	for i := range daily {
+		for j := range daily[i] {
+			if math.Abs(annual[i][j]-252*daily[i][j]) > 1e-12 {
+				t.Errorf("annual[%d][%d] = %v, want 252 × %v", i, j, annual[i][j], daily[i][j])
+			}
+		}
+	}
+	if daily[0][1] >= 0 {
+		t.Errorf("covariance of opposite moves = %v, want negative", daily[0][1])
+	}
+
+	if _, ok := returnCovariance(closes[:2], 252); ok {
+		t.Error("returnCovariance accepted a single return")
+	}
+}
+
+func TestAllocationStrategies(t *testing.T) {
+	// Uncorrelated daily returns of 1% and 2% on a and b
+	closes := [][]float64{{100}, {100}}
+	for i := 1; i < 14; i++ {
+		ra, rb := 0.01, 0.02
+		if i%2 == 0 {
+			ra = -ra
+		}
+		if (i-1)%4 >= 2 {
+			rb = -rb
+		}
+		closes[0] = append(closes[0], closes[0][i-1]*(1+ra))
+		closes[1] = append(closes[1], closes[1][i-1]*(1+rb))
+	}
+	markets := testMarkets([]string{"a", "b"}, closes...)
+	bars := markets[0].Data
+
+	tests := []struct {
+		strategyType models.StrategyType
+		weights      []float64
+	}{
+		{models.StrategyTypeRiskParity, []float64{2.0 / 3, 1.0 / 3}}, // Inverse volatility
+		{models.StrategyTypeMinVariance, []float64{0.8, 0.2}},        // Inverse variance
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategyType), func(t *testing.T) {
+			request := testRequest(tt.strategyType, map[string]interface{}{"lookback_period": 12.0}, bars)
+			request.AssetIDs = []string{"a", "b"}
+			result := runTestBacktest(t, request, markets...)
+
+			// Allocated once the 12 returns are in, not again within the month
+			if len(result.WeightHistory) != 1 || !result.WeightHistory[0].Date.Equal(bars[12].Date) {
+				t.Fatalf("weight history = %+v, want one allocation on bar 12", result.WeightHistory)
+			}
+			snapshot := result.WeightHistory[0]
+			for i, asset := range []string{"a", "b"} {
+				if math.Abs(snapshot.Weights[asset]-tt.weights[i]) > 1e-6 {
+					t.Errorf("%s weight = %v, want %v", asset, snapshot.Weights[asset], tt.weights[i])
+				}
+			}
+			if tt.strategyType == models.StrategyTypeRiskParity && math.Abs(snapshot.RiskContributions["a"]-0.5) > 1e-6 {
+				t.Errorf("risk contributions = %v, want equal", snapshot.RiskContributions)
+			}
+
+			// The portfolio holds the weights, to whole shares
+			daily := result.DailyReturns[12]
+			for i, asset := range []string{"a", "b"} {
+				held := daily.Positions[asset].MarketValue / daily.PortfolioValue
+				if math.Abs(held-tt.weights[i]) > 0.02 {
+					t.Errorf("%s held at %v, want %v", asset, held, tt.weights[i])
+				}
+			}
+		})
+	}
+}
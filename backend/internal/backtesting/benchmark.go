@@ -0,0 +1,146 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+)
+
+// CompareToBenchmark fills in the result's metrics relative to a benchmark
+// backtest. Both wealth indexes are compared on their common trading dates,
+// so benchmarks on another exchange calendar compound over the gaps.
+func (be *BacktestEngine) CompareToBenchmark(result, benchmark *models.BacktestResult, benchmarkID string) {
+	if result == nil || benchmark == nil {
+		return
+	}
+
+	strategyWealth, benchmarkWealth := commonWealth(result.DailyReturns, benchmark.DailyReturns)
+	if len(strategyWealth) < 2 {
+		return
+	}
+
+	metrics := result.PerformanceMetrics
+	periodsPerYear := metrics.PeriodsPerYear
+	if periodsPerYear <= 0 {
+		periodsPerYear = defaultPeriodsPerYear
+	}
+	result.PerformanceMetrics.Relative = relativeMetrics(strategyWealth, benchmarkWealth, metrics.RiskFreeRate, periodsPerYear)
+	result.PerformanceMetrics.Relative.Benchmark = benchmarkID
+}
+
+// commonWealth returns the time-weighted wealth indexes of two backtests on
+// the dates both have, rebased to 1 on the first common date
+func commonWealth(strategy, benchmark []models.DailyReturn) ([]float64, []float64) {
+	byDate := make(map[int64]float64, len(benchmark))
+	for _, daily := range benchmark {
+		byDate[civilDate(daily.Date).Unix()] = 1 + daily.CumulativeReturn
+	}
+
+	var strategyWealth, benchmarkWealth []float64
+	for _, daily := range strategy {
+		wealth, ok := byDate[civilDate(daily.Date).Unix()]
+		if !ok {
+			continue
+		}
+		strategyWealth = append(strategyWealth, 1+daily.CumulativeReturn)
+		benchmarkWealth = append(benchmarkWealth, wealth)
+	}
+	if len(strategyWealth) == 0 || strategyWealth[0] <= 0 || benchmarkWealth[0] <= 0 {
+		return nil, nil
+	}
+
+	for i := len(strategyWealth) - 1; i >= 0; i-- {
+		strategyWealth[i] /= strategyWealth[0]
+		benchmarkWealth[i] /= benchmarkWealth[0]
+	}
+	return strategyWealth, benchmarkWealth
+}
+
+// relativeMetrics computes the benchmark-relative metrics of two aligned
+// wealth indexes given an annual risk-free rate and the periods per year
+// their returns annualize with
+func relativeMetrics(strategyWealth, benchmarkWealth []float64, riskFreeRate, periodsPerYear float64) *models.RelativeMetrics {
+	n := len(strategyWealth)
+	strategyReturns := make([]float64, 0, n-1)
+	benchmarkReturns := make([]float64, 0, n-1)
+	activeReturns := make([]float64, 0, n-1)
+	for i := 1; i < n; i++ {
+		rs := periodReturn(strategyWealth[i-1], strategyWealth[i])
+		rb := periodReturn(benchmarkWealth[i-1], benchmarkWealth[i])
+		strategyReturns = append(strategyReturns, rs)
+		benchmarkReturns = append(benchmarkReturns, rb)
+		activeReturns = append(activeReturns, rs-rb)
+	}
+
+	metrics := &models.RelativeMetrics{
+		BenchmarkReturn: benchmarkWealth[n-1] - 1,
+		ExcessReturn:    strategyWealth[n-1] - benchmarkWealth[n-1],
+	}
+
+	// Beta and correlation from the daily return covariance
+	strategyMean, strategyStd, _ := meanStd(strategyReturns)
+	benchmarkMean, benchmarkStd, _ := meanStd(benchmarkReturns)
+	covariance := 0.0
+	for i := range strategyReturns {
+		covariance += (strategyReturns[i] - strategyMean) * (benchmarkReturns[i] - benchmarkMean)
+	}
+	if len(strategyReturns) > 1 {
+		covariance /= float64(len(strategyReturns) - 1)
+	}
+	if benchmarkStd > 0 {
+		metrics.Beta = covariance / (benchmarkStd * benchmarkStd)
+		if strategyStd > 0 {
+			metrics.Correlation = covariance / (strategyStd * benchmarkStd)
+		}
+	}
+
+	// Jensen's alpha: the annualized mean return left after the beta-scaled
+	// benchmark excess over the risk-free rate
+	dailyRiskFreeRate := riskFreeRate / periodsPerYear
+	metrics.Alpha = (strategyMean - dailyRiskFreeRate - metrics.Beta*(benchmarkMean-dailyRiskFreeRate)) * periodsPerYear
+
+	if activeMean, activeStd, ok := meanStd(activeReturns); ok && activeStd > 0 {
+		metrics.TrackingError = activeStd * math.Sqrt(periodsPerYear)
+		metrics.InformationRatio = activeMean * periodsPerYear / metrics.TrackingError
+	}
+
+	metrics.UpCapture = captureRatio(strategyReturns, benchmarkReturns, func(r float64) bool { return r > 0 })
+	metrics.DownCapture = captureRatio(strategyReturns, benchmarkReturns, func(r float64) bool { return r < 0 })
+
+	// Drawdown of the strategy's wealth relative to the benchmark's
+	peak := 1.0
+	for i := range strategyWealth {
+		relative := strategyWealth[i] / benchmarkWealth[i]
+		if relative > peak {
+			peak = relative
+		}
+		if drawdown := (peak - relative) / peak; drawdown > metrics.MaxRelativeDrawdown {
+			metrics.MaxRelativeDrawdown = drawdown
+		}
+	}
+	return metrics
+}
+
+// captureRatio returns the strategy's compounded return over the periods the
+// benchmark return matches, divided by the benchmark's compounded return
+// over them; 0 when the benchmark has no such periods
+func captureRatio(strategyReturns, benchmarkReturns []float64, match func(float64) bool) float64 {
+	strategyGrowth, benchmarkGrowth := 1.0, 1.0
+	for i, rb := range benchmarkReturns {
+		if match(rb) {
+			strategyGrowth *= 1 + strategyReturns[i]
+			benchmarkGrowth *= 1 + rb
+		}
+	}
+	if benchmarkGrowth == 1 {
+		return 0
+	}
+	return (strategyGrowth - 1) / (benchmarkGrowth - 1)
+}
+
+// periodReturn returns the simple return between two wealth levels
+func periodReturn(from, to float64) float64 {
+	if from <= 0 {
+		return 0
+	}
+	return to/from - 1
+}
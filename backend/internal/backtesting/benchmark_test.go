@@ -0,0 +1,46 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+	"testing"
+)
+
+func TestCompareToBenchmark(t *testing.T) {
+	// The strategy moves 10% on the days the benchmark moves 5%
+	strategyBars := dailyBars(models.MarketTypeUSStock, 100, 110, 99, 108.9)
+	benchmarkBars := dailyBars(models.MarketTypeUSStock, 100, 105, 99.75, 104.7375)
+	be := NewBacktestEngine()
+
+	result := runTestBacktest(t, testRequest(testAllIn, nil, strategyBars), testMarket("x", models.MarketTypeUSStock, strategyBars))
+	benchmark := runTestBacktest(t, testRequest(testAllIn, nil, benchmarkBars), testMarket("b", models.MarketTypeUSStock, benchmarkBars))
+	be.CompareToBenchmark(result, benchmark, "b")
+
+	relative := result.PerformanceMetrics.Relative
+	if relative == nil || relative.Benchmark != "b" {
+		t.Fatalf("relative metrics = %+v, want them against b", relative)
+	}
+	if !closeTo(relative.BenchmarkReturn, 0.047375) || !closeTo(relative.ExcessReturn, 0.089-0.047375) {
+		t.Errorf("benchmark return %v and excess %v, want 0.047375 and %v", relative.BenchmarkReturn, relative.ExcessReturn, 0.089-0.047375)
+	}
+	if math.Abs(relative.Beta-2) > 1e-9 || math.Abs(relative.Correlation-1) > 1e-9 {
+		t.Errorf("beta %v correlation %v, want 2 and 1", relative.Beta, relative.Correlation)
+	}
+
+	// With returns exactly twice the benchmark's, Jensen's alpha is the
+	// risk-free rate and the active returns are the benchmark's own
+	if math.Abs(relative.Alpha-result.PerformanceMetrics.RiskFreeRate) > 1e-9 {
+		t.Errorf("alpha = %v, want the risk-free rate %v", relative.Alpha, result.PerformanceMetrics.RiskFreeRate)
+	}
+	_, benchmarkStd, _ := meanStd([]float64{0.05, -0.05, 0.05})
+	if want := benchmarkStd * math.Sqrt(result.PerformanceMetrics.PeriodsPerYear); math.Abs(relative.TrackingError-want) > 1e-9 {
+		t.Errorf("tracking error = %v, want %v", relative.TrackingError, want)
+	}
+
+	// Dates the benchmark did not trade are compounded over
+	benchmark.DailyReturns = append(benchmark.DailyReturns[:2:2], benchmark.DailyReturns[3])
+	be.CompareToBenchmark(result, benchmark, "b")
+	if relative := result.PerformanceMetrics.Relative; !closeTo(relative.BenchmarkReturn, 0.047375) {
+		t.Errorf("benchmark return over a gap = %v, want 0.047375", relative.BenchmarkReturn)
+	}
+}
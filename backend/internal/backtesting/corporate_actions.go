@@ -0,0 +1,126 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"sort"
+	"time"
+)
+
+// sortedCorporateActions returns an asset's corporate actions in ex-date order
+func sortedCorporateActions(actions []models.CorporateAction) []models.CorporateAction {
+	sorted := append([]models.CorporateAction(nil), actions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+	return sorted
+}
+
+// adjustMarket switches a market onto its adjusted price series: every bar's
+// OHLC is scaled by its adjusted to raw close ratio, which folds splits and
+// reinvested dividends into the prices, so the explicit actions are dropped
+func adjustMarket(market *models.MarketData) error {
+	bars := make([]models.OHLCV, len(market.Data))
+	adjusted := false
+	for i, bar := range market.Data {
+		if bar.AdjClose > 0 && bar.Close > 0 {
+			factor := bar.AdjClose / bar.Close
+			bar.Open *= factor
+			bar.High *= factor
+			bar.Low *= factor
+			bar.Close = bar.AdjClose
+			adjusted = true
+		}
+		bars[i] = bar
+	}
+	if !adjusted {
+		return fmt.Errorf("no adjusted prices available for %s", market.AssetID)
+	}
+	market.Data = bars
+	market.CorporateActions = nil
+	return nil
+}
+
+// applyCorporateActions books the dividends and splits of every asset whose
+// ex-date has passed by the execution bar, before any order fills on it.
+// Positions held into the ex-date receive the dividend; shorts pay it.
+func applyCorporateActions(ctx *StrategyContext) {
+	ctx.dividends = make(map[string]float64)
+	ctx.reinvested = false
+	today := civilDate(ctx.dates[ctx.execIndex])
+	for _, asset := range ctx.Assets {
+		actions := ctx.actions[asset]
+		for ctx.nextAction[asset] < len(actions) {
+			action := actions[ctx.nextAction[asset]]
+			if civilDate(action.Date).After(today) {
+				break
+			}
+			ctx.nextAction[asset]++
+
+			switch action.Type {
+			case models.CorporateActionDividend:
+				if cash := ctx.Portfolio.payDividend(asset, action.Amount); cash != 0 {
+					ctx.dividends[asset] += cash
+				}
+			case models.CorporateActionSplit:
+				ctx.Portfolio.splitPosition(asset, action.Ratio)
+			}
+		}
+	}
+}
+
+// civilDate returns the calendar day of a time in its own location, so ex-dates
+// match bars whatever time of day either is stamped with
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// payDividend credits a cash dividend per share on the position in an asset
+// and returns the cash booked, negative for a short that owes the dividend
+func (p *Portfolio) payDividend(asset string, amount float64) float64 {
+	cash := p.Positions[asset].Quantity * amount * p.FXRate(asset)
+	p.Cash += cash
+	p.DividendIncome += cash
+	return cash
+}
+
+// splitPosition multiplies the shares held in an asset by ratio, keeping the
+// cost basis; fractional shares from the split are kept
+func (p *Portfolio) splitPosition(asset string, ratio float64) {
+	position, ok := p.Positions[asset]
+	if !ok || ratio <= 0 {
+		return
+	}
+	position.Quantity *= ratio
+	position.AvgPrice /= ratio
+	p.Positions[asset] = position
+}
+
+// Dividends returns the dividend cash booked per asset on the current
+// execution bar; shorts pay dividends and show negative amounts
+func (ctx *StrategyContext) Dividends() map[string]float64 {
+	return ctx.dividends
+}
+
+// ReinvestDividends buys every asset with the dividend it paid on the current
+// execution bar, once per bar. Cash that does not fill a whole lot stays in cash.
+func (ctx *StrategyContext) ReinvestDividends() []*models.Trade {
+	if ctx.reinvested {
+		return nil
+	}
+	ctx.reinvested = true
+
+	var trades []*models.Trade
+	for _, asset := range ctx.Assets {
+		cash := ctx.dividends[asset]
+		if cash <= 0 {
+			continue
+		}
+
+		price := ctx.executionPrice(asset)
+		if trade := ctx.fill(asset, "buy", price, ctx.affordableQuantity(asset, price, cash), models.TradeReasonDividendReinvest); trade != nil {
+			trades = append(trades, trade)
+		}
+	}
+	return trades
+}
@@ -0,0 +1,124 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestCorporateActions(t *testing.T) {
+	holdParameters := map[string]interface{}{"target_allocation": 1.0, "rebalance_frequency": "never"}
+	reinvestParameters := map[string]interface{}{"target_allocation": 1.0, "rebalance_frequency": "never", "dividend_reinvest": true}
+
+	tests := []struct {
+		name       string
+		strategy   models.StrategyType
+		parameters map[string]interface{}
+		closes     []float64
+		action     models.CorporateAction // Goes ex on bar 2
+		quantity   float64                // Held at the end
+		cash       float64
+		dividends  float64
+	}{
+		{
+			// 100 shares bought at 100 go ex a dividend of 2 at 98
+			name:       "cash dividend",
+			strategy:   models.StrategyTypeBuyAndHold,
+			parameters: holdParameters,
+			closes:     []float64{100, 100, 98, 98},
+			action:     models.CorporateAction{Type: models.CorporateActionDividend, Amount: 2},
+			quantity:   100,
+			cash:       200,
+			dividends:  200,
+		},
+		{
+			name:       "reinvested dividend",
+			strategy:   models.StrategyTypeBuyAndHold,
+			parameters: reinvestParameters,
+			closes:     []float64{100, 100, 98, 98},
+			action:     models.CorporateAction{Type: models.CorporateActionDividend, Amount: 2},
+			quantity:   102,
+			cash:       200 - 2*98,
+			dividends:  200,
+		},
+		{
+			name:       "two for one split",
+			strategy:   models.StrategyTypeBuyAndHold,
+			parameters: holdParameters,
+			closes:     []float64{100, 100, 50, 50},
+			action:     models.CorporateAction{Type: models.CorporateActionSplit, Ratio: 2},
+			quantity:   200,
+			cash:       0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := dailyBars(models.MarketTypeUSStock, tt.closes...)
+			market := testMarket("x", models.MarketTypeUSStock, bars)
+			tt.action.Date = bars[2].Date
+			market.CorporateActions = []models.CorporateAction{tt.action}
+			result := runTestBacktest(t, testRequest(tt.strategy, tt.parameters, bars), market)
+
+			last := result.DailyReturns[len(bars)-1]
+			if last.Position.Quantity != tt.quantity || !closeTo(last.Cash, tt.cash) {
+				t.Errorf("ended with %v shares and %v cash, want %v and %v", last.Position.Quantity, last.Cash, tt.quantity, tt.cash)
+			}
+			if got := result.DailyReturns[2].Dividends; !closeTo(got, tt.dividends) {
+				t.Errorf("dividends on the ex-date = %v, want %v", got, tt.dividends)
+			}
+			if got := result.PerformanceMetrics.DividendIncome; !closeTo(got, tt.dividends) {
+				t.Errorf("dividend income = %v, want %v", got, tt.dividends)
+			}
+
+			// Going ex neither makes nor loses money
+			if got := result.DailyReturns[2].PortfolioValue; !closeTo(got, 10000) {
+				t.Errorf("value on the ex-date = %v, want 10000", got)
+			}
+		})
+	}
+}
+
+func TestShortPaysDividend(t *testing.T) {
+	bars := dailyBars(models.MarketTypeUSStock, 100, 100, 99)
+	market := testMarket("x", models.MarketTypeUSStock, bars)
+	market.CorporateActions = []models.CorporateAction{{Date: bars[2].Date, Type: models.CorporateActionDividend, Amount: 1}}
+	request := testRequest(testAllShort, nil, bars)
+	request.Margin = &models.MarginConfig{AllowShort: true}
+	result := runTestBacktest(t, request, market)
+
+	// The short of 200 owes the dividend and gains as much on the price
+	if got := result.PerformanceMetrics.DividendIncome; !closeTo(got, -200) {
+		t.Errorf("dividend income = %v, want -200", got)
+	}
+	if got := result.DailyReturns[2].PortfolioValue; !closeTo(got, 10000) {
+		t.Errorf("value on the ex-date = %v, want 10000", got)
+	}
+}
+
+func TestAdjustedPrices(t *testing.T) {
+	// Adjusted closes fold a later dividend of 2 into the first two bars
+	bars := dailyBars(models.MarketTypeUSStock, 100, 100, 98, 98)
+	bars[0].AdjClose, bars[1].AdjClose, bars[2].AdjClose, bars[3].AdjClose = 98, 98, 98, 98
+	market := testMarket("x", models.MarketTypeUSStock, bars)
+	market.CorporateActions = []models.CorporateAction{{Date: bars[2].Date, Type: models.CorporateActionDividend, Amount: 2}}
+
+	request := testRequest(testAllIn, nil, bars)
+	request.PriceAdjustment = models.PriceAdjustmentAdjusted
+	result := runTestBacktest(t, request, market)
+
+	buys := tradesBy(result, "buy")
+	if len(buys) != 1 || buys[0].Price != 98 || buys[0].Quantity != 102 {
+		t.Fatalf("buys = %+v, want 102 at the adjusted 98", buys)
+	}
+	if result.PerformanceMetrics.DividendIncome != 0 || !closeTo(result.PerformanceMetrics.TotalReturn, 0) {
+		t.Errorf("dividend income %v and return %v, want the dividend left in the prices", result.PerformanceMetrics.DividendIncome, result.PerformanceMetrics.TotalReturn)
+	}
+
+	// Markets without adjusted closes cannot be adjusted
+	for i := range bars {
+		bars[i].AdjClose = 0
+	}
+	if _, err := NewBacktestEngine().RunBacktest(request, market); err == nil {
+		t.Error("adjusted backtest ran without adjusted prices")
+	}
+}
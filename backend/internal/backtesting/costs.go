@@ -0,0 +1,353 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+)
+
+// CommissionModel computes the fees charged on a fill
+type CommissionModel interface {
+	Commission(action string, price, quantity float64) float64
+}
+
+// SlippageModel returns the fractional price concession paid on a fill.
+// bars holds the history up to and including the fill bar.
+type SlippageModel interface {
+	Slippage(action string, price, quantity float64, bars []models.OHLCV) float64
+}
+
+// CostModel combines the commission and slippage models applied to fills
+type CostModel struct {
+	Commission CommissionModel
+	Slippage   SlippageModel
+}
+
+// commission returns the fee for a fill, zero without a commission model
+func (cm CostModel) commission(action string, price, quantity float64) float64 {
+	if cm.Commission == nil || quantity <= 0 {
+		return 0
+	}
+	return cm.Commission.Commission(action, price, quantity)
+}
+
+// fillPrice applies slippage against the trader: buys fill higher, sells lower
+func (cm CostModel) fillPrice(action string, price, quantity float64, bars []models.OHLCV) float64 {
+	if cm.Slippage == nil || quantity <= 0 {
+		return price
+	}
+	slippage := cm.Slippage.Slippage(action, price, quantity, bars)
+	if action == "sell" {
+		return price * (1 - slippage)
+	}
+	return price * (1 + slippage)
+}
+
+// Commission models
+
+// PercentageCommission charges a fixed rate of the traded amount
+type PercentageCommission struct {
+	Rate float64
+}
+
+// Commission implements CommissionModel
+func (c PercentageCommission) Commission(action string, price, quantity float64) float64 {
+	return price * quantity * c.Rate
+}
+
+// PerShareCommission charges a fixed fee per unit traded
+type PerShareCommission struct {
+	PerShare float64
+}
+
+// Commission implements CommissionModel
+func (c PerShareCommission) Commission(action string, price, quantity float64) float64 {
+	return quantity * c.PerShare
+}
+
+// MinimumCommission enforces a minimum ticket fee on top of another model
+type MinimumCommission struct {
+	Base    CommissionModel
+	Minimum float64
+}
+
+// Commission implements CommissionModel
+func (c MinimumCommission) Commission(action string, price, quantity float64) float64 {
+	return math.Max(c.Base.Commission(action, price, quantity), c.Minimum)
+}
+
+// StampDuty charges a rate of the traded amount on sells only, as on A-shares
+type StampDuty struct {
+	Rate float64
+}
+
+// Commission implements CommissionModel
+func (c StampDuty) Commission(action string, price, quantity float64) float64 {
+	if action != "sell" {
+		return 0
+	}
+	return price * quantity * c.Rate
+}
+
+// BinanceCommission charges Binance spot maker or taker fees. Engine fills are
+// market orders, so the taker rate applies unless Maker is set.
+type BinanceCommission struct {
+	MakerRate float64
+	TakerRate float64
+	Maker     bool
+}
+
+// Commission implements CommissionModel
+func (c BinanceCommission) Commission(action string, price, quantity float64) float64 {
+	rate := c.TakerRate
+	if c.Maker {
+		rate = c.MakerRate
+	}
+	return price * quantity * rate
+}
+
+// CompositeCommission sums several commission models, e.g. broker fee plus stamp duty
+type CompositeCommission []CommissionModel
+
+// Commission implements CommissionModel
+func (c CompositeCommission) Commission(action string, price, quantity float64) float64 {
+	total := 0.0
+	for _, model := range c {
+		total += model.Commission(action, price, quantity)
+	}
+	return total
+}
+
+// Slippage models
+
+// FixedSlippage concedes a fixed number of basis points on every fill
+type FixedSlippage struct {
+	Bps float64
+}
+
+// Slippage implements SlippageModel
+func (s FixedSlippage) Slippage(action string, price, quantity float64, bars []models.OHLCV) float64 {
+	return s.Bps / 10000
+}
+
+// VolatilitySlippage scales slippage with the recent standard deviation of daily returns
+type VolatilitySlippage struct {
+	Multiplier float64
+	Window     int
+}
+
+// Slippage implements SlippageModel
+func (s VolatilitySlippage) Slippage(action string, price, quantity float64, bars []models.OHLCV) float64 {
+	if len(bars) < 3 {
+		return 0
+	}
+	window := s.Window
+	if window < 2 || window > len(bars)-1 {
+		window = len(bars) - 1
+	}
+
+	returns := make([]float64, 0, window)
+	for i := len(bars) - window; i < len(bars); i++ {
+		if bars[i-1].Close > 0 {
+			returns = append(returns, bars[i].Close/bars[i-1].Close-1)
+		}
+	}
+	return s.Multiplier * stdDev(returns)
+}
+
+// VolumeParticipationSlippage models market impact growing with the square
+// root of the order's share of the bar's volume
+type VolumeParticipationSlippage struct {
+	ImpactCoefficient float64
+}
+
+// Slippage implements SlippageModel
+func (s VolumeParticipationSlippage) Slippage(action string, price, quantity float64, bars []models.OHLCV) float64 {
+	if len(bars) == 0 || bars[len(bars)-1].Volume <= 0 {
+		return 0
+	}
+	participation := quantity / float64(bars[len(bars)-1].Volume)
+	return s.ImpactCoefficient * math.Sqrt(participation)
+}
+
+// stdDev returns the sample standard deviation of values
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += math.Pow(v-mean, 2)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
+}
+
+// DefaultCostModels returns the built-in cost model for each market type
+func DefaultCostModels() map[models.MarketType]CostModel {
+	aShareStock := CostModel{
+		Commission: CompositeCommission{
+			MinimumCommission{Base: PercentageCommission{Rate: 0.00025}, Minimum: 5}, // 佣金万2.5, 最低5元
+			StampDuty{Rate: 0.0005}, // 印花税 0.05%, 仅卖出
+		},
+	}
+	usEquity := CostModel{
+		Commission: MinimumCommission{Base: PerShareCommission{PerShare: 0.005}, Minimum: 1},
+	}
+	hkEquity := CostModel{
+		Commission: CompositeCommission{
+			MinimumCommission{Base: PercentageCommission{Rate: 0.0003}, Minimum: 50},
+			PercentageCommission{Rate: 0.001}, // 港股印花税双边收取
+		},
+	}
+
+	return map[models.MarketType]CostModel{
+		models.MarketTypeAShareIndex: {Commission: PercentageCommission{Rate: 0.0003}},
+		models.MarketTypeAShareStock: aShareStock,
+		models.MarketTypeUSIndex:     usEquity,
+		models.MarketTypeUSStock:     usEquity,
+		models.MarketTypeETF:         usEquity,
+		models.MarketTypeHKIndex:     {Commission: PercentageCommission{Rate: 0.0003}},
+		models.MarketTypeHKStock:     hkEquity,
+		models.MarketTypeCrypto:      {Commission: BinanceCommission{MakerRate: 0.001, TakerRate: 0.001}},
+	}
+}
+
+// buildCostModel resolves the cost model for a run: the market default,
+// overridden by the request's cost config, overridden by the strategy's
+// risk management rates
+func buildCostModel(base CostModel, config *models.CostConfig, risk *models.RiskManagementConfig) (CostModel, error) {
+	costs := base
+
+	if config != nil {
+		if err := validateCostConfig(config); err != nil {
+			return CostModel{}, err
+		}
+		if commission := commissionFromConfig(config); commission != nil {
+			costs.Commission = commission
+		}
+		if config.SlippageModel != "" {
+			costs.Slippage = slippageFromConfig(config)
+		}
+	}
+
+	if risk != nil {
+		if risk.CommissionRate < 0 || risk.SlippageRate < 0 {
+			return CostModel{}, fmt.Errorf("commission_rate and slippage_rate must not be negative")
+		}
+		if risk.CommissionRate > 0 {
+			costs.Commission = PercentageCommission{Rate: risk.CommissionRate}
+		}
+		if risk.SlippageRate > 0 {
+			costs.Slippage = FixedSlippage{Bps: risk.SlippageRate * 10000}
+		}
+	}
+
+	return costs, nil
+}
+
+// validateCostConfig checks a request's cost config
+func validateCostConfig(config *models.CostConfig) error {
+	switch config.CommissionModel {
+	case "", "percentage", "per_share", "binance", "none":
+	default:
+		return fmt.Errorf("unsupported commission_model: %s", config.CommissionModel)
+	}
+	switch config.SlippageModel {
+	case "", "none", "fixed_bps", "volatility", "volume_participation":
+	default:
+		return fmt.Errorf("unsupported slippage_model: %s", config.SlippageModel)
+	}
+
+	values := map[string]float64{
+		"commission_rate":       config.CommissionRate,
+		"per_share_fee":         config.PerShareFee,
+		"min_commission":        config.MinCommission,
+		"stamp_duty_rate":       config.StampDutyRate,
+		"maker_rate":            config.MakerRate,
+		"taker_rate":            config.TakerRate,
+		"slippage_bps":          config.SlippageBps,
+		"volatility_multiplier": config.VolatilityMultiplier,
+		"impact_coefficient":    config.ImpactCoefficient,
+	}
+	for name, value := range values {
+		if value < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if config.VolatilityWindow < 0 {
+		return fmt.Errorf("volatility_window must not be negative")
+	}
+
+	// Without a commission model the market default applies, which the
+	// commission settings cannot adjust
+	if config.CommissionModel == "" {
+		settings := []struct {
+			name string
+			set  bool
+		}{
+			{"commission_rate", config.CommissionRate > 0},
+			{"per_share_fee", config.PerShareFee > 0},
+			{"min_commission", config.MinCommission > 0},
+			{"stamp_duty_rate", config.StampDutyRate > 0},
+			{"maker_rate", config.MakerRate > 0},
+			{"taker_rate", config.TakerRate > 0},
+			{"order_liquidity", config.OrderLiquidity != ""},
+		}
+		for _, setting := range settings {
+			if setting.set {
+				return fmt.Errorf("%s requires a commission_model", setting.name)
+			}
+		}
+	}
+	return nil
+}
+
+// commissionFromConfig builds a commission model from a cost config, or nil
+// when the config does not override the commission
+func commissionFromConfig(config *models.CostConfig) CommissionModel {
+	var base CommissionModel
+	switch config.CommissionModel {
+	case "percentage":
+		base = PercentageCommission{Rate: config.CommissionRate}
+	case "per_share":
+		base = PerShareCommission{PerShare: config.PerShareFee}
+	case "binance":
+		base = BinanceCommission{MakerRate: config.MakerRate, TakerRate: config.TakerRate, Maker: config.OrderLiquidity == "maker"}
+	case "none":
+		return CompositeCommission{}
+	default:
+		return nil
+	}
+
+	if config.MinCommission > 0 {
+		base = MinimumCommission{Base: base, Minimum: config.MinCommission}
+	}
+	if config.StampDutyRate > 0 {
+		base = CompositeCommission{base, StampDuty{Rate: config.StampDutyRate}}
+	}
+	return base
+}
+
+// slippageFromConfig builds a slippage model from a cost config
+func slippageFromConfig(config *models.CostConfig) SlippageModel {
+	switch config.SlippageModel {
+	case "fixed_bps":
+		return FixedSlippage{Bps: config.SlippageBps}
+	case "volatility":
+		window := config.VolatilityWindow
+		if window == 0 {
+			window = 20
+		}
+		return VolatilitySlippage{Multiplier: config.VolatilityMultiplier, Window: window}
+	case "volume_participation":
+		return VolumeParticipationSlippage{ImpactCoefficient: config.ImpactCoefficient}
+	default: // none
+		return nil
+	}
+}
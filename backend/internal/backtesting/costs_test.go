@@ -0,0 +1,123 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+	"testing"
+)
+
+func TestDefaultCommissions(t *testing.T) {
+	costs := DefaultCostModels()
+	tests := []struct {
+		name            string
+		market          models.MarketType
+		action          string
+		price, quantity float64
+		want            float64
+	}{
+		{"A-share minimum fee", models.MarketTypeAShareStock, "buy", 10, 100, 5},
+		{"A-share sell adds stamp duty", models.MarketTypeAShareStock, "sell", 10, 10000, 25 + 50},
+		{"US per share", models.MarketTypeUSStock, "buy", 100, 1000, 5},
+		{"US minimum ticket", models.MarketTypeUSStock, "sell", 100, 10, 1},
+		{"HK stamp duty both sides", models.MarketTypeHKStock, "buy", 100, 10000, 300 + 1000},
+		{"crypto taker", models.MarketTypeCrypto, "sell", 50000, 0.1, 5},
+	}
+
+	for _, tt := range tests {
+		got := costs[tt.market].commission(tt.action, tt.price, tt.quantity)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: commission = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFillPrice(t *testing.T) {
+	costs := CostModel{Slippage: FixedSlippage{Bps: 10}}
+	if got := costs.fillPrice("buy", 100, 1, nil); math.Abs(got-100.1) > 1e-9 {
+		t.Errorf("buy fill = %v, want 100.1", got)
+	}
+	if got := costs.fillPrice("sell", 100, 1, nil); math.Abs(got-99.9) > 1e-9 {
+		t.Errorf("sell fill = %v, want 99.9", got)
+	}
+	if got := (CostModel{}).fillPrice("buy", 100, 1, nil); got != 100 {
+		t.Errorf("fill without slippage = %v, want 100", got)
+	}
+}
+
+func TestBuildCostModel(t *testing.T) {
+	base := DefaultCostModels()[models.MarketTypeAShareStock]
+	tests := []struct {
+		name    string
+		config  *models.CostConfig
+		risk    *models.RiskManagementConfig
+		want    float64 // Commission on selling 10000 at 10
+		wantErr bool
+	}{
+		{"market default", nil, nil, 75, false},
+		{"percentage with minimum", &models.CostConfig{CommissionModel: "percentage", CommissionRate: 0.0001, MinCommission: 20}, nil, 20, false},
+		{"percentage with stamp duty", &models.CostConfig{CommissionModel: "percentage", CommissionRate: 0.001, StampDutyRate: 0.001}, nil, 200, false},
+		{"binance maker", &models.CostConfig{CommissionModel: "binance", MakerRate: 0.0002, TakerRate: 0.001, OrderLiquidity: "maker"}, nil, 20, false},
+		{"no commission", &models.CostConfig{CommissionModel: "none"}, nil, 0, false},
+		{"slippage only keeps the market commission", &models.CostConfig{SlippageModel: "fixed_bps", SlippageBps: 5}, nil, 75, false},
+		{"risk management rate wins", &models.CostConfig{CommissionModel: "none"}, &models.RiskManagementConfig{CommissionRate: 0.002}, 200, false},
+		{"commission rate without a model", &models.CostConfig{CommissionRate: 0.001}, nil, 0, true},
+		{"order liquidity without a model", &models.CostConfig{OrderLiquidity: "maker"}, nil, 0, true},
+		{"unknown model", &models.CostConfig{CommissionModel: "flat"}, nil, 0, true},
+		{"negative rate", &models.CostConfig{CommissionModel: "percentage", CommissionRate: -0.001}, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		costs, err := buildCostModel(base, tt.config, tt.risk)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got := costs.commission("sell", 10, 10000); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: commission = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBacktestTradingCosts(t *testing.T) {
+	bars := dailyBars(models.MarketTypeUSStock, 100, 100)
+	tests := []struct {
+		name       string
+		costs      *models.CostConfig
+		price      float64
+		quantity   float64
+		commission float64
+	}{
+		// 100 shares plus the $1 minimum ticket would overdraw the cash
+		{"market default", nil, 100, 99, 1},
+		{
+			"request override",
+			&models.CostConfig{CommissionModel: "percentage", CommissionRate: 0.001, SlippageModel: "fixed_bps", SlippageBps: 10},
+			100.1, 99, 99 * 100.1 * 0.001,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := testRequest(testAllIn, nil, bars)
+			request.Costs = tt.costs
+			result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+			buys := tradesBy(result, "buy")
+			if len(buys) != 1 || buys[0].Price != tt.price || buys[0].Quantity != tt.quantity {
+				t.Fatalf("buys = %+v, want %v at %v", buys, tt.quantity, tt.price)
+			}
+			if !closeTo(buys[0].Commission, tt.commission) || !closeTo(buys[0].Slippage, (tt.price-100)*tt.quantity) {
+				t.Errorf("commission %v slippage %v, want %v and %v", buys[0].Commission, buys[0].Slippage, tt.commission, (tt.price-100)*tt.quantity)
+			}
+
+			// Costs come out of the cash and the marked position is worth the close
+			want := 10000 - tt.price*tt.quantity - tt.commission + 100*tt.quantity
+			if got := result.DailyReturns[1].PortfolioValue; !closeTo(got, want) {
+				t.Errorf("portfolio value = %v, want %v", got, want)
+			}
+		})
+	}
+}
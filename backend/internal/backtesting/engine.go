@@ -2,26 +2,73 @@ package backtesting
 
 import (
 	"fmt"
+	"macro_strategy/internal/calendar"
 	"macro_strategy/internal/models"
-	"math"
-	"sort"
 	"time"
 )
 
 // BacktestEngine handles strategy backtesting
 type BacktestEngine struct {
-	commissionRate float64 // Commission rate per trade
+	costModels  map[models.MarketType]CostModel // Default trading costs per market
+	defaultCost CostModel                       // Used for markets without a registered model
+	lotRules    map[models.MarketType]LotRule   // Default lot and tick rules per market
 }
 
-// NewBacktestEngine creates a new backtest engine
+// NewBacktestEngine creates a new backtest engine with the built-in market cost and lot models
 func NewBacktestEngine() *BacktestEngine {
 	return &BacktestEngine{
-		commissionRate: 0.0003, // 0.03% commission rate
+		costModels:  DefaultCostModels(),
+		defaultCost: CostModel{Commission: PercentageCommission{Rate: 0.0003}}, // 0.03% commission rate
+		lotRules:    DefaultLotRules(),
 	}
 }
 
+// SetCostModel registers the default cost model for a market type
+func (be *BacktestEngine) SetCostModel(marketType models.MarketType, costs CostModel) {
+	be.costModels[marketType] = costs
+}
+
+// SetLotRule registers the default lot rule for a market type
+func (be *BacktestEngine) SetLotRule(marketType models.MarketType, rule LotRule) {
+	be.lotRules[marketType] = rule
+}
+
+// lotRuleFor resolves the lot rule for market data: the market default
+// overridden by the asset's or exchange's trading rules. Markets without a
+// registered rule trade in whole units.
+func (be *BacktestEngine) lotRuleFor(marketData *models.MarketData) LotRule {
+	base, ok := be.lotRules[marketData.MarketType]
+	if !ok {
+		base = LotRule{LotSize: 1}
+	}
+	return newLotRule(base, marketData.TradingRules)
+}
+
+// costModelFor resolves the cost model for a request on a market
+func (be *BacktestEngine) costModelFor(request models.BacktestRequest, marketType models.MarketType) (CostModel, error) {
+	base, ok := be.costModels[marketType]
+	if !ok {
+		base = be.defaultCost
+	}
+	return buildCostModel(base, request.Costs, request.Strategy.RiskManagement)
+}
+
 // RunBacktest executes a backtest for given request
 func (be *BacktestEngine) RunBacktest(request models.BacktestRequest, marketData *models.MarketData) (*models.BacktestResult, error) {
+	return be.run(request, []*models.MarketData{marketData})
+}
+
+// RunPortfolioBacktest executes a portfolio backtest over the request's
+// AssetIDs, given the market data of every asset
+func (be *BacktestEngine) RunPortfolioBacktest(request models.BacktestRequest, marketData []*models.MarketData) (*models.BacktestResult, error) {
+	if len(request.AssetIDs) == 0 {
+		return nil, fmt.Errorf("invalid request: asset_ids is required for a portfolio backtest")
+	}
+	return be.run(request, marketData)
+}
+
+// run executes a backtest over one or more assets
+func (be *BacktestEngine) run(request models.BacktestRequest, marketData []*models.MarketData) (*models.BacktestResult, error) {
 	startTime := time.Now()
 
 	// Validate request
@@ -29,39 +76,117 @@ func (be *BacktestEngine) RunBacktest(request models.BacktestRequest, marketData
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
 
-	// Filter market data to backtest period
-	filteredData := be.filterDataByDateRange(marketData.Data, request.StartDate, request.EndDate)
-	if len(filteredData) == 0 {
-		return nil, fmt.Errorf("no market data available for the specified period")
+	// Filter each asset's market data to the backtest period
+	assets, markets, err := be.backtestMarkets(request, marketData)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.PriceAdjustment == models.PriceAdjustmentAdjusted {
+		for _, market := range markets {
+			if err := adjustMarket(market); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Execute strategy
-	trades, dailyReturns, err := be.executeStrategy(request, filteredData)
+	run, err := be.executeStrategy(request, assets, markets)
 	if err != nil {
 		return nil, fmt.Errorf("strategy execution failed: %w", err)
 	}
 
 	// Calculate performance metrics
-	metrics := be.calculatePerformanceMetrics(dailyReturns, trades)
+	basis := newReturnBasis(request, assets, markets, run.dailyReturns)
+	metrics := be.calculatePerformanceMetrics(run.dailyReturns, run.trades, basis, varConfidence(request))
+	metrics.FinancingCost = run.financingCost
+	metrics.DividendIncome = run.dividendIncome
 
 	// Create result
 	result := &models.BacktestResult{
-		ID:                 generateBacktestID(),
-		Request:            request,
-		Trades:             trades,
-		DailyReturns:       dailyReturns,
-		PerformanceMetrics: metrics,
-		CreatedAt:          startTime,
-		Duration:           time.Since(startTime),
+		ID:                   generateBacktestID(),
+		Request:              request,
+		Trades:               run.trades,
+		DailyReturns:         run.dailyReturns,
+		PerformanceMetrics:   metrics,
+		CircuitBreakerEvents: run.circuitBreakerEvents,
+		Holdings:             run.holdings,
+		ExecutionTiming:      executionTiming(request),
+		BaseCurrency:         run.baseCurrency,
+		CreatedAt:            startTime,
+		Duration:             time.Since(startTime),
+	}
+	result.DrawdownEpisodes = be.calculateDrawdownEpisodes(run.dailyReturns, drawdownTopN(request))
+	if reporter, ok := run.strategy.(ResultReporter); ok {
+		reporter.ReportResult(result)
 	}
 
 	return result, nil
 }
 
+// backtestMarkets resolves the traded assets in request order and filters
+// their market data to the backtest period. A single-asset backtest trades the
+// asset of its one market data set.
+func (be *BacktestEngine) backtestMarkets(request models.BacktestRequest, marketData []*models.MarketData) ([]string, map[string]*models.MarketData, error) {
+	if len(request.AssetIDs) == 0 {
+		if len(marketData) != 1 || marketData[0] == nil {
+			return nil, nil, fmt.Errorf("a single-asset backtest needs one market data set")
+		}
+		market := *marketData[0]
+		if market.AssetID == "" {
+			market.AssetID = request.IndexID
+		}
+		market.Data = be.filterDataByDateRange(market.Data, request.StartDate, request.EndDate)
+		if len(market.Data) == 0 {
+			return nil, nil, fmt.Errorf("no market data available for the specified period")
+		}
+		return []string{market.AssetID}, map[string]*models.MarketData{market.AssetID: &market}, nil
+	}
+
+	byID := make(map[string]*models.MarketData, len(marketData))
+	for _, md := range marketData {
+		if md != nil {
+			byID[md.AssetID] = md
+		}
+	}
+
+	markets := make(map[string]*models.MarketData, len(request.AssetIDs))
+	for _, asset := range request.AssetIDs {
+		md, ok := byID[asset]
+		if !ok {
+			return nil, nil, fmt.Errorf("no market data for asset %s", asset)
+		}
+		market := *md
+		market.Data = be.filterDataByDateRange(md.Data, request.StartDate, request.EndDate)
+		if len(market.Data) == 0 {
+			return nil, nil, fmt.Errorf("no market data available for %s in the specified period", asset)
+		}
+		markets[asset] = &market
+	}
+	return request.AssetIDs, markets, nil
+}
+
 // validateRequest validates the backtest request
 func (be *BacktestEngine) validateRequest(request models.BacktestRequest) error {
-	if request.IndexID == "" {
-		return fmt.Errorf("index_id is required")
+	if request.IndexID == "" && len(request.AssetIDs) == 0 {
+		return fmt.Errorf("index_id or asset_ids is required")
+	}
+	seen := make(map[string]bool, len(request.AssetIDs))
+	for _, asset := range request.AssetIDs {
+		if asset == "" || seen[asset] {
+			return fmt.Errorf("asset_ids must be distinct and non-empty")
+		}
+		seen[asset] = true
+	}
+	switch request.DateAlignment {
+	case "", models.DateAlignmentUnion, models.DateAlignmentIntersection:
+	default:
+		return fmt.Errorf("unsupported date_alignment: %s", request.DateAlignment)
+	}
+	switch request.PriceAdjustment {
+	case "", models.PriceAdjustmentNone, models.PriceAdjustmentAdjusted:
+	default:
+		return fmt.Errorf("unsupported price_adjustment: %s", request.PriceAdjustment)
 	}
 	if request.InitialCash <= 0 {
 		return fmt.Errorf("initial_cash must be positive")
@@ -69,12 +194,59 @@ func (be *BacktestEngine) validateRequest(request models.BacktestRequest) error
 	if request.StartDate.After(request.EndDate) {
 		return fmt.Errorf("start_date must be before end_date")
 	}
-	if request.Strategy.Type == "" {
-		return fmt.Errorf("strategy type is required")
+	if err := ValidateStrategyConfig(request.Strategy); err != nil {
+		return err
+	}
+	if err := validateRiskManagementConfig(request.Strategy.RiskManagement); err != nil {
+		return fmt.Errorf("invalid risk_management: %w", err)
+	}
+	if err := validateMarginConfig(request.Margin); err != nil {
+		return fmt.Errorf("invalid margin: %w", err)
+	}
+	if err := validateRiskFreeConfig(request.RiskFree); err != nil {
+		return fmt.Errorf("invalid risk_free: %w", err)
+	}
+	if request.VaRConfidence != 0 && (request.VaRConfidence < 0.5 || request.VaRConfidence >= 1) {
+		return fmt.Errorf("var_confidence must be at least 0.5 and below 1")
+	}
+	if request.DrawdownTopN < 0 {
+		return fmt.Errorf("drawdown_top_n must not be negative")
+	}
+	switch request.ExecutionTiming {
+	case "", models.ExecutionTimingClose, models.ExecutionTimingNextOpen,
+		models.ExecutionTimingNextClose, models.ExecutionTimingNextVWAP:
+	default:
+		return fmt.Errorf("unsupported execution_timing: %s", request.ExecutionTiming)
 	}
 	return nil
 }
 
+// executionTiming returns the request's execution timing, defaulting to
+// filling on the signal bar's close
+func executionTiming(request models.BacktestRequest) models.ExecutionTiming {
+	if request.ExecutionTiming == "" {
+		return models.ExecutionTimingClose
+	}
+	return request.ExecutionTiming
+}
+
+// varConfidence returns the request's VaR confidence level, defaulting to 95%
+func varConfidence(request models.BacktestRequest) float64 {
+	if request.VaRConfidence == 0 {
+		return defaultVaRConfidence
+	}
+	return request.VaRConfidence
+}
+
+// drawdownTopN returns how many drawdown episodes the request reports,
+// defaulting to the 10 deepest
+func drawdownTopN(request models.BacktestRequest) int {
+	if request.DrawdownTopN == 0 {
+		return defaultDrawdownTopN
+	}
+	return request.DrawdownTopN
+}
+
 // filterDataByDateRange filters market data by date range
 func (be *BacktestEngine) filterDataByDateRange(data []models.OHLCV, startDate, endDate time.Time) []models.OHLCV {
 	var filtered []models.OHLCV
@@ -87,466 +259,287 @@ func (be *BacktestEngine) filterDataByDateRange(data []models.OHLCV, startDate,
 	return filtered
 }
 
-// executeStrategy executes the trading strategy
-func (be *BacktestEngine) executeStrategy(request models.BacktestRequest, data []models.OHLCV) ([]models.Trade, []models.DailyReturn, error) {
-	switch request.Strategy.Type {
-	case models.StrategyTypeMonthlyRotation:
-		return be.executeMonthlyRotationStrategy(request, data)
-	case models.StrategyTypeBuyAndHold:
-		return be.executeBuyAndHoldStrategy(request, data)
-	default:
-		return nil, nil, fmt.Errorf("unsupported strategy type: %s", request.Strategy.Type)
-	}
+// strategyRun holds the raw output of a strategy execution
+type strategyRun struct {
+	strategy             Strategy
+	trades               []models.Trade
+	dailyReturns         []models.DailyReturn
+	circuitBreakerEvents []models.CircuitBreakerEvent
+	holdings             []models.AssetHoldings
+	financingCost        float64
+	dividendIncome       float64
+	baseCurrency         models.Currency
 }
 
-// executeMonthlyRotationStrategy executes monthly rotation strategy
-func (be *BacktestEngine) executeMonthlyRotationStrategy(request models.BacktestRequest, data []models.OHLCV) ([]models.Trade, []models.DailyReturn, error) {
-	// Parse strategy parameters
-	params, err := be.parseMonthlyRotationParams(request.Strategy.Parameters)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var trades []models.Trade
-	var dailyReturns []models.DailyReturn
-
-	cash := request.InitialCash
-	position := models.Position{}
-
-	// Store initial portfolio value for calculations
-	initialPortfolioValue := request.InitialCash
-
-	for i, dataPoint := range data {
-		currentDate := dataPoint.Date
-		currentPrice := dataPoint.Close
-
-		// Check if we should buy
-		if be.shouldBuy(currentDate, params.BuyDaysBeforeMonthEnd, data, i) && position.Quantity == 0 {
-			// Buy with all available cash
-			quantity := math.Floor(cash / currentPrice)
-			if quantity > 0 {
-				amount := quantity * currentPrice
-				commission := amount * be.commissionRate
-				totalCost := amount + commission
-
-				if totalCost <= cash {
-					trade := models.Trade{
-						Date:       currentDate,
-						Action:     "buy",
-						Price:      currentPrice,
-						Quantity:   quantity,
-						Amount:     amount,
-						Commission: commission,
-					}
-					trades = append(trades, trade)
-
-					cash -= totalCost
-					position.Quantity = quantity
-					position.AvgPrice = currentPrice
-				}
-			}
-		}
-
-		// Check if we should sell
-		if be.shouldSell(currentDate, params.SellDaysAfterMonthStart, data, i) && position.Quantity > 0 {
-			// Sell all positions
-			amount := position.Quantity * currentPrice
-			commission := amount * be.commissionRate
-			netAmount := amount - commission
-
-			trade := models.Trade{
-				Date:       currentDate,
-				Action:     "sell",
-				Price:      currentPrice,
-				Quantity:   position.Quantity,
-				Amount:     amount,
-				Commission: commission,
-			}
-			trades = append(trades, trade)
-
-			cash += netAmount
-			position = models.Position{} // Reset position
-		}
-
-		// Update position market value if holding
-		if position.Quantity > 0 {
-			position.MarketValue = position.Quantity * currentPrice
-			position.UnrealizedPL = position.MarketValue - (position.Quantity * position.AvgPrice)
-		}
+// executeStrategy executes the trading strategy registered for the request
+// over the assets' market data, aligned to one timeline
+func (be *BacktestEngine) executeStrategy(request models.BacktestRequest, assets []string, markets map[string]*models.MarketData) (*strategyRun, error) {
+	def, ok := GetStrategyDefinition(request.Strategy.Type)
+	if !ok {
+		return nil, fmt.Errorf("unsupported strategy type: %s", request.Strategy.Type)
+	}
+	if len(assets) > 1 && !def.MultiAsset {
+		return nil, fmt.Errorf("%s trades a single asset and cannot run in portfolio mode", request.Strategy.Type)
+	}
 
-		// Calculate daily portfolio value
-		portfolioValue := cash + position.MarketValue
-		dailyReturn := 0.0
-		cumulativeReturn := (portfolioValue - initialPortfolioValue) / initialPortfolioValue
+	strategy, err := def.Factory(request.Strategy.Parameters)
+	if err != nil {
+		return nil, err
+	}
 
-		// Calculate daily return correctly: only after first day
-		if i > 0 {
-			prevValue := dailyReturns[i-1].PortfolioValue
-			if prevValue > 0 {
-				dailyReturn = (portfolioValue - prevValue) / prevValue
-			}
+	data := make(map[string][]models.OHLCV, len(assets))
+	for _, asset := range assets {
+		data[asset] = markets[asset].Data
+	}
+	dates, series, err := alignSeries(data, assets, request.DateAlignment)
+	if err != nil {
+		return nil, err
+	}
+	for _, asset := range assets {
+		market := markets[asset]
+		if err := calendar.New(market.MarketType, market.TradingHours).Validate(dates[0], dates[len(dates)-1]); err != nil {
+			return nil, fmt.Errorf("%s: %w", asset, err)
 		}
+	}
+	base := baseCurrency(request, assets, markets)
+	fxRates, err := alignFXRates(base, assets, markets, dates)
+	if err != nil {
+		return nil, err
+	}
 
-		dailyReturns = append(dailyReturns, models.DailyReturn{
-			Date:             currentDate,
-			PortfolioValue:   portfolioValue,
-			DailyReturn:      dailyReturn,
-			CumulativeReturn: cumulativeReturn,
-			Cash:             cash,
-			Position:         position,
-		})
-	}
-
-	// Force sell any remaining positions at the end of backtest period
-	if position.Quantity > 0 {
-		lastDay := data[len(data)-1]
-		lastPrice := lastDay.Close
-		lastDate := lastDay.Date
-
-		// Sell all remaining positions
-		amount := position.Quantity * lastPrice
-		commission := amount * be.commissionRate
-		netAmount := amount - commission
-
-		trade := models.Trade{
-			Date:       lastDate,
-			Action:     "sell",
-			Price:      lastPrice,
-			Quantity:   position.Quantity,
-			Amount:     amount,
-			Commission: commission,
+	portfolio := NewPortfolio(request.InitialCash, request.Margin)
+	for _, asset := range assets {
+		costs, err := be.costModelFor(request, markets[asset].MarketType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trading costs: %w", err)
 		}
-		trades = append(trades, trade)
+		portfolio.AddAsset(asset, costs, be.lotRuleFor(markets[asset]))
+	}
 
-		// Update final portfolio state
-		lastIndex := len(dailyReturns) - 1
-		dailyReturns[lastIndex].Cash += netAmount
-		dailyReturns[lastIndex].Position = models.Position{}
-		dailyReturns[lastIndex].PortfolioValue = dailyReturns[lastIndex].Cash
-		dailyReturns[lastIndex].CumulativeReturn = (dailyReturns[lastIndex].PortfolioValue - initialPortfolioValue) / initialPortfolioValue
+	first := markets[assets[0]]
+	ctx := &StrategyContext{
+		Request:   request,
+		Data:      first.Data,
+		Portfolio: portfolio,
+		Calendar:  calendar.New(first.MarketType, first.TradingHours),
+		Assets:    assets,
+		dates:     dates,
+		series:    series,
+		timing:    executionTiming(request),
+		risk:      newRiskManager(request.Strategy.RiskManagement, portfolio),
+		reason:    models.TradeReasonSignal,
+
+		actions:    make(map[string][]models.CorporateAction, len(assets)),
+		nextAction: make(map[string]int, len(assets)),
+		fxRates:    fxRates,
+
+		periodsPerYear: periodsPerYear(request, assets, markets, dates[0], dates[len(dates)-1]),
+	}
+	for _, asset := range assets {
+		ctx.actions[asset] = sortedCorporateActions(markets[asset].CorporateActions)
 	}
 
-	// Calculate drawdown for each day
-	be.calculateDrawdown(dailyReturns)
+	if err := strategy.Init(ctx); err != nil {
+		return nil, err
+	}
 
-	return trades, dailyReturns, nil
-}
+	dailyReturns := make([]models.DailyReturn, 0, len(dates))
+	for i := range dates {
+		ctx.Index, ctx.execIndex = i, i
 
-// parseMonthlyRotationParams parses monthly rotation strategy parameters
-func (be *BacktestEngine) parseMonthlyRotationParams(parameters map[string]interface{}) (*models.MonthlyRotationParams, error) {
-	params := &models.MonthlyRotationParams{}
+		// Borrow fees and debit interest accrue over the calendar days held
+		if i > 0 {
+			portfolio.AccrueFinancing(dates[i].Sub(dates[i-1]).Hours() / 24)
+		}
 
-	if buyDays, ok := parameters["buy_days_before_month_end"]; ok {
-		if buyDaysFloat, ok := buyDays.(float64); ok {
-			params.BuyDaysBeforeMonthEnd = int(buyDaysFloat)
-		} else {
-			return nil, fmt.Errorf("invalid buy_days_before_month_end parameter")
+		// Foreign assets convert at the bar's FX rate; dividends and splits go
+		// ex at the start of the bar
+		applyFXRates(ctx)
+		applyCorporateActions(ctx)
+
+		// With next-bar execution the strategy decides on the previous bar and
+		// its orders fill on this one. Signals on the last bar are never filled.
+		// Opening fills come before any intrabar exit.
+		if ctx.timing == models.ExecutionTimingNextOpen && i > 0 {
+			if err := be.runOnBar(ctx, strategy, i-1); err != nil {
+				return nil, err
+			}
 		}
-	} else {
-		params.BuyDaysBeforeMonthEnd = 1 // Default value
-	}
 
-	if sellDays, ok := parameters["sell_days_after_month_start"]; ok {
-		if sellDaysFloat, ok := sellDays.(float64); ok {
-			params.SellDaysAfterMonthStart = int(sellDaysFloat)
-		} else {
-			return nil, fmt.Errorf("invalid sell_days_after_month_start parameter")
+		// Intrabar stop-loss and take-profit exits happen before the strategy sees the close
+		if ctx.risk != nil {
+			ctx.risk.beforeBar(ctx)
 		}
-	} else {
-		params.SellDaysAfterMonthStart = 1 // Default value
-	}
 
-	return params, nil
-}
+		// Same-bar strategies decide on the close, so they size orders against
+		// the portfolio valued at it
+		switch {
+		case ctx.timing == models.ExecutionTimingClose:
+			ctx.markToMarket()
+			if err := be.runOnBar(ctx, strategy, i); err != nil {
+				return nil, err
+			}
+		case ctx.timing != models.ExecutionTimingNextOpen && i > 0:
+			if err := be.runOnBar(ctx, strategy, i-1); err != nil {
+				return nil, err
+			}
+		}
 
-// shouldBuy determines if we should buy on a given date
-func (be *BacktestEngine) shouldBuy(currentDate time.Time, buyDaysBeforeMonthEnd int, data []models.OHLCV, currentIndex int) bool {
-	// Find the last trading day of the month for the current date's month
-	year, month, _ := currentDate.Date()
+		ctx.markToMarket()
 
-	// Get all trading days in this month from the data
-	var monthTradingDays []time.Time
-	for _, d := range data {
-		if d.Date.Year() == year && d.Date.Month() == month {
-			monthTradingDays = append(monthTradingDays, d.Date)
+		// Margin calls and the drawdown circuit breaker act on the closing portfolio value
+		applyMarginCall(ctx)
+		if ctx.risk != nil {
+			ctx.risk.afterBar(ctx)
 		}
-	}
 
-	if len(monthTradingDays) < buyDaysBeforeMonthEnd {
-		return false
+		daily := be.newDailyReturn(dates[i], portfolio, assets, request.InitialCash, dailyReturns)
+		for _, cash := range ctx.dividends {
+			daily.Dividends += cash
+		}
+		dailyReturns = append(dailyReturns, daily)
 	}
 
-	// Sort trading days to ensure correct order
-	sort.Slice(monthTradingDays, func(i, j int) bool {
-		return monthTradingDays[i].Before(monthTradingDays[j])
-	})
-
-	// Find the target buy date (buyDaysBeforeMonthEnd from the end)
-	// If buyDaysBeforeMonthEnd = 1, we want the last trading day
-	// If buyDaysBeforeMonthEnd = 2, we want the second-to-last trading day
-	targetIndex := len(monthTradingDays) - buyDaysBeforeMonthEnd
-	if targetIndex < 0 {
-		targetIndex = 0
+	ctx.reason = models.TradeReasonEndOfBacktest
+	if err := strategy.Finalize(ctx); err != nil {
+		return nil, err
 	}
 
-	targetDate := monthTradingDays[targetIndex]
-	return currentDate.Equal(targetDate)
-}
+	// Finalize may have traded on the last bar, so refresh its snapshot
+	lastIndex := len(dailyReturns) - 1
+	last := be.newDailyReturn(dates[lastIndex], portfolio, assets, request.InitialCash, dailyReturns[:lastIndex])
+	last.Dividends = dailyReturns[lastIndex].Dividends
+	dailyReturns[lastIndex] = last
 
-// shouldSell determines if we should sell on a given date
-func (be *BacktestEngine) shouldSell(currentDate time.Time, sellDaysAfterMonthStart int, data []models.OHLCV, currentIndex int) bool {
-	// Find the first trading day of the month for the current date's month
-	year, month, _ := currentDate.Date()
+	// Calculate drawdown for each day
+	be.calculateDrawdown(dailyReturns)
 
-	// Get all trading days in this month from the data
-	var monthTradingDays []time.Time
-	for _, d := range data {
-		if d.Date.Year() == year && d.Date.Month() == month {
-			monthTradingDays = append(monthTradingDays, d.Date)
-		}
+	run := &strategyRun{
+		strategy:       strategy,
+		trades:         portfolio.Trades,
+		dailyReturns:   dailyReturns,
+		financingCost:  portfolio.FinancingCost,
+		dividendIncome: portfolio.DividendIncome,
+		baseCurrency:   base,
 	}
-
-	if len(monthTradingDays) < sellDaysAfterMonthStart {
-		return false
+	if len(assets) > 1 {
+		run.holdings = buildHoldings(assets, dates, series, dailyReturns)
 	}
-
-	// Sort trading days to ensure correct order
-	sort.Slice(monthTradingDays, func(i, j int) bool {
-		return monthTradingDays[i].Before(monthTradingDays[j])
-	})
-
-	// Find the target sell date (sellDaysAfterMonthStart from the beginning)
-	// If sellDaysAfterMonthStart = 1, we want the first trading day
-	// If sellDaysAfterMonthStart = 2, we want the second trading day
-	targetIndex := sellDaysAfterMonthStart - 1
-	if targetIndex >= len(monthTradingDays) {
-		targetIndex = len(monthTradingDays) - 1
+	if ctx.risk != nil {
+		run.circuitBreakerEvents = ctx.risk.breakerEvents
 	}
-
-	targetDate := monthTradingDays[targetIndex]
-	return currentDate.Equal(targetDate)
+	return run, nil
 }
 
-// calculateDrawdown calculates drawdown for each day
-func (be *BacktestEngine) calculateDrawdown(dailyReturns []models.DailyReturn) {
-	peak := dailyReturns[0].PortfolioValue
-
-	for i := range dailyReturns {
-		if dailyReturns[i].PortfolioValue > peak {
-			peak = dailyReturns[i].PortfolioValue
-		}
+// runOnBar lets the strategy decide on the bar at index while orders fill on
+// the current execution bar
+func (be *BacktestEngine) runOnBar(ctx *StrategyContext, strategy Strategy, index int) error {
+	ctx.Index = index
+	defer func() { ctx.Index = ctx.execIndex }()
 
-		drawdown := (peak - dailyReturns[i].PortfolioValue) / peak
-		dailyReturns[i].Drawdown = drawdown
+	if err := strategy.OnBar(ctx); err != nil {
+		return fmt.Errorf("%s on %s: %w", ctx.Request.Strategy.Type, ctx.dates[index].Format("2006-01-02"), err)
 	}
+	return nil
 }
 
-// generateBacktestID generates a unique ID for the backtest
-func generateBacktestID() string {
-	return fmt.Sprintf("bt_%d", time.Now().UnixNano())
-}
-
-// executeBuyAndHoldStrategy executes buy and hold strategy
-func (be *BacktestEngine) executeBuyAndHoldStrategy(request models.BacktestRequest, data []models.OHLCV) ([]models.Trade, []models.DailyReturn, error) {
-	// Parse strategy parameters
-	params, err := be.parseBuyAndHoldParams(request.Strategy.Parameters)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var trades []models.Trade
-	var dailyReturns []models.DailyReturn
-
-	cash := request.InitialCash
-	position := models.Position{}
-	initialPurchase := false
-	lastRebalanceMonth := -1
-
-	// Store initial portfolio value for calculations
-	initialPortfolioValue := request.InitialCash
-
-	for i, dataPoint := range data {
-		currentDate := dataPoint.Date
-		currentPrice := dataPoint.Close
-
-		// Initial purchase on first day
-		if !initialPurchase {
-			quantity := math.Floor((cash * params.TargetAllocation) / currentPrice)
-			if quantity > 0 {
-				amount := quantity * currentPrice
-				commission := amount * be.commissionRate
-				totalCost := amount + commission
-
-				if totalCost <= cash {
-					trade := models.Trade{
-						Date:       currentDate,
-						Action:     "buy",
-						Price:      currentPrice,
-						Quantity:   quantity,
-						Amount:     amount,
-						Commission: commission,
-					}
-					trades = append(trades, trade)
-
-					cash -= totalCost
-					position.Quantity = quantity
-					position.AvgPrice = currentPrice
-					initialPurchase = true
-				}
-			}
-		}
-
-		// Check for rebalancing
-		if params.RebalanceFrequency != "never" && params.RebalanceFrequency != "" {
-			if be.shouldRebalance(currentDate, params.RebalanceFrequency, &lastRebalanceMonth) {
-				// Simple rebalancing: maintain target allocation
-				currentPortfolioValue := cash + (position.Quantity * currentPrice)
-				targetPositionValue := currentPortfolioValue * params.TargetAllocation
-				currentPositionValue := position.Quantity * currentPrice
-
-				// If position value differs significantly from target, rebalance
-				if math.Abs(currentPositionValue-targetPositionValue) > currentPortfolioValue*0.05 { // 5% threshold
-					if currentPositionValue > targetPositionValue {
-						// Sell excess
-						excessValue := currentPositionValue - targetPositionValue
-						excessQuantity := math.Floor(excessValue / currentPrice)
-						if excessQuantity > 0 && excessQuantity <= position.Quantity {
-							amount := excessQuantity * currentPrice
-							commission := amount * be.commissionRate
-							netAmount := amount - commission
-
-							trade := models.Trade{
-								Date:       currentDate,
-								Action:     "sell",
-								Price:      currentPrice,
-								Quantity:   excessQuantity,
-								Amount:     amount,
-								Commission: commission,
-							}
-							trades = append(trades, trade)
-
-							cash += netAmount
-							position.Quantity -= excessQuantity
-						}
-					} else {
-						// Buy more
-						deficitValue := targetPositionValue - currentPositionValue
-						additionalQuantity := math.Floor(deficitValue / currentPrice)
-						if additionalQuantity > 0 {
-							amount := additionalQuantity * currentPrice
-							commission := amount * be.commissionRate
-							totalCost := amount + commission
-
-							if totalCost <= cash {
-								trade := models.Trade{
-									Date:       currentDate,
-									Action:     "buy",
-									Price:      currentPrice,
-									Quantity:   additionalQuantity,
-									Amount:     amount,
-									Commission: commission,
-								}
-								trades = append(trades, trade)
-
-								cash -= totalCost
-								position.Quantity += additionalQuantity
-								// Update average price
-								position.AvgPrice = ((position.AvgPrice * (position.Quantity - additionalQuantity)) + (currentPrice * additionalQuantity)) / position.Quantity
-							}
-						}
-					}
-				}
-			}
-		}
-
-		// Update position market value if holding
-		if position.Quantity > 0 {
-			position.MarketValue = position.Quantity * currentPrice
-			position.UnrealizedPL = position.MarketValue - (position.Quantity * position.AvgPrice)
-		}
-
-		// Calculate daily portfolio value
-		portfolioValue := cash + position.MarketValue
-		dailyReturn := 0.0
-		cumulativeReturn := (portfolioValue - initialPortfolioValue) / initialPortfolioValue
-
-		// Calculate daily return correctly: only after first day
-		if i > 0 {
-			prevValue := dailyReturns[i-1].PortfolioValue
-			if prevValue > 0 {
-				dailyReturn = (portfolioValue - prevValue) / prevValue
-			}
-		}
-
-		dailyReturns = append(dailyReturns, models.DailyReturn{
-			Date:             currentDate,
-			PortfolioValue:   portfolioValue,
-			DailyReturn:      dailyReturn,
-			CumulativeReturn: cumulativeReturn,
-			Cash:             cash,
-			Position:         position,
-		})
+// newDailyReturn snapshots the portfolio at the end of a bar. External cash
+// flows are stripped from the daily return so returns stay time-weighted.
+// Portfolio backtests report every asset's position instead of a single one.
+func (be *BacktestEngine) newDailyReturn(date time.Time, portfolio *Portfolio, assets []string, initialValue float64, previous []models.DailyReturn) models.DailyReturn {
+	// Before the first bar the portfolio is the initial cash with no return yet
+	prevValue, prevContributed, prevCumulative := initialValue, initialValue, 0.0
+	if len(previous) > 0 {
+		prev := previous[len(previous)-1]
+		prevValue, prevContributed, prevCumulative = prev.PortfolioValue, prev.ContributedCapital, prev.CumulativeReturn
 	}
 
-	// Calculate drawdown for each day
-	be.calculateDrawdown(dailyReturns)
-
-	return trades, dailyReturns, nil
-}
+	portfolioValue := portfolio.Value()
+	cashFlow := portfolio.ContributedCapital - prevContributed
 
-// parseBuyAndHoldParams parses buy and hold strategy parameters
-func (be *BacktestEngine) parseBuyAndHoldParams(parameters map[string]interface{}) (*models.BuyAndHoldParams, error) {
-	params := &models.BuyAndHoldParams{
-		RebalanceFrequency: "never",
-		DividendReinvest:   false,
-		TargetAllocation:   1.0, // 100% allocation by default
+	periodReturn := 0.0
+	if prevValue > 0 {
+		periodReturn = (portfolioValue - cashFlow - prevValue) / prevValue
 	}
 
-	if rebalanceFreq, ok := parameters["rebalance_frequency"]; ok {
-		if freq, ok := rebalanceFreq.(string); ok {
-			params.RebalanceFrequency = freq
-		}
+	dailyReturn := 0.0
+	if len(previous) > 0 {
+		// Calculate daily return correctly: only after first day
+		dailyReturn = periodReturn
 	}
 
-	if dividendReinvest, ok := parameters["dividend_reinvest"]; ok {
-		if reinvest, ok := dividendReinvest.(bool); ok {
-			params.DividendReinvest = reinvest
+	grossExposure, netExposure := portfolio.Exposure()
+	snapshot := models.DailyReturn{
+		Date:               date,
+		PortfolioValue:     portfolioValue,
+		DailyReturn:        dailyReturn,
+		CumulativeReturn:   (1+prevCumulative)*(1+periodReturn) - 1,
+		Cash:               portfolio.Cash,
+		CashFlow:           cashFlow,
+		ContributedCapital: portfolio.ContributedCapital,
+		GrossExposure:      grossExposure,
+		NetExposure:        netExposure,
+	}
+	if len(assets) == 1 {
+		snapshot.Position = portfolio.Position(assets[0])
+	} else {
+		snapshot.Positions = make(map[string]models.Position, len(portfolio.Positions))
+		for asset, position := range portfolio.Positions {
+			snapshot.Positions[asset] = position
 		}
 	}
+	return snapshot
+}
 
-	if targetAllocation, ok := parameters["target_allocation"]; ok {
-		if allocation, ok := targetAllocation.(float64); ok {
-			if allocation > 0 && allocation <= 1.0 {
-				params.TargetAllocation = allocation
+// buildHoldings turns the daily position snapshots of a portfolio backtest
+// into one holdings time series per asset
+func buildHoldings(assets []string, dates []time.Time, series map[string]*assetSeries, dailyReturns []models.DailyReturn) []models.AssetHoldings {
+	holdings := make([]models.AssetHoldings, len(assets))
+	for a, asset := range assets {
+		points := make([]models.HoldingPoint, len(dates))
+		for i, date := range dates {
+			bar, _ := series[asset].bar(i)
+			position := dailyReturns[i].Positions[asset]
+
+			weight := 0.0
+			if dailyReturns[i].PortfolioValue > 0 {
+				weight = position.MarketValue / dailyReturns[i].PortfolioValue
+			}
+			points[i] = models.HoldingPoint{
+				Date:        date,
+				Quantity:    position.Quantity,
+				Price:       bar.Close,
+				MarketValue: position.MarketValue,
+				Weight:      weight,
+				Tradable:    series[asset].tradable(i, dates),
 			}
 		}
+		holdings[a] = models.AssetHoldings{AssetID: asset, Series: points}
 	}
-
-	return params, nil
+	return holdings
 }
 
-// shouldRebalance determines if rebalancing should occur
-func (be *BacktestEngine) shouldRebalance(currentDate time.Time, frequency string, lastRebalanceMonth *int) bool {
-	currentMonth := int(currentDate.Month())
-	currentYear := currentDate.Year()
+// calculateDrawdown calculates drawdown for each day on the time-weighted
+// wealth index, so external contributions do not mask losses. The initial
+// capital is the first peak, so a loss on the first day is a drawdown.
+func (be *BacktestEngine) calculateDrawdown(dailyReturns []models.DailyReturn) {
+	peak := 1.0
 
-	switch frequency {
-	case "monthly":
-		if *lastRebalanceMonth != currentMonth {
-			*lastRebalanceMonth = currentMonth
-			return true
-		}
-	case "quarterly":
-		if currentMonth%3 == 1 && *lastRebalanceMonth != currentMonth { // January, April, July, October
-			*lastRebalanceMonth = currentMonth
-			return true
+	for i := range dailyReturns {
+		wealth := 1 + dailyReturns[i].CumulativeReturn
+		if wealth > peak {
+			peak = wealth
 		}
-	case "yearly":
-		if currentMonth == 1 && *lastRebalanceMonth != currentYear*12+currentMonth {
-			*lastRebalanceMonth = currentYear*12 + currentMonth
-			return true
+
+		drawdown := 0.0
+		if peak > 0 {
+			drawdown = (peak - wealth) / peak
 		}
+		dailyReturns[i].Drawdown = drawdown
 	}
+}
 
-	return false
+// generateBacktestID generates a unique ID for the backtest
+func generateBacktestID() string {
+	return fmt.Sprintf("bt_%d", time.Now().UnixNano())
 }
@@ -0,0 +1,173 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/calendar"
+	"macro_strategy/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+// testAllIn is a strategy for engine tests that puts all cash into the asset
+// whenever it holds none, so it re-enters after any forced exit
+const testAllIn models.StrategyType = "test_all_in"
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        testAllIn,
+		Name:        "All In",
+		Description: "Buys with all cash whenever flat",
+		Factory: func(parameters map[string]interface{}) (Strategy, error) {
+			return allInStrategy{}, nil
+		},
+	})
+}
+
+type allInStrategy struct{}
+
+func (allInStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+func (allInStrategy) OnBar(ctx *StrategyContext) error {
+	if ctx.Position().Quantity == 0 {
+		ctx.BuyValue(ctx.Portfolio.Cash)
+	}
+	return nil
+}
+
+func (allInStrategy) Finalize(ctx *StrategyContext) error {
+	return nil
+}
+
+// testStart is the first session of the test bars
+var testStart = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// dailyBars returns one bar per trading day of the market from testStart with
+// the given closes. Each bar opens at the previous close and trades between
+// its open and close.
+func dailyBars(marketType models.MarketType, closes ...float64) []models.OHLCV {
+	days := calendar.New(marketType, nil).TradingDays(testStart, testStart.AddDate(0, 0, 2*len(closes)+14))
+	bars := make([]models.OHLCV, len(closes))
+	for i, close := range closes {
+		open := close
+		if i > 0 {
+			open = closes[i-1]
+		}
+		bars[i] = models.OHLCV{
+			Date:   days[i],
+			Open:   open,
+			High:   math.Max(open, close),
+			Low:    math.Min(open, close),
+			Close:  close,
+			Volume: 1000000,
+		}
+	}
+	return bars
+}
+
+// testMarket returns the market data of an asset quoted in US dollars
+func testMarket(assetID string, marketType models.MarketType, bars []models.OHLCV) *models.MarketData {
+	return &models.MarketData{
+		AssetID:    assetID,
+		Symbol:     assetID,
+		MarketType: marketType,
+		Currency:   models.CurrencyUSD,
+		Data:       bars,
+	}
+}
+
+// testMarkets returns the US stock markets of assets with daily bars of the
+// closes given per asset
+func testMarkets(assets []string, closes ...[]float64) []*models.MarketData {
+	markets := make([]*models.MarketData, len(assets))
+	for i, asset := range assets {
+		markets[i] = testMarket(asset, models.MarketTypeUSStock, dailyBars(models.MarketTypeUSStock, closes[i]...))
+	}
+	return markets
+}
+
+// testRequest returns a cost-free request running a strategy over bars
+func testRequest(strategyType models.StrategyType, parameters map[string]interface{}, bars []models.OHLCV) models.BacktestRequest {
+	return models.BacktestRequest{
+		IndexID:     "x",
+		Strategy:    models.StrategyConfig{Type: strategyType, Parameters: parameters},
+		StartDate:   bars[0].Date,
+		EndDate:     bars[len(bars)-1].Date,
+		InitialCash: 10000,
+		Costs:       &models.CostConfig{CommissionModel: "none"},
+	}
+}
+
+// runTestBacktest runs a request over the given markets, in portfolio mode
+// when the request lists its assets
+func runTestBacktest(t *testing.T, request models.BacktestRequest, markets ...*models.MarketData) *models.BacktestResult {
+	t.Helper()
+	be := NewBacktestEngine()
+
+	var result *models.BacktestResult
+	var err error
+	if len(request.AssetIDs) > 0 {
+		result, err = be.RunPortfolioBacktest(request, markets)
+	} else {
+		result, err = be.RunBacktest(request, markets[0])
+	}
+	if err != nil {
+		t.Fatalf("backtest failed: %v", err)
+	}
+	return result
+}
+
+// tradesBy returns the trades of a backtest with an action, all when empty
+func tradesBy(result *models.BacktestResult, action string) []models.Trade {
+	var trades []models.Trade
+	for _, trade := range result.Trades {
+		if action == "" || trade.Action == action {
+			trades = append(trades, trade)
+		}
+	}
+	return trades
+}
+
+// closeTo reports whether two values agree to a relative 1e-9
+func closeTo(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Max(1, math.Abs(want))
+}
+
+func TestExecutionTiming(t *testing.T) {
+	tests := []struct {
+		timing models.ExecutionTiming
+		bar    int // Bar the first-bar signal fills on
+		price  float64
+	}{
+		{models.ExecutionTimingClose, 0, 100},
+		{models.ExecutionTimingNextOpen, 1, 104},
+		{models.ExecutionTimingNextClose, 1, 110},
+		{models.ExecutionTimingNextVWAP, 1, (104 + 115 + 101 + 110) / 4.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.timing), func(t *testing.T) {
+			bars := dailyBars(models.MarketTypeUSStock, 100, 110, 120)
+			bars[1].Open, bars[1].High, bars[1].Low = 104, 115, 101
+			request := testRequest(testAllIn, nil, bars)
+			request.ExecutionTiming = tt.timing
+			result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+			buys := tradesBy(result, "buy")
+			if len(buys) != 1 || !buys[0].Date.Equal(bars[tt.bar].Date) || !closeTo(buys[0].Price, tt.price) {
+				t.Fatalf("buys = %+v, want one at %v on bar %d", buys, tt.price, tt.bar)
+			}
+			if result.ExecutionTiming != tt.timing {
+				t.Errorf("result timing = %s, want %s", result.ExecutionTiming, tt.timing)
+			}
+
+			// Nothing is held before the fill bar
+			for i := 0; i < tt.bar; i++ {
+				if q := result.DailyReturns[i].Position.Quantity; q != 0 {
+					t.Errorf("held %v on bar %d before the fill", q, i)
+				}
+			}
+		})
+	}
+}
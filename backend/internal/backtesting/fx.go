@@ -0,0 +1,72 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"sort"
+	"time"
+)
+
+// baseCurrency returns the currency a backtest reports in: the request's base
+// currency, else the quote currency of the first asset
+func baseCurrency(request models.BacktestRequest, assets []string, markets map[string]*models.MarketData) models.Currency {
+	if request.BaseCurrency != "" {
+		return request.BaseCurrency
+	}
+	return markets[assets[0]].Currency
+}
+
+// alignFXRates maps the FX rates of every asset quoted outside the base
+// currency onto the backtest timeline, taking the latest rate at or before
+// each date and the first rate before it. Assets in the base currency, or
+// without a known currency, have no rates and convert at 1.
+func alignFXRates(base models.Currency, assets []string, markets map[string]*models.MarketData, dates []time.Time) (map[string][]float64, error) {
+	aligned := make(map[string][]float64)
+	if base == "" {
+		return aligned, nil
+	}
+
+	for _, asset := range assets {
+		market := markets[asset]
+		if market.Currency == "" || market.Currency == base {
+			continue
+		}
+		if len(market.FXRates) == 0 {
+			return nil, fmt.Errorf("no %s/%s FX rates for %s", market.Currency, base, asset)
+		}
+
+		rates := append([]models.FXRate(nil), market.FXRates...)
+		sort.SliceStable(rates, func(i, j int) bool {
+			return rates[i].Date.Before(rates[j].Date)
+		})
+		for _, rate := range rates {
+			if rate.Rate <= 0 {
+				return nil, fmt.Errorf("invalid %s/%s FX rate %v on %s", market.Currency, base, rate.Rate, rate.Date.Format("2006-01-02"))
+			}
+		}
+
+		series := make([]float64, len(dates))
+		next := 0
+		for i, date := range dates {
+			day := civilDate(date)
+			for next < len(rates) && !civilDate(rates[next].Date).After(day) {
+				next++
+			}
+			if next == 0 {
+				series[i] = rates[0].Rate
+			} else {
+				series[i] = rates[next-1].Rate
+			}
+		}
+		aligned[asset] = series
+	}
+	return aligned, nil
+}
+
+// applyFXRates sets every foreign asset's FX rate for the execution bar,
+// before anything trades or is valued on it
+func applyFXRates(ctx *StrategyContext) {
+	for asset, rates := range ctx.fxRates {
+		ctx.Portfolio.SetFXRate(asset, rates[ctx.execIndex])
+	}
+}
@@ -0,0 +1,47 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestFXConversion(t *testing.T) {
+	// A dollar stock flat at 100 from a yuan account while the dollar gains 10%;
+	// the first rate is fixed the day before the backtest starts
+	bars := dailyBars(models.MarketTypeUSStock, 100, 100, 100)
+	market := testMarket("x", models.MarketTypeUSStock, bars)
+	market.FXRates = []models.FXRate{
+		{Date: bars[0].Date.AddDate(0, 0, -1), Rate: 7},
+		{Date: bars[2].Date, Rate: 7.7},
+	}
+	request := testRequest(testAllIn, nil, bars)
+	request.InitialCash = 70000
+	request.BaseCurrency = models.CurrencyCNY
+	result := runTestBacktest(t, request, market)
+
+	if result.BaseCurrency != models.CurrencyCNY {
+		t.Errorf("base currency = %s, want CNY", result.BaseCurrency)
+	}
+	buys := tradesBy(result, "buy")
+	if len(buys) != 1 || buys[0].Quantity != 100 || buys[0].FXRate != 7 {
+		t.Fatalf("buys = %+v, want 100 at a rate of 7", buys)
+	}
+
+	want := []float64{70000, 70000, 77000}
+	for i, value := range want {
+		if got := result.DailyReturns[i].PortfolioValue; !closeTo(got, value) {
+			t.Errorf("value on bar %d = %v CNY, want %v", i, got, value)
+		}
+	}
+	if got := result.PerformanceMetrics.TotalReturn; !closeTo(got, 0.1) {
+		t.Errorf("total return = %v, want the 10%% currency gain", got)
+	}
+
+	// Assets quoted outside the base currency need rates
+	market.FXRates = nil
+	_, err := NewBacktestEngine().RunBacktest(request, market)
+	if err == nil || !strings.Contains(err.Error(), "no USD/CNY FX rates") {
+		t.Errorf("error = %v, want missing FX rates", err)
+	}
+}
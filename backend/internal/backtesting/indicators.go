@@ -0,0 +1,211 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+)
+
+// closeMeanStd returns the mean and sample standard deviation of the closes
+// of the last window bars. ok is false until enough bars are available.
+func closeMeanStd(bars []models.OHLCV, window int) (mean, std float64, ok bool) {
+	if window < 2 || len(bars) < window {
+		return 0, 0, false
+	}
+	recent := bars[len(bars)-window:]
+
+	for _, bar := range recent {
+		mean += bar.Close
+	}
+	mean /= float64(window)
+
+	variance := 0.0
+	for _, bar := range recent {
+		variance += math.Pow(bar.Close-mean, 2)
+	}
+	variance /= float64(window - 1)
+
+	return mean, math.Sqrt(variance), true
+}
+
+// highestHigh returns the highest High of the last window bars
+func highestHigh(bars []models.OHLCV, window int) (float64, bool) {
+	if window < 1 || len(bars) < window {
+		return 0, false
+	}
+	highest := math.Inf(-1)
+	for _, bar := range bars[len(bars)-window:] {
+		highest = math.Max(highest, bar.High)
+	}
+	return highest, true
+}
+
+// lowestLow returns the lowest Low of the last window bars
+func lowestLow(bars []models.OHLCV, window int) (float64, bool) {
+	if window < 1 || len(bars) < window {
+		return 0, false
+	}
+	lowest := math.Inf(1)
+	for _, bar := range bars[len(bars)-window:] {
+		lowest = math.Min(lowest, bar.Low)
+	}
+	return lowest, true
+}
+
+// averageTrueRange returns the simple average of the true range over the
+// last period bars. The first true range needs a previous close.
+func averageTrueRange(bars []models.OHLCV, period int) (float64, bool) {
+	if period < 1 || len(bars) < period+1 {
+		return 0, false
+	}
+
+	sum := 0.0
+	for i := len(bars) - period; i < len(bars); i++ {
+		prevClose := bars[i-1].Close
+		trueRange := math.Max(bars[i].High-bars[i].Low,
+			math.Max(math.Abs(bars[i].High-prevClose), math.Abs(bars[i].Low-prevClose)))
+		sum += trueRange
+	}
+	return sum / float64(period), true
+}
+
+// trailingReturn returns the close-to-close return over lookback bars ending
+// skip bars before the last one
+func trailingReturn(bars []models.OHLCV, lookback, skip int) (float64, bool) {
+	end := len(bars) - 1 - skip
+	start := end - lookback
+	if lookback < 1 || skip < 0 || start < 0 || bars[start].Close <= 0 {
+		return 0, false
+	}
+	return bars[end].Close/bars[start].Close - 1, true
+}
+
+// returnVolatility returns the sample standard deviation of the daily close
+// returns over the last window bars
+func returnVolatility(bars []models.OHLCV, window int) (float64, bool) {
+	if window < 2 || len(bars) < window+1 {
+		return 0, false
+	}
+
+	returns := make([]float64, 0, window)
+	for i := len(bars) - window; i < len(bars); i++ {
+		if bars[i-1].Close <= 0 {
+			return 0, false
+		}
+		returns = append(returns, bars[i].Close/bars[i-1].Close-1)
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += math.Pow(r-mean, 2)
+	}
+	variance /= float64(len(returns) - 1)
+	return math.Sqrt(variance), true
+}
+
+// linearRegression returns the least squares slope and intercept of y on x
+func linearRegression(x, y []float64) (slope, intercept float64, ok bool) {
+	n := len(x)
+	if n < 2 || len(y) != n {
+		return 0, 0, false
+	}
+
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	covariance, variance := 0.0, 0.0
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+		variance += (x[i] - meanX) * (x[i] - meanX)
+	}
+	if variance == 0 {
+		return 0, 0, false
+	}
+	slope = covariance / variance
+	return slope, meanY - slope*meanX, true
+}
+
+// halfLife returns the mean reversion half-life in bars of a series, from
+// the regression of its changes on its lagged level. It is zero when the
+// series does not revert.
+func halfLife(series []float64) float64 {
+	if len(series) < 3 {
+		return 0
+	}
+	lagged := series[:len(series)-1]
+	changes := make([]float64, len(lagged))
+	for i := range lagged {
+		changes[i] = series[i+1] - series[i]
+	}
+
+	lambda, _, ok := linearRegression(lagged, changes)
+	if !ok || lambda >= 0 {
+		return 0
+	}
+	return -math.Ln2 / lambda
+}
+
+// meanStd returns the mean and sample standard deviation of values
+func meanStd(values []float64) (mean, std float64, ok bool) {
+	if len(values) < 2 {
+		return 0, 0, false
+	}
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, value := range values {
+		variance += math.Pow(value-mean, 2)
+	}
+	variance /= float64(len(values) - 1)
+
+	return mean, math.Sqrt(variance), true
+}
+
+// volumeTrend returns the daily growth rate of volume over the last window
+// bars, the slope of log volume against time
+func volumeTrend(bars []models.OHLCV, window int) (float64, bool) {
+	if window < 2 || len(bars) < window {
+		return 0, false
+	}
+	recent := bars[len(bars)-window:]
+	days := make([]float64, window)
+	logVolumes := make([]float64, window)
+	for i, bar := range recent {
+		if bar.Volume <= 0 {
+			return 0, false
+		}
+		days[i] = float64(i)
+		logVolumes[i] = math.Log(float64(bar.Volume))
+	}
+	slope, _, ok := linearRegression(days, logVolumes)
+	return slope, ok
+}
+
+// averageTurnover returns the mean turnover rate of the last window bars,
+// failing when the data carries no turnover
+func averageTurnover(bars []models.OHLCV, window int) (float64, bool) {
+	if window < 1 || len(bars) < window {
+		return 0, false
+	}
+	total := 0.0
+	for _, bar := range bars[len(bars)-window:] {
+		total += bar.Turnover
+	}
+	if total <= 0 {
+		return 0, false
+	}
+	return total / float64(window), true
+}
@@ -0,0 +1,162 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+)
+
+// LotRule quantizes order quantities and prices to what a market accepts
+type LotRule struct {
+	LotSize     float64                     // Quantity step, e.g. 100 shares or 0.00001 BTC; 0 means 1
+	MinQuantity float64                     // Smallest order quantity, 0 means one lot
+	MinNotional float64                     // Smallest order value
+	Tick        func(price float64) float64 // Price step at a given price, nil leaves prices unrounded
+}
+
+// DefaultLotRules returns the built-in lot rule for each market type
+func DefaultLotRules() map[models.MarketType]LotRule {
+	cent := fixedTick(0.01)
+
+	// Coins trade from far below a cent upwards, so without exchange rules
+	// prices keep eight significant digits rather than a fixed step
+	significant := relativeTick(8)
+
+	return map[models.MarketType]LotRule{
+		models.MarketTypeAShareIndex: {LotSize: 1},
+		models.MarketTypeAShareStock: {LotSize: 100, Tick: cent}, // 1手 = 100股
+		models.MarketTypeUSIndex:     {LotSize: 1},
+		models.MarketTypeUSStock:     {LotSize: 1, Tick: cent},
+		models.MarketTypeETF:         {LotSize: 1, Tick: cent},
+		models.MarketTypeHKIndex:     {LotSize: 1},
+		models.MarketTypeHKStock:     {LotSize: 100, Tick: hkTickSize}, // Board lots vary per stock
+		models.MarketTypeCrypto:      {LotSize: 0.00001, Tick: significant},
+	}
+}
+
+// newLotRule overlays asset or exchange trading rules on a market's default rule
+func newLotRule(base LotRule, rules *models.TradingRules) LotRule {
+	if rules == nil {
+		return base
+	}
+
+	rule := base
+	if rules.LotSize > 0 {
+		rule.LotSize = rules.LotSize
+	}
+	if rules.MinQuantity > 0 {
+		rule.MinQuantity = rules.MinQuantity
+	}
+	if rules.MinNotional > 0 {
+		rule.MinNotional = rules.MinNotional
+	}
+	if rules.TickSize > 0 {
+		rule.Tick = fixedTick(rules.TickSize)
+	}
+	return rule
+}
+
+// Quantize rounds a quantity down to a whole number of lots, returning zero
+// when the result is below the minimum order quantity
+func (r LotRule) Quantize(quantity float64) float64 {
+	if quantity <= 0 {
+		return 0
+	}
+
+	// The epsilon keeps e.g. 0.3/0.1 from flooring to two lots
+	step := r.step()
+	lots := math.Floor(quantity/step + 1e-9)
+	quantized := roundTo(lots*step, step)
+	if quantized < r.MinQuantity {
+		return 0
+	}
+	return quantized
+}
+
+// step returns the quantity step, defaulting to whole units
+func (r LotRule) step() float64 {
+	if r.LotSize <= 0 {
+		return 1
+	}
+	return r.LotSize
+}
+
+// Accepts reports whether an order of quantity at price meets the minimums
+func (r LotRule) Accepts(price, quantity float64) bool {
+	if quantity <= 0 || quantity < r.MinQuantity {
+		return false
+	}
+	return price*quantity >= r.MinNotional
+}
+
+// RoundPrice rounds a fill price to the tick grid against the trader: buys
+// round up, sells round down
+func (r LotRule) RoundPrice(action string, price float64) float64 {
+	if r.Tick == nil || price <= 0 {
+		return price
+	}
+	tick := r.Tick(price)
+	if tick <= 0 {
+		return price
+	}
+
+	ticks := price / tick
+	if action == "sell" {
+		ticks = math.Floor(ticks + 1e-9)
+	} else {
+		ticks = math.Ceil(ticks - 1e-9)
+	}
+	return roundTo(ticks*tick, tick)
+}
+
+// fixedTick returns a tick function with a constant price step
+func fixedTick(tick float64) func(float64) float64 {
+	return func(float64) float64 {
+		return tick
+	}
+}
+
+// relativeTick returns a tick function keeping a number of significant
+// digits of the price, so tiny prices never round to zero
+func relativeTick(digits int) func(float64) float64 {
+	return func(price float64) float64 {
+		if price <= 0 {
+			return 0
+		}
+		return math.Pow(10, math.Floor(math.Log10(price))-float64(digits-1))
+	}
+}
+
+// hkTickSize returns the HKEX spread table step for a price
+func hkTickSize(price float64) float64 {
+	switch {
+	case price < 0.25:
+		return 0.001
+	case price < 0.5:
+		return 0.005
+	case price < 10:
+		return 0.01
+	case price < 20:
+		return 0.02
+	case price < 100:
+		return 0.05
+	case price < 200:
+		return 0.1
+	case price < 500:
+		return 0.2
+	case price < 1000:
+		return 0.5
+	case price < 2000:
+		return 1
+	case price < 5000:
+		return 2
+	default:
+		return 5
+	}
+}
+
+// roundTo strips floating point noise below the precision of step
+func roundTo(value, step float64) float64 {
+	decimals := math.Max(0, math.Ceil(-math.Log10(step))) + 2
+	scale := math.Pow(10, decimals)
+	return math.Round(value*scale) / scale
+}
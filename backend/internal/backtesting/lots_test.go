@@ -0,0 +1,131 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+	"testing"
+)
+
+func TestQuantize(t *testing.T) {
+	rules := DefaultLotRules()
+	tests := []struct {
+		name     string
+		rule     LotRule
+		quantity float64
+		want     float64
+	}{
+		{"A-share round lot", rules[models.MarketTypeAShareStock], 1250, 1200},
+		{"A-share below a lot", rules[models.MarketTypeAShareStock], 99, 0},
+		{"whole shares", rules[models.MarketTypeUSStock], 10.9, 10},
+		{"crypto step", rules[models.MarketTypeCrypto], 0.123456789, 0.12345},
+		{"float noise", LotRule{LotSize: 0.1}, 0.3, 0.3},
+		{"below minimum quantity", LotRule{LotSize: 1, MinQuantity: 5}, 4, 0},
+		{"negative", LotRule{LotSize: 1}, -3, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rule.Quantize(tt.quantity); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: Quantize(%v) = %v, want %v", tt.name, tt.quantity, got, tt.want)
+		}
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	rule := LotRule{LotSize: 0.001, MinQuantity: 0.01, MinNotional: 10}
+	tests := []struct {
+		price, quantity float64
+		want            bool
+	}{
+		{1000, 0.01, true},
+		{1000, 0.009, false},
+		{500, 0.01, false},
+		{1000, 0, false},
+	}
+	for _, tt := range tests {
+		if got := rule.Accepts(tt.price, tt.quantity); got != tt.want {
+			t.Errorf("Accepts(%v, %v) = %v, want %v", tt.price, tt.quantity, got, tt.want)
+		}
+	}
+}
+
+func TestRoundPrice(t *testing.T) {
+	rules := DefaultLotRules()
+	tests := []struct {
+		name   string
+		rule   LotRule
+		action string
+		price  float64
+		want   float64
+	}{
+		{"cent tick buy rounds up", rules[models.MarketTypeUSStock], "buy", 10.001, 10.01},
+		{"cent tick sell rounds down", rules[models.MarketTypeUSStock], "sell", 10.019, 10.01},
+		{"on the grid", rules[models.MarketTypeUSStock], "buy", 10.01, 10.01},
+		{"HK spread table", rules[models.MarketTypeHKStock], "buy", 312.1, 312.2},
+		{"HK penny stock", rules[models.MarketTypeHKStock], "sell", 0.2345, 0.234},
+		{"crypto sub-cent sell", rules[models.MarketTypeCrypto], "sell", 0.0000123456789, 0.000012345678},
+		{"crypto sub-cent buy", rules[models.MarketTypeCrypto], "buy", 0.0000123456789, 0.000012345679},
+		{"crypto large price", rules[models.MarketTypeCrypto], "sell", 65432.123456789, 65432.123},
+		{"unrounded index", rules[models.MarketTypeAShareIndex], "buy", 3456.789, 3456.789},
+		{"exchange tick", newLotRule(rules[models.MarketTypeCrypto], &models.TradingRules{TickSize: 0.5}), "buy", 100.2, 100.5},
+	}
+
+	for _, tt := range tests {
+		got := tt.rule.RoundPrice(tt.action, tt.price)
+		if math.Abs(got-tt.want) > tt.want*1e-12 {
+			t.Errorf("%s: RoundPrice(%s, %v) = %v, want %v", tt.name, tt.action, tt.price, got, tt.want)
+		}
+		if got <= 0 {
+			t.Errorf("%s: RoundPrice(%s, %v) rounded to %v", tt.name, tt.action, tt.price, got)
+		}
+	}
+}
+
+func TestNewLotRule(t *testing.T) {
+	base := DefaultLotRules()[models.MarketTypeHKStock]
+	rule := newLotRule(base, &models.TradingRules{LotSize: 500, MinNotional: 1000})
+	if rule.LotSize != 500 || rule.MinNotional != 1000 {
+		t.Errorf("rule = %+v, want lot size 500 and min notional 1000", rule)
+	}
+	if rule.Tick == nil {
+		t.Error("rules without a tick size dropped the market tick")
+	}
+	if got := newLotRule(base, nil); got.LotSize != base.LotSize {
+		t.Errorf("nil rules changed the lot size to %v", got.LotSize)
+	}
+}
+
+func TestBacktestLotSizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		market   models.MarketType
+		rules    *models.TradingRules
+		price    float64
+		quantity float64 // Bought with all of the 10000 cash, 0 for no trade
+	}{
+		{"A-share round lots", models.MarketTypeAShareStock, nil, 30, 300},
+		{"A-share cash below a lot", models.MarketTypeAShareStock, nil, 150, 0},
+		{"exchange board lot", models.MarketTypeHKStock, &models.TradingRules{LotSize: 500}, 15, 500},
+		{"whole shares", models.MarketTypeUSStock, nil, 30, 333},
+		{"crypto step", models.MarketTypeCrypto, nil, 30000, 0.33333},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := dailyBars(tt.market, tt.price, tt.price)
+			market := testMarket("x", tt.market, bars)
+			market.TradingRules = tt.rules
+			result := runTestBacktest(t, testRequest(testAllIn, nil, bars), market)
+
+			buys := tradesBy(result, "buy")
+			if tt.quantity == 0 {
+				if len(buys) != 0 {
+					t.Errorf("buys = %+v, want none", buys)
+				}
+				return
+			}
+			if len(buys) != 1 || buys[0].Quantity != tt.quantity {
+				t.Errorf("buys = %+v, want one of %v", buys, tt.quantity)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+	"time"
+)
+
+// Default margin requirements, as under Reg T and FINRA maintenance rules
+const (
+	defaultInitialMargin     = 0.5
+	defaultMaintenanceMargin = 0.3
+)
+
+// validateMarginConfig checks the ranges of the margin settings
+func validateMarginConfig(config *models.MarginConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.InitialMargin < 0 || config.InitialMargin > 1 {
+		return fmt.Errorf("initial_margin must be between 0 and 1")
+	}
+	if config.MaintenanceMargin < 0 || config.MaintenanceMargin > 1 {
+		return fmt.Errorf("maintenance_margin must be between 0 and 1")
+	}
+	if config.BorrowRate < 0 || config.MarginRate < 0 {
+		return fmt.Errorf("borrow_rate and margin_rate must not be negative")
+	}
+
+	margin := normalizeMarginConfig(config)
+	if margin.MaintenanceMargin > margin.InitialMargin {
+		return fmt.Errorf("maintenance_margin must not exceed initial_margin")
+	}
+	return nil
+}
+
+// normalizeMarginConfig returns a copy of the config with default requirements filled in
+func normalizeMarginConfig(config *models.MarginConfig) *models.MarginConfig {
+	if config == nil {
+		return nil
+	}
+	margin := *config
+	if margin.InitialMargin == 0 {
+		margin.InitialMargin = defaultInitialMargin
+	}
+	if margin.MaintenanceMargin == 0 {
+		margin.MaintenanceMargin = math.Min(defaultMaintenanceMargin, margin.InitialMargin)
+	}
+	return &margin
+}
+
+// orderSide maps a trade action to the market side it trades on: shorts are
+// sells and covers are buys for costs, slippage and tick rounding
+func orderSide(action string) string {
+	if action == "sell" || action == "short" {
+		return "sell"
+	}
+	return "buy"
+}
+
+// Short sells quantity of an asset short, rounded down to whole lots, at
+// price. It returns nil unless the margin account allows shorting, no long
+// position is held in the asset and the account still meets the initial
+// margin afterwards.
+func (p *Portfolio) Short(asset string, date time.Time, price, quantity float64) *models.Trade {
+	position := p.Positions[asset]
+	if p.margin == nil || !p.margin.AllowShort || position.Quantity > 0 {
+		return nil
+	}
+	rules := p.assets[asset]
+	quantity = rules.lots.Quantize(quantity)
+	if price <= 0 || !rules.lots.Accepts(price, quantity) {
+		return nil
+	}
+
+	commission := rules.costs.commission("sell", price, quantity)
+	if !p.meetsInitialMargin(asset, price, position.Quantity-quantity, commission) {
+		return nil
+	}
+
+	trade := p.newTrade(asset, date, "short", price, quantity, commission)
+
+	// Short proceeds are credited to cash and held against the short
+	p.Cash += (quantity*price - commission) * p.FXRate(asset)
+	shortQuantity := -position.Quantity + quantity
+	position.AvgPrice = (position.AvgPrice*-position.Quantity + price*quantity) / shortQuantity
+	position.Quantity = -shortQuantity
+	p.setPosition(asset, position)
+	p.MarkToMarket(asset, price)
+
+	return trade
+}
+
+// Cover buys back up to quantity of a short position in an asset at price.
+// Partial covers are rounded down to whole lots.
+func (p *Portfolio) Cover(asset string, date time.Time, price, quantity float64) *models.Trade {
+	rules := p.assets[asset]
+	position := p.Positions[asset]
+	shortQuantity := -position.Quantity
+	if shortQuantity <= 0 || price <= 0 {
+		return nil
+	}
+	if quantity < shortQuantity {
+		quantity = rules.lots.Quantize(quantity)
+		if !rules.lots.Accepts(price, quantity) {
+			return nil
+		}
+	} else {
+		quantity = shortQuantity
+	}
+
+	commission := rules.costs.commission("buy", price, quantity)
+	trade := p.newTrade(asset, date, "cover", price, quantity, commission)
+
+	p.Cash -= (quantity*price + commission) * p.FXRate(asset)
+	position.Quantity += quantity
+	if position.Quantity >= 0 {
+		position = models.Position{} // Reset position
+	}
+	p.setPosition(asset, position)
+	p.MarkToMarket(asset, price)
+
+	return trade
+}
+
+// ShortableQuantity returns the whole-lot quantity of an asset that can be
+// shorted for up to the given base-currency notional value at price within
+// the initial margin
+func (p *Portfolio) ShortableQuantity(asset string, price, value float64) float64 {
+	position := p.Positions[asset]
+	if p.margin == nil || !p.margin.AllowShort || position.Quantity > 0 || price <= 0 || value <= 0 {
+		return 0
+	}
+	rules := p.assets[asset]
+	fx := p.FXRate(asset)
+
+	// Margin headroom left by the other positions
+	shortQuantity := -position.Quantity
+	equity := p.Cash + p.marketValue(asset) + position.Quantity*price*fx
+	headroom := equity/p.margin.InitialMargin - p.grossValue(asset)
+	maxQuantity := headroom/(price*fx) - shortQuantity
+	quantity := rules.lots.Quantize(math.Min(value/(price*fx), maxQuantity))
+
+	// Shrink the quantity until the commission also fits in the margin
+	for i := 0; i < 10 && quantity > 0; i++ {
+		commission := rules.costs.commission("sell", price, quantity)
+		if p.meetsInitialMargin(asset, price, -(shortQuantity + quantity), commission) {
+			return quantity
+		}
+		quantity = rules.lots.Quantize(math.Min(quantity-rules.lots.step(), (headroom-commission*fx/p.margin.InitialMargin)/(price*fx)-shortQuantity))
+	}
+	for quantity > 0 && !p.meetsInitialMargin(asset, price, -(shortQuantity+quantity), rules.costs.commission("sell", price, quantity)) {
+		quantity = rules.lots.Quantize(quantity - rules.lots.step())
+	}
+	return quantity
+}
+
+// meetsInitialMargin reports whether equity after paying commission covers
+// the initial margin on all positions once the asset's position is quantity
+// at price. Price and commission are in the asset's currency.
+func (p *Portfolio) meetsInitialMargin(asset string, price, quantity, commission float64) bool {
+	fx := p.FXRate(asset)
+	equity := p.Cash + p.marketValue(asset) + (p.Positions[asset].Quantity*price-commission)*fx
+	return equity >= p.margin.InitialMargin*(p.grossValue(asset)+math.Abs(quantity)*price*fx)
+}
+
+// AccrueFinancing charges borrow fees on the short market value and interest
+// on a negative cash balance for the given number of calendar days
+func (p *Portfolio) AccrueFinancing(days float64) {
+	if p.margin == nil || days <= 0 {
+		return
+	}
+
+	cost := 0.0
+	for _, position := range p.Positions {
+		if position.Quantity < 0 {
+			cost += -position.MarketValue * p.margin.BorrowRate * days / 365
+		}
+	}
+	if p.Cash < 0 {
+		cost += -p.Cash * p.margin.MarginRate * days / 365
+	}
+	p.Cash -= cost
+	p.FinancingCost += cost
+}
+
+// marginCallQuantities returns how much of each position must be liquidated
+// once equity falls below the maintenance margin on the marked positions.
+// Every position is cut by the same fraction so the initial margin is
+// restored; non-positive equity liquidates everything.
+func (p *Portfolio) marginCallQuantities() map[string]float64 {
+	if p.margin == nil || len(p.Positions) == 0 {
+		return nil
+	}
+
+	equity := p.Value()
+	gross := p.grossValue("")
+	if gross <= 0 || equity >= p.margin.MaintenanceMargin*gross {
+		return nil
+	}
+
+	keep := math.Max(0, equity/(p.margin.InitialMargin*gross))
+	quantities := make(map[string]float64, len(p.Positions))
+	for asset, position := range p.Positions {
+		quantity := math.Abs(position.Quantity)
+		quantities[asset] = quantity - p.assets[asset].lots.Quantize(quantity*keep)
+	}
+	return quantities
+}
+
+// applyMarginCall liquidates at the close whatever the margin call requires
+func applyMarginCall(ctx *StrategyContext) {
+	quantities := ctx.Portfolio.marginCallQuantities()
+	if len(quantities) == 0 {
+		return
+	}
+
+	for _, asset := range ctx.Assets {
+		quantity := quantities[asset]
+		if quantity <= 0 {
+			continue
+		}
+		action := "sell"
+		if ctx.Portfolio.Position(asset).Quantity < 0 {
+			action = "cover"
+		}
+		ctx.fill(asset, action, ctx.AssetPrice(asset), quantity, models.TradeReasonMarginCall)
+	}
+	ctx.markToMarket()
+}
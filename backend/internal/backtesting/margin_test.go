@@ -0,0 +1,95 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+// testAllShort is a strategy for engine tests that shorts twice the portfolio
+// value, all the default 50% initial margin allows, whenever it holds no position
+const testAllShort models.StrategyType = "test_all_short"
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        testAllShort,
+		Name:        "All Short",
+		Description: "Shorts twice the portfolio value whenever flat",
+		Factory: func(parameters map[string]interface{}) (Strategy, error) {
+			return allShortStrategy{}, nil
+		},
+	})
+}
+
+type allShortStrategy struct{}
+
+func (allShortStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+func (allShortStrategy) OnBar(ctx *StrategyContext) error {
+	if ctx.Position().Quantity == 0 {
+		ctx.ShortValue(2 * ctx.Portfolio.Value())
+	}
+	return nil
+}
+
+func (allShortStrategy) Finalize(ctx *StrategyContext) error {
+	return nil
+}
+
+func TestShortSelling(t *testing.T) {
+	bars := dailyBars(models.MarketTypeUSStock, 100, 100, 100)
+	request := testRequest(testAllShort, nil, bars)
+	request.Margin = &models.MarginConfig{AllowShort: true, BorrowRate: 0.365}
+	result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+	// 10000 of equity at 50% initial margin shorts 20000 worth
+	shorts := tradesBy(result, "short")
+	if len(shorts) != 1 || shorts[0].Quantity != 200 {
+		t.Fatalf("shorts = %+v, want one of 200", shorts)
+	}
+	daily := result.DailyReturns[0]
+	if daily.Position.Quantity != -200 || !closeTo(daily.GrossExposure, 2) || !closeTo(daily.NetExposure, -2) {
+		t.Errorf("position %v with exposure %v/%v, want -200 at 2/-2", daily.Position.Quantity, daily.GrossExposure, daily.NetExposure)
+	}
+
+	// Borrow fees accrue at 0.1% a day on the 20000 short for two days
+	if got := result.PerformanceMetrics.FinancingCost; !closeTo(got, 40) {
+		t.Errorf("financing cost = %v, want 40", got)
+	}
+	if got := result.DailyReturns[2].PortfolioValue; !closeTo(got, 10000-40) {
+		t.Errorf("portfolio value = %v, want %v", got, 10000-40)
+	}
+
+	// Without a margin account there is nothing to short
+	request.Margin = nil
+	if result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars)); len(result.Trades) != 0 {
+		t.Errorf("cash account traded %+v", result.Trades)
+	}
+}
+
+func TestMarginCall(t *testing.T) {
+	// The short of 200 at 100 loses 6000 of the 10000 equity on bar 2, below
+	// the 30% maintenance margin on 26000 gross
+	bars := dailyBars(models.MarketTypeUSStock, 100, 100, 130, 130)
+	request := testRequest(testAllShort, nil, bars)
+	request.Margin = &models.MarginConfig{AllowShort: true}
+	result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+	covers := tradesBy(result, "cover")
+	if len(covers) != 1 || !covers[0].Date.Equal(bars[2].Date) || covers[0].Reason != models.TradeReasonMarginCall {
+		t.Fatalf("covers = %+v, want a margin call on bar 2", covers)
+	}
+
+	// Enough is covered at the close to restore the 50% initial margin
+	if covers[0].Quantity != 139 || covers[0].Price != 130 {
+		t.Errorf("covered %v at %v, want 139 at 130", covers[0].Quantity, covers[0].Price)
+	}
+	daily := result.DailyReturns[2]
+	if daily.Position.Quantity != -61 || !closeTo(daily.PortfolioValue, 4000) {
+		t.Errorf("left %v short worth %v, want -61 and 4000", daily.Position.Quantity, daily.PortfolioValue)
+	}
+	if daily.GrossExposure > 2 {
+		t.Errorf("gross exposure = %v, above the 2 the initial margin allows", daily.GrossExposure)
+	}
+}
@@ -3,30 +3,49 @@ package backtesting
 import (
 	"macro_strategy/internal/models"
 	"math"
+	"sort"
 )
 
-// calculatePerformanceMetrics calculates comprehensive performance metrics
-func (be *BacktestEngine) calculatePerformanceMetrics(dailyReturns []models.DailyReturn, trades []models.Trade) models.PerformanceMetrics {
+// defaultRiskFreeRate is the annual risk-free rate Sharpe, Sortino and alpha
+// are measured against when the request sets none
+const defaultRiskFreeRate = 0.03
+
+// defaultVaRConfidence is the confidence level of VaR and CVaR when the
+// request sets none
+const defaultVaRConfidence = 0.95
+
+// defaultDrawdownTopN is how many of the deepest drawdown episodes a result
+// reports when the request sets no number
+const defaultDrawdownTopN = 10
+
+// calculatePerformanceMetrics calculates comprehensive performance metrics,
+// annualized and measured against the risk-free rate of the return basis,
+// with VaR and CVaR at the given confidence level
+func (be *BacktestEngine) calculatePerformanceMetrics(dailyReturns []models.DailyReturn, trades []models.Trade, basis returnBasis, confidence float64) models.PerformanceMetrics {
 	if len(dailyReturns) == 0 {
 		return models.PerformanceMetrics{}
 	}
 
-	initialValue := dailyReturns[0].PortfolioValue
-	finalValue := dailyReturns[len(dailyReturns)-1].PortfolioValue
+	last := dailyReturns[len(dailyReturns)-1]
+	finalValue := last.PortfolioValue
+	contributedCapital := last.ContributedCapital
 
-	// Basic return metrics - ensure we don't divide by zero
+	// Basic return metrics measured against all capital put in - ensure we don't divide by zero
 	totalReturn := 0.0
-	if initialValue > 0 {
-		totalReturn = (finalValue - initialValue) / initialValue
+	if contributedCapital > 0 {
+		totalReturn = (finalValue - contributedCapital) / contributedCapital
 	}
 
+	// Time-weighted return strips external cash flows and drives annualization
+	timeWeightedReturn := last.CumulativeReturn
+
 	daysCount := len(dailyReturns)
-	yearsCount := float64(daysCount) / 252.0 // Assuming 252 trading days per year
+	yearsCount := float64(daysCount) / basis.periodsPerYear
 
 	// Calculate annualized return safely
 	annualizedReturn := 0.0
-	if yearsCount > 0 && totalReturn > -1 {
-		annualizedReturn = math.Pow(1+totalReturn, 1/yearsCount) - 1
+	if yearsCount > 0 && timeWeightedReturn > -1 {
+		annualizedReturn = math.Pow(1+timeWeightedReturn, 1/yearsCount) - 1
 	}
 
 	// Calculate daily returns for further analysis
@@ -37,12 +56,12 @@ func (be *BacktestEngine) calculatePerformanceMetrics(dailyReturns []models.Dail
 
 	// Risk metrics
 	maxDrawdown := be.calculateMaxDrawdown(dailyReturns)
-	volatility := be.calculateVolatility(returns)
+	volatility := be.calculateVolatility(returns, basis.periodsPerYear)
 
 	// Risk-adjusted metrics
-	riskFreeRate := 0.03 // Assume 3% annual risk-free rate
+	riskFreeRate := basis.meanRiskFreeRate()
 	sharpeRatio := be.calculateSharpeRatio(annualizedReturn, volatility, riskFreeRate)
-	sortinoRatio := be.calculateSortinoRatio(returns, riskFreeRate)
+	sortinoRatio := be.calculateSortinoRatio(returns, basis.riskFreeRates[1:], basis.periodsPerYear)
 	calmarRatio := be.calculateCalmarRatio(annualizedReturn, maxDrawdown)
 
 	// Trade-based metrics
@@ -51,6 +70,9 @@ func (be *BacktestEngine) calculatePerformanceMetrics(dailyReturns []models.Dail
 	// Drawdown periods
 	maxDrawdownPeriod, recoveryPeriod := be.calculateDrawdownPeriods(dailyReturns)
 
+	// Tail risk and return distribution
+	tailMetrics := be.calculateTailMetrics(dailyReturns, returns, basis.riskFreeRates[1:], basis.periodsPerYear, confidence)
+
 	return models.PerformanceMetrics{
 		TotalReturn:       totalReturn,
 		AnnualizedReturn:  annualizedReturn,
@@ -70,6 +92,25 @@ func (be *BacktestEngine) calculatePerformanceMetrics(dailyReturns []models.Dail
 		MaxLosingTrade:    tradeMetrics.MaxLosingTrade,
 		MaxDrawdownPeriod: maxDrawdownPeriod,
 		RecoveryPeriod:    recoveryPeriod,
+
+		ContributedCapital: contributedCapital,
+		NetProfit:          finalValue - contributedCapital,
+		TimeWeightedReturn: timeWeightedReturn,
+
+		RiskFreeRate:   riskFreeRate,
+		PeriodsPerYear: basis.periodsPerYear,
+
+		VaRConfidence:       confidence,
+		HistoricalVaR:       tailMetrics.HistoricalVaR,
+		HistoricalCVaR:      tailMetrics.HistoricalCVaR,
+		ParametricVaR:       tailMetrics.ParametricVaR,
+		ParametricCVaR:      tailMetrics.ParametricCVaR,
+		Skewness:            tailMetrics.Skewness,
+		Kurtosis:            tailMetrics.Kurtosis,
+		OmegaRatio:          tailMetrics.OmegaRatio,
+		UlcerIndex:          tailMetrics.UlcerIndex,
+		TailRatio:           tailMetrics.TailRatio,
+		LongestLosingStreak: tailMetrics.LongestLosingStreak,
 	}
 }
 
@@ -85,7 +126,7 @@ func (be *BacktestEngine) calculateMaxDrawdown(dailyReturns []models.DailyReturn
 }
 
 // calculateVolatility calculates annualized volatility
-func (be *BacktestEngine) calculateVolatility(returns []float64) float64 {
+func (be *BacktestEngine) calculateVolatility(returns []float64, periodsPerYear float64) float64 {
 	if len(returns) < 2 {
 		return 0.0
 	}
@@ -104,8 +145,8 @@ func (be *BacktestEngine) calculateVolatility(returns []float64) float64 {
 	}
 	variance /= float64(len(returns) - 1)
 
-	// Annualize volatility (252 trading days)
-	return math.Sqrt(variance) * math.Sqrt(252)
+	// Annualize volatility
+	return math.Sqrt(variance) * math.Sqrt(periodsPerYear)
 }
 
 // calculateSharpeRatio calculates the Sharpe ratio
@@ -116,26 +157,29 @@ func (be *BacktestEngine) calculateSharpeRatio(annualizedReturn, volatility, ris
 	return (annualizedReturn - riskFreeRate) / volatility
 }
 
-// calculateSortinoRatio calculates the Sortino ratio
-func (be *BacktestEngine) calculateSortinoRatio(returns []float64, riskFreeRate float64) float64 {
+// calculateSortinoRatio calculates the Sortino ratio. Each return's downside
+// is measured against the annual risk-free rate in effect on it.
+func (be *BacktestEngine) calculateSortinoRatio(returns, riskFreeRates []float64, periodsPerYear float64) float64 {
 	if len(returns) == 0 {
 		return 0.0
 	}
 
-	// Calculate mean return
-	sum := 0.0
-	for _, r := range returns {
+	// Calculate mean return and mean risk-free rate
+	sum, riskFreeSum := 0.0, 0.0
+	for i, r := range returns {
 		sum += r
+		riskFreeSum += riskFreeRates[i]
 	}
 	meanReturn := sum / float64(len(returns))
-	annualizedMeanReturn := meanReturn * 252
+	annualizedMeanReturn := meanReturn * periodsPerYear
+	riskFreeRate := riskFreeSum / float64(len(returns))
 
 	// Calculate downside deviation
 	downsideVariance := 0.0
 	downsideCount := 0
-	dailyRiskFreeRate := riskFreeRate / 252
 
-	for _, r := range returns {
+	for i, r := range returns {
+		dailyRiskFreeRate := riskFreeRates[i] / periodsPerYear
 		if r < dailyRiskFreeRate {
 			downsideVariance += math.Pow(r-dailyRiskFreeRate, 2)
 			downsideCount++
@@ -147,7 +191,7 @@ func (be *BacktestEngine) calculateSortinoRatio(returns []float64, riskFreeRate
 	}
 
 	downsideVariance /= float64(len(returns))
-	downsideDeviation := math.Sqrt(downsideVariance) * math.Sqrt(252)
+	downsideDeviation := math.Sqrt(downsideVariance) * math.Sqrt(periodsPerYear)
 
 	if downsideDeviation == 0 {
 		return math.Inf(1)
@@ -167,6 +211,133 @@ func (be *BacktestEngine) calculateCalmarRatio(annualizedReturn, maxDrawdown flo
 	return annualizedReturn / maxDrawdown
 }
 
+// TailMetrics holds tail-risk and return distribution metrics. VaR and CVaR
+// are one-day losses reported as positive fractions of portfolio value.
+type TailMetrics struct {
+	HistoricalVaR       float64
+	HistoricalCVaR      float64
+	ParametricVaR       float64
+	ParametricCVaR      float64
+	Skewness            float64
+	Kurtosis            float64
+	OmegaRatio          float64
+	UlcerIndex          float64
+	TailRatio           float64
+	LongestLosingStreak int
+}
+
+// calculateTailMetrics calculates tail-risk and distribution metrics of the
+// daily returns. Omega is measured against the risk-free return of each day.
+func (be *BacktestEngine) calculateTailMetrics(dailyReturns []models.DailyReturn, returns, riskFreeRates []float64, periodsPerYear, confidence float64) TailMetrics {
+	var metrics TailMetrics
+
+	// Ulcer index: root mean square of the drawdowns
+	if len(dailyReturns) > 0 {
+		sumSquares := 0.0
+		for _, dr := range dailyReturns {
+			sumSquares += dr.Drawdown * dr.Drawdown
+		}
+		metrics.UlcerIndex = math.Sqrt(sumSquares / float64(len(dailyReturns)))
+	}
+
+	// Longest run of consecutive losing days
+	streak := 0
+	for _, r := range returns {
+		if r < 0 {
+			streak++
+			if streak > metrics.LongestLosingStreak {
+				metrics.LongestLosingStreak = streak
+			}
+		} else {
+			streak = 0
+		}
+	}
+
+	mean, std, ok := meanStd(returns)
+	if !ok {
+		return metrics
+	}
+
+	// Historical VaR is the loss at the tail quantile, CVaR the mean loss beyond it
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	tail := 1 - confidence
+	cutoff := quantile(sorted, tail)
+	metrics.HistoricalVaR = -cutoff
+	tailSum, tailCount := 0.0, 0
+	for _, r := range sorted {
+		if r > cutoff {
+			break
+		}
+		tailSum += r
+		tailCount++
+	}
+	if tailCount > 0 {
+		metrics.HistoricalCVaR = -tailSum / float64(tailCount)
+	}
+
+	// Parametric VaR and CVaR assume normally distributed returns
+	z := math.Sqrt2 * math.Erfinv(2*tail-1)
+	density := math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+	metrics.ParametricVaR = -(mean + z*std)
+	metrics.ParametricCVaR = -mean + std*density/tail
+
+	// Skewness and excess kurtosis from the central moments
+	m2, m3, m4 := 0.0, 0.0, 0.0
+	for _, r := range returns {
+		d := r - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	n := float64(len(returns))
+	m2, m3, m4 = m2/n, m3/n, m4/n
+	if m2 > 0 {
+		metrics.Skewness = m3 / math.Pow(m2, 1.5)
+		metrics.Kurtosis = m4/(m2*m2) - 3
+	}
+
+	// Omega: gains over losses relative to the daily risk-free return, 0
+	// without any return below it
+	gains, losses := 0.0, 0.0
+	for i, r := range returns {
+		excess := r - riskFreeRates[i]/periodsPerYear
+		if excess > 0 {
+			gains += excess
+		} else {
+			losses -= excess
+		}
+	}
+	if losses > 0 {
+		metrics.OmegaRatio = gains / losses
+	}
+
+	// Tail ratio: the 95th percentile return over the 5th percentile loss
+	if lower := quantile(sorted, 0.05); lower < 0 {
+		metrics.TailRatio = quantile(sorted, 0.95) / -lower
+	}
+
+	return metrics
+}
+
+// quantile returns the p-quantile of sorted values, interpolating linearly
+// between neighbouring values
+func quantile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	if lower < 0 {
+		return sorted[0]
+	}
+	fraction := position - float64(lower)
+	return sorted[lower] + fraction*(sorted[lower+1]-sorted[lower])
+}
+
 // TradeMetrics holds trade-based performance metrics
 type TradeMetrics struct {
 	TotalTrades     int
@@ -180,35 +351,144 @@ type TradeMetrics struct {
 	MaxLosingTrade  float64
 }
 
+// openLot is a buy still open for round-trip matching, with the quantity
+// later sells have not yet closed
+type openLot struct {
+	trade    models.Trade
+	quantity float64
+}
+
+// gridLot returns the open lot a grid sell closes first: the lot bought on
+// the same level with the quantity sold, else the first lot on that level,
+// else the oldest lot when the level's layer was already closed elsewhere
+func gridLot(lots []openLot, sell models.Trade) int {
+	first := -1
+	for i, lot := range lots {
+		if lot.trade.GridLevel != sell.GridLevel {
+			continue
+		}
+		if math.Abs(lot.quantity-sell.Quantity) <= sell.Quantity*1e-9 {
+			return i
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return 0
+	}
+	return first
+}
+
+// closeLots closes up to quantity of the open lots, starting with the lot at
+// first and then oldest first. It returns the base-currency value the closed
+// quantity was opened at, its share of the opening commissions, the quantity
+// closed and the lots left open.
+func closeLots(lots []openLot, first int, quantity float64) (value, fees, closed float64, remaining []openLot) {
+	order := make([]int, 0, len(lots))
+	order = append(order, first)
+	for i := range lots {
+		if i != first {
+			order = append(order, i)
+		}
+	}
+
+	left := make([]float64, len(lots))
+	for _, i := range order {
+		lot := lots[i]
+		take := math.Min(quantity-closed, lot.quantity)
+		if take > 0 && lot.trade.Quantity > 0 {
+			value += baseAmount(lot.trade, lot.trade.Price*take)
+			fees += baseAmount(lot.trade, lot.trade.Commission*take/lot.trade.Quantity)
+			closed += take
+		}
+		left[i] = lot.quantity - math.Max(take, 0)
+	}
+
+	for i, lot := range lots {
+		// Drop lots closed up to floating-point noise
+		if left[i] > lot.trade.Quantity*1e-9 {
+			lot.quantity = left[i]
+			remaining = append(remaining, lot)
+		}
+	}
+	return value, fees, closed, remaining
+}
+
+// baseAmount converts an amount in a trade's asset currency into the base
+// currency at the FX rate the trade filled at
+func baseAmount(trade models.Trade, amount float64) float64 {
+	if trade.FXRate > 0 {
+		return amount * trade.FXRate
+	}
+	return amount
+}
+
 // calculateTradeMetrics calculates trade-based metrics
 func (be *BacktestEngine) calculateTradeMetrics(trades []models.Trade) TradeMetrics {
 	if len(trades) == 0 {
 		return TradeMetrics{}
 	}
 
-	// Group trades into round trips (buy-sell pairs)
+	// Group trades into round trips (buy-sell and short-cover pairs) per
+	// asset. A sell closes open buy lots first in, first out, partially
+	// where it sells less than a lot; a grid sell closes the layer bought on
+	// its level first, and any quantity beyond it the oldest lots. Covers
+	// close open short lots the same way, first in, first out.
 	var roundTrips []float64
-	var buyTrade *models.Trade
-
-	for i, trade := range trades {
-		currentTrade := trade // Create a copy to avoid pointer issues
-		if currentTrade.Action == "buy" {
-			buyTrade = &currentTrade
-		} else if currentTrade.Action == "sell" && buyTrade != nil {
-			// Calculate P&L for this round trip
-			grossPnL := (currentTrade.Price - buyTrade.Price) * currentTrade.Quantity
-			totalCommission := currentTrade.Commission + buyTrade.Commission
-			netPnL := grossPnL - totalCommission
-
-			// Calculate percentage return based on initial investment
-			initialInvestment := buyTrade.Price*buyTrade.Quantity + buyTrade.Commission
-			percentageReturn := netPnL / initialInvestment
-
-			// Store percentage return for analysis
-			roundTrips = append(roundTrips, percentageReturn)
-			buyTrade = nil
+	allOpenBuys := make(map[string][]openLot)
+	allOpenShorts := make(map[string][]openLot)
+
+	for _, trade := range trades {
+		if trade.Quantity <= 0 {
+			continue
 		}
-		_ = i // Suppress unused variable warning
+		switch trade.Action {
+		case "buy":
+			allOpenBuys[trade.AssetID] = append(allOpenBuys[trade.AssetID], openLot{trade: trade, quantity: trade.Quantity})
+			continue
+		case "short":
+			allOpenShorts[trade.AssetID] = append(allOpenShorts[trade.AssetID], openLot{trade: trade, quantity: trade.Quantity})
+			continue
+		case "cover":
+			if len(allOpenShorts[trade.AssetID]) == 0 {
+				continue
+			}
+
+			// Proceeds of the shorts the cover closes against its cost on that
+			// quantity, on the short value and commissions put up
+			value, fees, closed, remaining := closeLots(allOpenShorts[trade.AssetID], 0, trade.Quantity)
+			allOpenShorts[trade.AssetID] = remaining
+			if closed <= 0 || value <= 0 {
+				continue
+			}
+			cost := baseAmount(trade, trade.Price*closed+trade.Commission*closed/trade.Quantity)
+			roundTrips = append(roundTrips, (value-fees-cost)/(value+fees))
+			continue
+		}
+		openBuys := allOpenBuys[trade.AssetID]
+		if trade.Action != "sell" || len(openBuys) == 0 {
+			continue
+		}
+
+		first := 0
+		if trade.GridLevel > 0 {
+			first = gridLot(openBuys, trade)
+		}
+
+		// Cost of the lots the sell closes and its proceeds on that quantity,
+		// both in the base currency so currency moves while the position was
+		// open count towards the round trip
+		value, fees, closed, remaining := closeLots(openBuys, first, trade.Quantity)
+		allOpenBuys[trade.AssetID] = remaining
+		cost := value + fees
+		if closed <= 0 || cost <= 0 {
+			continue
+		}
+		proceeds := baseAmount(trade, trade.Price*closed-trade.Commission*closed/trade.Quantity)
+
+		// Store percentage return on the cost of the closed quantity for analysis
+		roundTrips = append(roundTrips, (proceeds-cost)/cost)
 	}
 
 	if len(roundTrips) == 0 {
@@ -283,6 +563,55 @@ func (be *BacktestEngine) calculateTradeMetrics(trades []models.Trade) TradeMetr
 	}
 }
 
+// calculateDrawdownEpisodes splits the drawdown series into episodes from a
+// peak to its recovery and returns the topN deepest, deepest first. A
+// drawdown from the initial capital peaks on the first day. An episode still
+// open at the end of the backtest has no recovery date and no recovery days.
+// Durations count days on the backtest timeline.
+func (be *BacktestEngine) calculateDrawdownEpisodes(dailyReturns []models.DailyReturn, topN int) []models.DrawdownEpisode {
+	var episodes []models.DrawdownEpisode
+	var current *models.DrawdownEpisode
+	peakIndex, troughIndex := 0, 0
+
+	for i, dr := range dailyReturns {
+		if dr.Drawdown <= 0 {
+			if current != nil {
+				// Recovered to the previous peak
+				recoveryDate := dr.Date
+				current.RecoveryDate = &recoveryDate
+				current.RecoveryDays = i - troughIndex
+				current.TotalDays = i - peakIndex
+				episodes = append(episodes, *current)
+				current = nil
+			}
+			peakIndex = i
+			continue
+		}
+
+		if current == nil {
+			current = &models.DrawdownEpisode{PeakDate: dailyReturns[peakIndex].Date}
+		}
+		if dr.Drawdown > current.Depth {
+			current.Depth = dr.Drawdown
+			current.TroughDate = dr.Date
+			current.DeclineDays = i - peakIndex
+			troughIndex = i
+		}
+	}
+	if current != nil {
+		current.TotalDays = len(dailyReturns) - 1 - peakIndex
+		episodes = append(episodes, *current)
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool {
+		return episodes[i].Depth > episodes[j].Depth
+	})
+	if len(episodes) > topN {
+		episodes = episodes[:topN]
+	}
+	return episodes
+}
+
 // calculateDrawdownPeriods calculates maximum drawdown period and recovery period
 func (be *BacktestEngine) calculateDrawdownPeriods(dailyReturns []models.DailyReturn) (int, int) {
 	if len(dailyReturns) == 0 {
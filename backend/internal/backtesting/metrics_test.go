@@ -0,0 +1,318 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func trade(action string, price, quantity float64, gridLevel int) models.Trade {
+	return models.Trade{AssetID: "x", Action: action, Price: price, Quantity: quantity, GridLevel: gridLevel}
+}
+
+// roundTripTest is a sequence of trades and the return of each round trip it makes
+type roundTripTest struct {
+	name   string
+	trades []models.Trade
+	want   []float64
+}
+
+// checkRoundTrips compares the trade metrics of each test with the ones its
+// round-trip returns give
+func checkRoundTrips(t *testing.T, tests []roundTripTest) {
+	t.Helper()
+	be := NewBacktestEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := be.calculateTradeMetrics(tt.trades)
+
+			wins, losses := 0, 0
+			maxWin, maxLoss := 0.0, 0.0
+			for _, r := range tt.want {
+				if r > 0 {
+					wins++
+					maxWin = math.Max(maxWin, r)
+				} else if r < 0 {
+					losses++
+					maxLoss = math.Min(maxLoss, r)
+				}
+			}
+			if metrics.TotalTrades != len(tt.want) {
+				t.Fatalf("round trips = %d, want %d", metrics.TotalTrades, len(tt.want))
+			}
+			if metrics.WinningTrades != wins || metrics.LosingTrades != losses {
+				t.Fatalf("wins/losses = %d/%d, want %d/%d", metrics.WinningTrades, metrics.LosingTrades, wins, losses)
+			}
+			if wins > 0 && math.Abs(metrics.MaxWinningTrade-maxWin) > 1e-9 {
+				t.Errorf("max winning trade = %v, want %v", metrics.MaxWinningTrade, maxWin)
+			}
+			if losses > 0 && math.Abs(metrics.MaxLosingTrade-maxLoss) > 1e-9 {
+				t.Errorf("max losing trade = %v, want %v", metrics.MaxLosingTrade, maxLoss)
+			}
+		})
+	}
+}
+
+func TestCalculateTradeMetricsRoundTrips(t *testing.T) {
+	checkRoundTrips(t, []roundTripTest{
+		{
+			name:   "one buy one sell",
+			trades: []models.Trade{trade("buy", 10, 100, 0), trade("sell", 12, 100, 0)},
+			want:   []float64{0.2},
+		},
+		{
+			name:   "full exit after two buys uses both lots",
+			trades: []models.Trade{trade("buy", 10, 100, 0), trade("buy", 20, 100, 0), trade("sell", 18, 200, 0)},
+			want:   []float64{0.2},
+		},
+		{
+			name: "partial sells close lots first in first out",
+			trades: []models.Trade{
+				trade("buy", 10, 100, 0), trade("buy", 20, 100, 0),
+				trade("sell", 15, 50, 0), trade("sell", 15, 150, 0),
+			},
+			want: []float64{0.5, -0.1},
+		},
+		{
+			name: "grid sell closes its own level",
+			trades: []models.Trade{
+				trade("buy", 10, 100, 1), trade("buy", 9, 100, 2),
+				trade("sell", 9.9, 100, 2), trade("sell", 11, 100, 1),
+			},
+			want: []float64{0.1, 0.1},
+		},
+		{
+			name: "grid sell prefers the level lot of its quantity",
+			trades: []models.Trade{
+				trade("buy", 10, 50, 1), trade("buy", 8, 100, 1), trade("sell", 11, 100, 1),
+			},
+			want: []float64{0.375},
+		},
+		{
+			name: "grid sell of a closed level falls back to the oldest lot",
+			trades: []models.Trade{
+				trade("buy", 10, 100, 1), trade("sell", 10, 100, 0),
+				trade("buy", 9, 100, 1), trade("sell", 9.9, 100, 2),
+			},
+			want: []float64{0, 0.1},
+		},
+		{
+			name: "risk exit of a layered grid closes every layer",
+			trades: []models.Trade{
+				trade("buy", 10, 100, 1), trade("buy", 9, 100, 2), trade("buy", 8, 100, 3),
+				trade("sell", 7.2, 300, 0),
+			},
+			want: []float64{-0.2},
+		},
+	})
+}
+
+func TestCalculateTradeMetricsCostsAndFX(t *testing.T) {
+	buy := trade("buy", 10, 100, 0)
+	buy.Commission = 5
+	buy.FXRate = 7
+	sell := trade("sell", 10, 100, 0)
+	sell.Commission = 5
+	sell.FXRate = 7.7
+
+	// Flat in the asset's currency, 10% up on the currency, less commissions
+	metrics := NewBacktestEngine().calculateTradeMetrics([]models.Trade{buy, sell})
+	cost := (1000 + 5) * 7.0
+	want := ((1000-5)*7.7 - cost) / cost
+	if math.Abs(metrics.MaxWinningTrade-want) > 1e-9 {
+		t.Errorf("round trip return = %v, want %v", metrics.MaxWinningTrade, want)
+	}
+}
+
+func TestCalculateTradeMetricsShorts(t *testing.T) {
+	short := trade("short", 10, 100, 0)
+	short.Commission = 5
+	cover := trade("cover", 10, 100, 0)
+	cover.Commission = 5
+
+	checkRoundTrips(t, []roundTripTest{
+		{
+			name:   "short and cover",
+			trades: []models.Trade{trade("short", 20, 100, 0), trade("cover", 15, 100, 0)},
+			want:   []float64{0.25},
+		},
+		{
+			name: "scaled-in shorts cover first in first out",
+			trades: []models.Trade{
+				trade("short", 20, 100, 0), trade("short", 10, 100, 0),
+				trade("cover", 12, 100, 0), trade("cover", 12, 100, 0),
+			},
+			want: []float64{0.4, -0.2},
+		},
+		{
+			name: "partial covers leave the rest open",
+			trades: []models.Trade{
+				trade("short", 10, 200, 0), trade("cover", 8, 50, 0), trade("cover", 11, 150, 0),
+			},
+			want: []float64{0.2, -0.1},
+		},
+		{
+			name: "cover spanning two shorts",
+			trades: []models.Trade{
+				trade("short", 10, 100, 0), trade("short", 30, 100, 0), trade("cover", 15, 200, 0),
+			},
+			want: []float64{0.25},
+		},
+		{
+			name:   "commissions on both legs",
+			trades: []models.Trade{short, cover},
+			want:   []float64{-10.0 / 1005},
+		},
+		{
+			name:   "cover without a short",
+			trades: []models.Trade{trade("cover", 10, 100, 0)},
+			want:   nil,
+		},
+	})
+}
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	tests := []struct{ p, want float64 }{
+		{0, 1}, {0.5, 3}, {1, 5}, {0.1, 1.4},
+	}
+	for _, tt := range tests {
+		if got := quantile(sorted, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("quantile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestTailMetrics(t *testing.T) {
+	// Alternating 10% gains and losses
+	bars := dailyBars(models.MarketTypeUSStock, 100, 110, 99, 108.9, 98.01)
+	request := testRequest(testAllIn, nil, bars)
+	request.VaRConfidence = 0.6
+	metrics := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars)).PerformanceMetrics
+
+	// The 40% quantile sits a fifth of the way from the second loss to the first gain
+	dailyRiskFree := defaultRiskFreeRate / 252
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"VaR confidence", metrics.VaRConfidence, 0.6},
+		{"historical VaR", metrics.HistoricalVaR, 0.06},
+		{"historical CVaR", metrics.HistoricalCVaR, 0.1},
+		{"parametric VaR", metrics.ParametricVaR, 0.02925400363877318},
+		{"parametric CVaR", metrics.ParametricCVaR, 0.11152748285690722},
+		{"skewness", metrics.Skewness, 0},
+		{"kurtosis", metrics.Kurtosis, -2},
+		{"omega ratio", metrics.OmegaRatio, (0.1 - dailyRiskFree) / (0.1 + dailyRiskFree)},
+		{"ulcer index", metrics.UlcerIndex, math.Sqrt((0.1*0.1 + 0.01*0.01 + 0.109*0.109) / 5)},
+		{"longest losing streak", float64(metrics.LongestLosingStreak), 1},
+	}
+	for _, tt := range tests {
+		if !closeTo(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	request.VaRConfidence = 1
+	if _, err := NewBacktestEngine().RunBacktest(request, testMarket("x", models.MarketTypeUSStock, bars)); err == nil {
+		t.Error("VaR confidence of 1 accepted")
+	}
+}
+
+// dailyWealth returns daily returns on consecutive days following a wealth index
+func dailyWealth(wealth ...float64) []models.DailyReturn {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	daily := make([]models.DailyReturn, len(wealth))
+	for i, w := range wealth {
+		daily[i] = models.DailyReturn{Date: start.AddDate(0, 0, i), CumulativeReturn: w - 1}
+	}
+	return daily
+}
+
+func TestCalculateDrawdown(t *testing.T) {
+	be := NewBacktestEngine()
+	daily := dailyWealth(0.9, 1.2, 0.9, 1.3)
+	be.calculateDrawdown(daily)
+
+	want := []float64{0.1, 0, 0.25, 0}
+	for i, dr := range daily {
+		if math.Abs(dr.Drawdown-want[i]) > 1e-9 {
+			t.Errorf("drawdown %d = %v, want %v", i, dr.Drawdown, want[i])
+		}
+	}
+}
+
+func TestCalculateDrawdownEpisodes(t *testing.T) {
+	be := NewBacktestEngine()
+	daily := dailyWealth(0.95, 1.0, 1.1, 0.99, 0.88, 1.0, 1.2, 1.08, 1.14)
+	be.calculateDrawdown(daily)
+
+	episodes := be.calculateDrawdownEpisodes(daily, 10)
+	if len(episodes) != 3 {
+		t.Fatalf("got %d episodes, want 3: %+v", len(episodes), episodes)
+	}
+
+	day := func(i int) time.Time { return daily[i].Date }
+	tests := []struct {
+		peak, trough, recovery  int // Indexes, recovery -1 when open
+		depth                   float64
+		decline, recover, total int
+	}{
+		{2, 4, 6, 0.2, 2, 2, 4},
+		{6, 7, -1, 0.1, 1, 0, 2},
+		{0, 0, 1, 0.05, 0, 1, 1},
+	}
+	for i, want := range tests {
+		got := episodes[i]
+		if !got.PeakDate.Equal(day(want.peak)) || !got.TroughDate.Equal(day(want.trough)) {
+			t.Errorf("episode %d peak/trough = %s/%s, want %s/%s", i,
+				got.PeakDate.Format("01-02"), got.TroughDate.Format("01-02"), day(want.peak).Format("01-02"), day(want.trough).Format("01-02"))
+		}
+		if want.recovery < 0 {
+			if got.RecoveryDate != nil {
+				t.Errorf("episode %d recovered on %s, want open", i, got.RecoveryDate.Format("01-02"))
+			}
+		} else if got.RecoveryDate == nil || !got.RecoveryDate.Equal(day(want.recovery)) {
+			t.Errorf("episode %d recovery = %v, want %s", i, got.RecoveryDate, day(want.recovery).Format("01-02"))
+		}
+		if math.Abs(got.Depth-want.depth) > 1e-9 {
+			t.Errorf("episode %d depth = %v, want %v", i, got.Depth, want.depth)
+		}
+		if got.DeclineDays != want.decline || got.RecoveryDays != want.recover || got.TotalDays != want.total {
+			t.Errorf("episode %d days = %d/%d/%d, want %d/%d/%d", i,
+				got.DeclineDays, got.RecoveryDays, got.TotalDays, want.decline, want.recover, want.total)
+		}
+	}
+
+	if top := be.calculateDrawdownEpisodes(daily, 1); len(top) != 1 || math.Abs(top[0].Depth-0.2) > 1e-9 {
+		t.Errorf("top 1 episodes = %+v, want the 20%% drawdown", top)
+	}
+}
+
+func TestDrawdownEpisodes(t *testing.T) {
+	// Down 10% from the initial capital, then 20% from a later high, recovered by the end
+	bars := dailyBars(models.MarketTypeUSStock, 100, 90, 100, 110, 99, 88, 110, 115)
+	request := testRequest(testAllIn, nil, bars)
+	result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+	if !closeTo(result.PerformanceMetrics.MaxDrawdown, 0.2) {
+		t.Errorf("max drawdown = %v, want 0.2", result.PerformanceMetrics.MaxDrawdown)
+	}
+	episodes := result.DrawdownEpisodes
+	if len(episodes) != 2 || !closeTo(episodes[0].Depth, 0.2) || !closeTo(episodes[1].Depth, 0.1) {
+		t.Fatalf("episodes = %+v, want depths 0.2 and 0.1", episodes)
+	}
+	deepest := episodes[0]
+	if !deepest.PeakDate.Equal(bars[3].Date) || !deepest.TroughDate.Equal(bars[5].Date) ||
+		deepest.RecoveryDate == nil || !deepest.RecoveryDate.Equal(bars[6].Date) {
+		t.Errorf("deepest episode = %+v, want peak, trough and recovery on bars 3, 5 and 6", deepest)
+	}
+
+	// Only the deepest episodes are kept
+	request.DrawdownTopN = 1
+	result = runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+	if len(result.DrawdownEpisodes) != 1 || !closeTo(result.DrawdownEpisodes[0].Depth, 0.2) {
+		t.Errorf("top 1 episodes = %+v, want the 20%% drawdown", result.DrawdownEpisodes)
+	}
+}
@@ -0,0 +1,245 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+	"time"
+)
+
+// Portfolio keeps the shared cash, positions and trade bookkeeping for a
+// backtest. Every asset trades under its own cost model and lot rule; a
+// single-asset backtest is a portfolio of one asset. Cash and market values
+// are held in the base currency; prices, commissions and trade amounts stay
+// in each asset's own currency and are converted at the asset's FX rate.
+type Portfolio struct {
+	Cash               float64
+	Positions          map[string]models.Position // Open positions by asset ID
+	Trades             []models.Trade
+	ContributedCapital float64 // Initial cash plus all external contributions
+	FinancingCost      float64 // Borrow fees and debit interest accrued on a margin account
+	DividendIncome     float64 // Cash dividends received, net of dividends paid on shorts
+	assets             map[string]assetRules
+	margin             *models.MarginConfig // Nil for a long-only cash account
+}
+
+// assetRules holds the trading costs, lot rule and current FX rate of one asset
+type assetRules struct {
+	costs CostModel
+	lots  LotRule
+	fx    float64 // Value of one unit of the asset's currency in the base currency
+}
+
+// NewPortfolio creates a portfolio funded with the initial cash. A margin
+// config turns it into a margin account that may sell short and borrow cash.
+func NewPortfolio(initialCash float64, margin *models.MarginConfig) *Portfolio {
+	return &Portfolio{
+		Cash:               initialCash,
+		Positions:          make(map[string]models.Position),
+		ContributedCapital: initialCash,
+		assets:             make(map[string]assetRules),
+		margin:             normalizeMarginConfig(margin),
+	}
+}
+
+// AddAsset registers a tradable asset with its cost model and lot rule
+func (p *Portfolio) AddAsset(asset string, costs CostModel, lots LotRule) {
+	p.assets[asset] = assetRules{costs: costs, lots: lots, fx: 1}
+}
+
+// SetFXRate sets the rate converting an asset's currency into the base currency
+func (p *Portfolio) SetFXRate(asset string, rate float64) {
+	rules := p.assets[asset]
+	rules.fx = rate
+	p.assets[asset] = rules
+}
+
+// FXRate returns the rate converting an asset's currency into the base
+// currency, 1 for assets in the base currency
+func (p *Portfolio) FXRate(asset string) float64 {
+	if rules, ok := p.assets[asset]; ok && rules.fx > 0 {
+		return rules.fx
+	}
+	return 1
+}
+
+// newTrade records a trade in an asset, tagged with the FX rate it converted at
+func (p *Portfolio) newTrade(asset string, date time.Time, action string, price, quantity, commission float64) *models.Trade {
+	trade := models.Trade{
+		Date:       date,
+		AssetID:    asset,
+		Action:     action,
+		Price:      price,
+		Quantity:   quantity,
+		Amount:     quantity * price,
+		Commission: commission,
+	}
+	if fx := p.FXRate(asset); fx != 1 {
+		trade.FXRate = fx
+	}
+	p.Trades = append(p.Trades, trade)
+	return &p.Trades[len(p.Trades)-1]
+}
+
+// Position returns the position held in an asset, zero when flat
+func (p *Portfolio) Position(asset string) models.Position {
+	return p.Positions[asset]
+}
+
+// setPosition stores a position, dropping it once flat
+func (p *Portfolio) setPosition(asset string, position models.Position) {
+	if position.Quantity == 0 {
+		delete(p.Positions, asset)
+		return
+	}
+	p.Positions[asset] = position
+}
+
+// Deposit adds external cash to the portfolio, e.g. a periodic contribution
+func (p *Portfolio) Deposit(amount float64) {
+	if amount <= 0 {
+		return
+	}
+	p.Cash += amount
+	p.ContributedCapital += amount
+}
+
+// AffordableQuantity returns the whole-lot quantity of an asset that can be
+// bought with the given amount of base-currency cash at price, including
+// commission
+func (p *Portfolio) AffordableQuantity(asset string, price, value float64) float64 {
+	if price <= 0 || value <= 0 {
+		return 0
+	}
+	value = math.Min(value, p.Cash) / p.FXRate(asset)
+	rules := p.assets[asset]
+
+	// Shrink the quantity until amount plus commission fits; fee models with
+	// minimums or per-share charges have no closed form
+	quantity := rules.lots.Quantize(value / price)
+	for i := 0; i < 10 && quantity > 0; i++ {
+		commission := rules.costs.commission("buy", price, quantity)
+		if quantity*price+commission <= value {
+			return quantity
+		}
+		quantity = rules.lots.Quantize(math.Min(quantity-rules.lots.step(), (value-commission)/price))
+	}
+	for quantity > 0 && quantity*price+rules.costs.commission("buy", price, quantity) > value {
+		quantity = rules.lots.Quantize(quantity - rules.lots.step())
+	}
+	return quantity
+}
+
+// Buy buys quantity of an asset, rounded down to whole lots, at price,
+// returning nil if the order is below the market minimums or cannot be
+// funded. A short position must be covered before buying.
+func (p *Portfolio) Buy(asset string, date time.Time, price, quantity float64) *models.Trade {
+	rules := p.assets[asset]
+	position := p.Positions[asset]
+	quantity = rules.lots.Quantize(quantity)
+	if price <= 0 || position.Quantity < 0 || !rules.lots.Accepts(price, quantity) {
+		return nil
+	}
+
+	commission := rules.costs.commission("buy", price, quantity)
+	totalCost := (quantity*price + commission) * p.FXRate(asset)
+	if p.margin == nil && totalCost > p.Cash {
+		return nil
+	}
+	if p.margin != nil && !p.meetsInitialMargin(asset, price, position.Quantity+quantity, commission) {
+		return nil
+	}
+
+	trade := p.newTrade(asset, date, "buy", price, quantity, commission)
+
+	p.Cash -= totalCost
+	newQuantity := position.Quantity + quantity
+	position.AvgPrice = (position.AvgPrice*position.Quantity + price*quantity) / newQuantity
+	position.Quantity = newQuantity
+	p.setPosition(asset, position)
+	p.MarkToMarket(asset, price)
+
+	return trade
+}
+
+// Sell sells up to quantity of an asset at price, returning nil if nothing is
+// held. Partial sells are rounded down to whole lots; closing the whole
+// position may sell an odd lot.
+func (p *Portfolio) Sell(asset string, date time.Time, price, quantity float64) *models.Trade {
+	rules := p.assets[asset]
+	position := p.Positions[asset]
+	if quantity < position.Quantity {
+		quantity = rules.lots.Quantize(quantity)
+		if !rules.lots.Accepts(price, quantity) {
+			return nil
+		}
+	} else {
+		quantity = position.Quantity
+	}
+	if quantity <= 0 || price <= 0 {
+		return nil
+	}
+
+	commission := rules.costs.commission("sell", price, quantity)
+	trade := p.newTrade(asset, date, "sell", price, quantity, commission)
+
+	p.Cash += (quantity*price - commission) * p.FXRate(asset)
+	position.Quantity -= quantity
+	if position.Quantity <= 0 {
+		position = models.Position{} // Reset position
+	}
+	p.setPosition(asset, position)
+	p.MarkToMarket(asset, price)
+
+	return trade
+}
+
+// MarkToMarket revalues the position in an asset at price, in the base
+// currency. Short positions have a negative market value.
+func (p *Portfolio) MarkToMarket(asset string, price float64) {
+	position, ok := p.Positions[asset]
+	if !ok {
+		return
+	}
+	fx := p.FXRate(asset)
+	position.MarketValue = position.Quantity * price * fx
+	position.UnrealizedPL = position.MarketValue - (position.Quantity * position.AvgPrice * fx)
+	p.Positions[asset] = position
+}
+
+// Value returns cash plus the marked value of all positions
+func (p *Portfolio) Value() float64 {
+	return p.Cash + p.marketValue("")
+}
+
+// marketValue returns the marked value of all positions except one asset
+func (p *Portfolio) marketValue(except string) float64 {
+	value := 0.0
+	for asset, position := range p.Positions {
+		if asset != except {
+			value += position.MarketValue
+		}
+	}
+	return value
+}
+
+// grossValue returns the summed absolute marked value of all positions
+// except one asset
+func (p *Portfolio) grossValue(except string) float64 {
+	value := 0.0
+	for asset, position := range p.Positions {
+		if asset != except {
+			value += math.Abs(position.MarketValue)
+		}
+	}
+	return value
+}
+
+// Exposure returns the gross and net position exposure as fractions of the
+// portfolio value
+func (p *Portfolio) Exposure() (gross, net float64) {
+	value := p.Value()
+	if value <= 0 {
+		return 0, 0
+	}
+	return p.grossValue("") / value, p.marketValue("") / value
+}
@@ -0,0 +1,227 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+)
+
+// riskManager applies a strategy's RiskManagementConfig as an overlay on any
+// strategy: stop-loss and take-profit exits checked intrabar on every
+// position, a cap on the size each position reaches by entries, and a
+// portfolio drawdown circuit breaker.
+type riskManager struct {
+	config models.RiskManagementConfig
+
+	// Drawdown circuit breaker state
+	peakValue       float64 // Highest closing value since start or last resume, adjusted for deposits
+	lastContributed float64
+	halted          bool
+	tripIndex       int
+	tripPrice       float64 // Close of the first asset at the trip
+	breakerEvents   []models.CircuitBreakerEvent
+}
+
+// newRiskManager creates a risk overlay for a portfolio, returning nil when no config is set
+func newRiskManager(config *models.RiskManagementConfig, portfolio *Portfolio) *riskManager {
+	if config == nil {
+		return nil
+	}
+
+	rm := &riskManager{
+		config:          *config,
+		peakValue:       portfolio.Value(),
+		lastContributed: portfolio.ContributedCapital,
+	}
+	if rm.config.DrawdownReentry == "" {
+		rm.config.DrawdownReentry = "never"
+		if rm.config.DrawdownCooldown > 0 {
+			rm.config.DrawdownReentry = "after_cooldown"
+		}
+	}
+	return rm
+}
+
+// validateRiskManagementConfig checks the ranges of the risk settings
+func validateRiskManagementConfig(config *models.RiskManagementConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.MaxPositionSize < 0 || config.MaxPositionSize > 1 {
+		return fmt.Errorf("max_position_size must be between 0 and 1")
+	}
+	if config.StopLoss < 0 || config.StopLoss >= 1 {
+		return fmt.Errorf("stop_loss must be between 0 and 1")
+	}
+	if config.TakeProfit < 0 {
+		return fmt.Errorf("take_profit must not be negative")
+	}
+	if config.MaxDrawdown < 0 || config.MaxDrawdown >= 1 {
+		return fmt.Errorf("max_drawdown must be between 0 and 1")
+	}
+	if config.DrawdownCooldown < 0 {
+		return fmt.Errorf("drawdown_cooldown must not be negative")
+	}
+	switch config.DrawdownReentry {
+	case "", "never", "after_cooldown", "above_trip_price":
+	default:
+		return fmt.Errorf("drawdown_reentry must be one of never, after_cooldown, above_trip_price")
+	}
+	return nil
+}
+
+// beforeBar runs before the strategy sees the current bar and applies
+// intrabar exits
+func (rm *riskManager) beforeBar(ctx *StrategyContext) {
+	rm.applyExits(ctx)
+}
+
+// afterBar runs on the closing portfolio value. It trips the drawdown circuit
+// breaker, liquidating every position, once drawdown reaches MaxDrawdown, and
+// lifts a tripped breaker from the next bar once the re-entry rule allows.
+func (rm *riskManager) afterBar(ctx *StrategyContext) {
+	portfolio := ctx.Portfolio
+
+	// External contributions raise the peak so they are not mistaken for gains
+	rm.peakValue += portfolio.ContributedCapital - rm.lastContributed
+	rm.lastContributed = portfolio.ContributedCapital
+
+	if rm.config.MaxDrawdown <= 0 {
+		return
+	}
+	if !rm.halted {
+		rm.checkDrawdown(ctx)
+	}
+
+	// Re-entry is decided on this bar's close, the last one every strategy
+	// decision from the next bar on has seen, so under next-bar execution the
+	// decision on this bar does not trade on a later close
+	next := ctx.Index + 1
+	if rm.halted && next < len(ctx.dates) && rm.canResume(ctx, next) {
+		rm.halted = false
+		rm.peakValue = portfolio.Value()
+		resumeDate := ctx.dates[next]
+		rm.breakerEvents[len(rm.breakerEvents)-1].ResumeDate = &resumeDate
+	}
+}
+
+// checkDrawdown trips the circuit breaker when the closing portfolio value
+// has fallen MaxDrawdown below its peak
+func (rm *riskManager) checkDrawdown(ctx *StrategyContext) {
+	value := ctx.Portfolio.Value()
+	if value > rm.peakValue {
+		rm.peakValue = value
+	}
+	if rm.peakValue <= 0 {
+		return
+	}
+
+	drawdown := (rm.peakValue - value) / rm.peakValue
+	if drawdown < rm.config.MaxDrawdown {
+		return
+	}
+
+	ctx.closeAll(models.TradeReasonCircuitBreaker, ctx.AssetPrice)
+	ctx.markToMarket()
+
+	rm.halted = true
+	rm.tripIndex = ctx.Index
+	rm.tripPrice = ctx.Price()
+	rm.breakerEvents = append(rm.breakerEvents, models.CircuitBreakerEvent{
+		TripDate:       ctx.Date(),
+		Drawdown:       drawdown,
+		PortfolioValue: value,
+	})
+}
+
+// canResume reports whether the re-entry rule allows trading again from the
+// bar at next, judged on the current close. Price re-entry follows the first
+// asset.
+func (rm *riskManager) canResume(ctx *StrategyContext, next int) bool {
+	if next-rm.tripIndex < rm.config.DrawdownCooldown {
+		return false
+	}
+
+	switch rm.config.DrawdownReentry {
+	case "after_cooldown":
+		return true
+	case "above_trip_price":
+		return ctx.Price() > rm.tripPrice
+	default: // never
+		return false
+	}
+}
+
+// applyExits closes each position whose asset trades through its stop-loss
+// or take-profit price on the current bar
+func (rm *riskManager) applyExits(ctx *StrategyContext) {
+	for _, asset := range ctx.Assets {
+		if bar, ok := ctx.series[asset].bar(ctx.Index); ok && ctx.Tradable(asset) {
+			rm.applyAssetExits(ctx, asset, bar)
+		}
+	}
+}
+
+// applyAssetExits closes the position in an asset if bar trades through the
+// stop-loss or take-profit price. Gaps through a level fill at the open; when
+// both levels fall inside one bar the stop is assumed to be hit first.
+func (rm *riskManager) applyAssetExits(ctx *StrategyContext, asset string, bar models.OHLCV) {
+	position := ctx.Portfolio.Position(asset)
+	if position.Quantity == 0 || position.AvgPrice <= 0 {
+		return
+	}
+
+	// Short positions stop out above the entry and take profit below it
+	if position.Quantity < 0 {
+		if rm.config.StopLoss > 0 {
+			stopPrice := position.AvgPrice * (1 + rm.config.StopLoss)
+			if bar.High >= stopPrice {
+				ctx.fill(asset, "cover", math.Max(stopPrice, bar.Open), -position.Quantity, models.TradeReasonStopLoss)
+				return
+			}
+		}
+		if rm.config.TakeProfit > 0 && rm.config.TakeProfit < 1 {
+			targetPrice := position.AvgPrice * (1 - rm.config.TakeProfit)
+			if bar.Low <= targetPrice {
+				ctx.fill(asset, "cover", math.Min(targetPrice, bar.Open), -position.Quantity, models.TradeReasonTakeProfit)
+			}
+		}
+		return
+	}
+
+	if rm.config.StopLoss > 0 {
+		stopPrice := position.AvgPrice * (1 - rm.config.StopLoss)
+		if bar.Low <= stopPrice {
+			ctx.fill(asset, "sell", math.Min(stopPrice, bar.Open), position.Quantity, models.TradeReasonStopLoss)
+			return
+		}
+	}
+
+	if rm.config.TakeProfit > 0 {
+		targetPrice := position.AvgPrice * (1 + rm.config.TakeProfit)
+		if bar.High >= targetPrice {
+			ctx.fill(asset, "sell", math.Max(targetPrice, bar.Open), position.Quantity, models.TradeReasonTakeProfit)
+		}
+	}
+}
+
+// capEntryQuantity limits a buy or short so the position in the asset does
+// not exceed MaxPositionSize of the portfolio value after the trade. No
+// entries are allowed while the drawdown circuit breaker is tripped.
+func (rm *riskManager) capEntryQuantity(portfolio *Portfolio, asset string, price, quantity float64) float64 {
+	if rm.halted {
+		return 0
+	}
+	if rm.config.MaxPositionSize <= 0 || price <= 0 {
+		return quantity
+	}
+
+	held := portfolio.Position(asset).Quantity
+	fx := portfolio.FXRate(asset)
+	portfolioValue := portfolio.Cash + portfolio.marketValue(asset) + held*price*fx
+	maxQuantity := portfolio.assets[asset].lots.Quantize(portfolioValue*rm.config.MaxPositionSize/(price*fx) - math.Abs(held))
+	if maxQuantity <= 0 {
+		return 0
+	}
+	return math.Min(quantity, maxQuantity)
+}
@@ -0,0 +1,123 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/calendar"
+	"macro_strategy/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// defaultPeriodsPerYear annualizes returns without a usable trading calendar
+const defaultPeriodsPerYear = 252.0
+
+// returnBasis is what a backtest's daily returns are annualized and measured
+// against
+type returnBasis struct {
+	periodsPerYear float64   // Trading days a year on the backtest's calendar
+	riskFreeRates  []float64 // Annual risk-free rate in effect on each daily return
+}
+
+// validateRiskFreeConfig checks the risk-free rate settings. A provider
+// source must have been loaded into the rates before the backtest runs.
+func validateRiskFreeConfig(config *models.RiskFreeConfig) error {
+	if config == nil {
+		return nil
+	}
+	switch config.Source {
+	case "", models.RiskFreeSourceShibor3M, models.RiskFreeSourceUSTBill3M:
+	default:
+		return fmt.Errorf("unsupported source: %s", config.Source)
+	}
+	if config.Source != "" && len(config.Rates) == 0 {
+		return fmt.Errorf("no rates loaded for source %s", config.Source)
+	}
+	if config.Rate <= -1 || config.Rate >= 1 {
+		return fmt.Errorf("rate must be between -1 and 1")
+	}
+	for _, rate := range config.Rates {
+		if rate.Rate <= -1 || rate.Rate >= 1 || math.IsNaN(rate.Rate) {
+			return fmt.Errorf("rate on %s must be between -1 and 1", rate.Date.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// periodsPerYear derives the annualization factor of a backtest from first
+// to last from the assets' trading calendars. A union timeline samples
+// returns on the busiest calendar, an intersection on the quietest.
+func periodsPerYear(request models.BacktestRequest, assets []string, markets map[string]*models.MarketData, first, last time.Time) float64 {
+	result := 0.0
+	for _, asset := range assets {
+		market := markets[asset]
+		periods := calendar.New(market.MarketType, market.TradingHours).TradingDaysPerYear(first, last)
+		switch {
+		case periods <= 0:
+		case result == 0,
+			request.DateAlignment == models.DateAlignmentIntersection && periods < result,
+			request.DateAlignment != models.DateAlignmentIntersection && periods > result:
+			result = periods
+		}
+	}
+	if result <= 0 {
+		return defaultPeriodsPerYear
+	}
+	return result
+}
+
+// newReturnBasis derives the annualization factor from the assets' trading
+// calendars and aligns the request's risk-free rates to the daily returns
+func newReturnBasis(request models.BacktestRequest, assets []string, markets map[string]*models.MarketData, dailyReturns []models.DailyReturn) returnBasis {
+	basis := returnBasis{riskFreeRates: make([]float64, len(dailyReturns))}
+	if len(dailyReturns) == 0 {
+		basis.periodsPerYear = defaultPeriodsPerYear
+		return basis
+	}
+
+	first, last := dailyReturns[0].Date, dailyReturns[len(dailyReturns)-1].Date
+	basis.periodsPerYear = periodsPerYear(request, assets, markets, first, last)
+
+	config := request.RiskFree
+	switch {
+	case config == nil:
+		for i := range basis.riskFreeRates {
+			basis.riskFreeRates[i] = defaultRiskFreeRate
+		}
+	case len(config.Rates) == 0:
+		for i := range basis.riskFreeRates {
+			basis.riskFreeRates[i] = config.Rate
+		}
+	default:
+		// Latest rate at or before each date, the first rate before it
+		rates := append([]models.InterestRate(nil), config.Rates...)
+		sort.SliceStable(rates, func(i, j int) bool {
+			return rates[i].Date.Before(rates[j].Date)
+		})
+		next := 0
+		for i, daily := range dailyReturns {
+			day := civilDate(daily.Date)
+			for next < len(rates) && !civilDate(rates[next].Date).After(day) {
+				next++
+			}
+			if next == 0 {
+				basis.riskFreeRates[i] = rates[0].Rate
+			} else {
+				basis.riskFreeRates[i] = rates[next-1].Rate
+			}
+		}
+	}
+	return basis
+}
+
+// meanRiskFreeRate returns the average annual risk-free rate over the returns
+func (b returnBasis) meanRiskFreeRate() float64 {
+	if len(b.riskFreeRates) == 0 {
+		return defaultRiskFreeRate
+	}
+	sum := 0.0
+	for _, rate := range b.riskFreeRates {
+		sum += rate
+	}
+	return sum / float64(len(b.riskFreeRates))
+}
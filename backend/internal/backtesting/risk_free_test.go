@@ -0,0 +1,111 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRiskFreeBasis(t *testing.T) {
+	closes := []float64{100, 110, 99, 108.9, 100}
+
+	tests := []struct {
+		name       string
+		marketType models.MarketType
+		riskFree   *models.RiskFreeConfig
+		rate       float64 // Mean annual risk-free rate over the daily returns
+		periods    float64
+	}{
+		{"default rate on the NYSE", models.MarketTypeUSStock, nil, 0.03, 252},
+		{"fixed rate on the NYSE", models.MarketTypeUSStock, &models.RiskFreeConfig{Rate: 0.05}, 0.05, 252},
+		{"fixed rate around the clock", models.MarketTypeCrypto, &models.RiskFreeConfig{Rate: 0.05}, 0.05, 366},
+		{
+			// Unsorted rates apply from their date on, the earliest one before it
+			"rate series",
+			models.MarketTypeUSStock,
+			&models.RiskFreeConfig{Rates: []models.InterestRate{
+				{Date: testStart.AddDate(0, 0, 3), Rate: 0.06},
+				{Date: testStart.AddDate(0, 0, 1), Rate: 0.04},
+				{Date: testStart.AddDate(0, 0, 2), Rate: 0.02},
+			}},
+			(0.04 + 0.04 + 0.02 + 0.06 + 0.06) / 5,
+			252,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := dailyBars(tt.marketType, closes...)
+			request := testRequest(testAllIn, nil, bars)
+			request.RiskFree = tt.riskFree
+			metrics := runTestBacktest(t, request, testMarket("x", tt.marketType, bars)).PerformanceMetrics
+
+			if !closeTo(metrics.RiskFreeRate, tt.rate) {
+				t.Errorf("risk-free rate = %v, want %v", metrics.RiskFreeRate, tt.rate)
+			}
+			if metrics.PeriodsPerYear != tt.periods {
+				t.Errorf("periods per year = %v, want %v", metrics.PeriodsPerYear, tt.periods)
+			}
+
+			// Sharpe is measured in excess of the rate and annualized on the calendar
+			volatility := NewBacktestEngine().calculateVolatility([]float64{0.1, -0.1, 0.1, 100/108.9 - 1}, tt.periods)
+			if !closeTo(metrics.Volatility, volatility) {
+				t.Errorf("volatility = %v, want %v", metrics.Volatility, volatility)
+			}
+			if want := (metrics.AnnualizedReturn - tt.rate) / volatility; !closeTo(metrics.SharpeRatio, want) {
+				t.Errorf("Sharpe ratio = %v, want %v", metrics.SharpeRatio, want)
+			}
+		})
+	}
+}
+
+func TestRiskFreeConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		riskFree models.RiskFreeConfig
+		wantErr  string
+	}{
+		{"unknown source", models.RiskFreeConfig{Source: "libor"}, "unsupported source"},
+		{"source not loaded", models.RiskFreeConfig{Source: models.RiskFreeSourceUSTBill3M}, "no rates loaded"},
+		{"rate out of range", models.RiskFreeConfig{Rate: 1}, "rate must be between -1 and 1"},
+		{
+			"series rate out of range",
+			models.RiskFreeConfig{Rates: []models.InterestRate{{Date: testStart, Rate: -1}}},
+			"rate on 2024-01-02 must be between -1 and 1",
+		},
+	}
+
+	bars := dailyBars(models.MarketTypeUSStock, 100, 110)
+	for _, tt := range tests {
+		request := testRequest(testAllIn, nil, bars)
+		request.RiskFree = &tt.riskFree
+		_, err := NewBacktestEngine().RunBacktest(request, testMarket("x", models.MarketTypeUSStock, bars))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPeriodsPerYear(t *testing.T) {
+	markets := map[string]*models.MarketData{
+		"stock":  testMarket("stock", models.MarketTypeUSStock, nil),
+		"crypto": testMarket("crypto", models.MarketTypeCrypto, nil),
+	}
+	first, last := testStart, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	// A union timeline samples on the busiest calendar, an intersection on the quietest
+	tests := []struct {
+		alignment models.DateAlignment
+		want      float64
+	}{
+		{models.DateAlignmentUnion, 366},
+		{models.DateAlignmentIntersection, 252},
+	}
+	for _, tt := range tests {
+		request := models.BacktestRequest{DateAlignment: tt.alignment}
+		if got := periodsPerYear(request, []string{"stock", "crypto"}, markets, first, last); got != tt.want {
+			t.Errorf("%s: periods per year = %v, want %v", tt.alignment, got, tt.want)
+		}
+	}
+}
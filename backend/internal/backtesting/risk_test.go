@@ -0,0 +1,154 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestRiskExits(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          models.RiskManagementConfig
+		closes          []float64
+		open, high, low float64 // Range of the exit bar, 0 where it opens at the previous close
+		price           float64
+		reason          string
+	}{
+		{
+			name:   "stop-loss inside the bar",
+			config: models.RiskManagementConfig{StopLoss: 0.1},
+			closes: []float64{100, 95, 85},
+			price:  90,
+			reason: models.TradeReasonStopLoss,
+		},
+		{
+			name:   "stop-loss gap fills at the open",
+			config: models.RiskManagementConfig{StopLoss: 0.1},
+			closes: []float64{100, 95, 80},
+			open:   85,
+			high:   85,
+			price:  85,
+			reason: models.TradeReasonStopLoss,
+		},
+		{
+			name:   "take-profit inside the bar",
+			config: models.RiskManagementConfig{TakeProfit: 0.1},
+			closes: []float64{100, 105, 115},
+			price:  110,
+			reason: models.TradeReasonTakeProfit,
+		},
+		{
+			name:   "stop-loss before take-profit in one bar",
+			config: models.RiskManagementConfig{StopLoss: 0.1, TakeProfit: 0.1},
+			closes: []float64{100, 100, 105},
+			high:   115,
+			low:    85,
+			price:  90,
+			reason: models.TradeReasonStopLoss,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// All in at 100 on the first bar, out on the last
+			bars := dailyBars(models.MarketTypeUSStock, tt.closes...)
+			exit := &bars[len(bars)-1]
+			if tt.open > 0 {
+				exit.Open = tt.open
+			}
+			if tt.high > 0 {
+				exit.High = tt.high
+			}
+			if tt.low > 0 {
+				exit.Low = tt.low
+			}
+			request := testRequest(testAllIn, nil, bars)
+			request.Strategy.RiskManagement = &tt.config
+			result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+			sells := tradesBy(result, "sell")
+			if len(sells) == 0 || !sells[0].Date.Equal(exit.Date) || sells[0].Price != tt.price || sells[0].Reason != tt.reason {
+				t.Fatalf("sells = %+v, want a %s at %v on the last bar", sells, tt.reason, tt.price)
+			}
+			if sells[0].Quantity != 100 {
+				t.Errorf("exit sold %v, want the whole position of 100", sells[0].Quantity)
+			}
+		})
+	}
+}
+
+func TestPositionCap(t *testing.T) {
+	bars := dailyBars(models.MarketTypeUSStock, 100, 100, 100)
+	request := testRequest(testAllIn, nil, bars)
+	request.Strategy.RiskManagement = &models.RiskManagementConfig{MaxPositionSize: 0.5}
+	result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+	// An all-in entry is cut to half the portfolio value
+	if buys := tradesBy(result, "buy"); len(buys) != 1 || buys[0].Quantity != 50 {
+		t.Errorf("buys = %+v, want one of 50", buys)
+	}
+	if last := result.DailyReturns[len(bars)-1]; last.Cash != 5000 {
+		t.Errorf("cash = %v, want 5000 left uninvested", last.Cash)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	// Down 20% from the entry on bar 2, first back above the trip close on bar 5
+	closes := []float64{100, 100, 80, 75, 78, 85, 90, 95}
+
+	tests := []struct {
+		name     string
+		timing   models.ExecutionTiming
+		reentry  string
+		cooldown int
+		resume   int // Bar trading resumes on, -1 if never
+	}{
+		{"never", models.ExecutionTimingClose, "never", 0, -1},
+		{"after cooldown", models.ExecutionTimingClose, "after_cooldown", 2, 4},
+		{"after cooldown on next close", models.ExecutionTimingNextClose, "after_cooldown", 2, 4},
+		{"above trip price", models.ExecutionTimingClose, "above_trip_price", 0, 6},
+		{"above trip price on next close", models.ExecutionTimingNextClose, "above_trip_price", 0, 6},
+		{"above trip price on next open", models.ExecutionTimingNextOpen, "above_trip_price", 0, 6},
+		{"above trip price after cooldown", models.ExecutionTimingClose, "above_trip_price", 5, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := dailyBars(models.MarketTypeUSStock, closes...)
+			request := testRequest(testAllIn, nil, bars)
+			request.ExecutionTiming = tt.timing
+			request.Strategy.RiskManagement = &models.RiskManagementConfig{
+				MaxDrawdown:      0.1,
+				DrawdownReentry:  tt.reentry,
+				DrawdownCooldown: tt.cooldown,
+			}
+			result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+			if len(result.CircuitBreakerEvents) != 1 {
+				t.Fatalf("got %d circuit breaker events, want 1", len(result.CircuitBreakerEvents))
+			}
+			event := result.CircuitBreakerEvents[0]
+			if !event.TripDate.Equal(bars[2].Date) {
+				t.Errorf("tripped on %s, want %s", event.TripDate.Format("2006-01-02"), bars[2].Date.Format("2006-01-02"))
+			}
+			if !closeTo(event.Drawdown, 0.2) {
+				t.Errorf("trip drawdown = %v, want 0.2", event.Drawdown)
+			}
+
+			// Strategies trade again from the resume bar, and not before
+			buys := tradesBy(result, "buy")
+			if tt.resume < 0 {
+				if event.ResumeDate != nil || len(buys) != 1 {
+					t.Errorf("resumed on %v with %d buys, want no resume", event.ResumeDate, len(buys))
+				}
+				return
+			}
+			if event.ResumeDate == nil || !event.ResumeDate.Equal(bars[tt.resume].Date) {
+				t.Fatalf("resume date = %v, want %s", event.ResumeDate, bars[tt.resume].Date.Format("2006-01-02"))
+			}
+			if len(buys) != 2 || !buys[1].Date.Equal(bars[tt.resume].Date) {
+				t.Errorf("buys = %+v, want the re-entry on %s", buys, bars[tt.resume].Date.Format("2006-01-02"))
+			}
+		})
+	}
+}
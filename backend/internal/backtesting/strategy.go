@@ -0,0 +1,630 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/calendar"
+	"macro_strategy/internal/models"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Strategy defines the lifecycle of a trading strategy driven by the engine.
+// Strategies only make decisions; cash, position and trade bookkeeping is
+// handled by the shared Portfolio behind the StrategyContext.
+type Strategy interface {
+	// Init is called once before the first bar is processed
+	Init(ctx *StrategyContext) error
+	// OnBar is called once per bar after the bar at ctx.Index has closed
+	OnBar(ctx *StrategyContext) error
+	// Finalize is called once after the last bar has been processed
+	Finalize(ctx *StrategyContext) error
+}
+
+// ResultReporter is implemented by strategies that add their own analytics,
+// such as rankings or weight histories, to the backtest result
+type ResultReporter interface {
+	ReportResult(result *models.BacktestResult)
+}
+
+// StrategyFactory builds a strategy instance from request parameters
+type StrategyFactory func(parameters map[string]interface{}) (Strategy, error)
+
+// ParameterSpec describes a single strategy parameter for the catalog and validation
+type ParameterSpec struct {
+	Type        string      `json:"type"` // "integer", "float", "string", "boolean", "object", "array"
+	Default     interface{} `json:"default"`
+	Range       []float64   `json:"range,omitempty"`
+	Options     []string    `json:"options,omitempty"`
+	Description string      `json:"description"`
+}
+
+// StrategyDefinition describes a registered strategy type
+type StrategyDefinition struct {
+	Type        models.StrategyType
+	Name        string
+	Description string
+	Parameters  map[string]ParameterSpec
+	Factory     StrategyFactory
+	MultiAsset  bool // Trades a universe of assets in portfolio mode
+}
+
+var (
+	strategyRegistry   = make(map[models.StrategyType]StrategyDefinition)
+	strategyRegistryMu sync.RWMutex
+)
+
+// RegisterStrategy registers a strategy type so the engine can run it
+func RegisterStrategy(def StrategyDefinition) {
+	if def.Type == "" || def.Factory == nil {
+		panic("backtesting: strategy definition requires a type and a factory")
+	}
+
+	strategyRegistryMu.Lock()
+	defer strategyRegistryMu.Unlock()
+
+	if _, exists := strategyRegistry[def.Type]; exists {
+		panic(fmt.Sprintf("backtesting: strategy %s registered twice", def.Type))
+	}
+	strategyRegistry[def.Type] = def
+}
+
+// GetStrategyDefinition returns the definition registered for a strategy type
+func GetStrategyDefinition(strategyType models.StrategyType) (StrategyDefinition, bool) {
+	strategyRegistryMu.RLock()
+	defer strategyRegistryMu.RUnlock()
+
+	def, ok := strategyRegistry[strategyType]
+	return def, ok
+}
+
+// SupportedStrategies returns all registered strategy definitions sorted by type
+func SupportedStrategies() []StrategyDefinition {
+	strategyRegistryMu.RLock()
+	defer strategyRegistryMu.RUnlock()
+
+	defs := make([]StrategyDefinition, 0, len(strategyRegistry))
+	for _, def := range strategyRegistry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Type < defs[j].Type
+	})
+	return defs
+}
+
+// ValidateStrategyConfig checks that a strategy type is registered and that
+// its parameters match the registered schema and can build a strategy
+func ValidateStrategyConfig(config models.StrategyConfig) error {
+	if config.Type == "" {
+		return fmt.Errorf("strategy type is required")
+	}
+
+	def, ok := GetStrategyDefinition(config.Type)
+	if !ok {
+		return fmt.Errorf("unsupported strategy type: %s", config.Type)
+	}
+
+	for name, value := range config.Parameters {
+		spec, ok := def.Parameters[name]
+		if !ok {
+			continue // Unknown parameters are ignored
+		}
+		if err := spec.validate(name, value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := def.Factory(config.Parameters); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validate checks a single parameter value against its spec
+func (spec ParameterSpec) validate(name string, value interface{}) error {
+	switch spec.Type {
+	case "integer", "float":
+		number, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s must be a number", name)
+		}
+		if spec.Type == "integer" && number != float64(int(number)) {
+			return fmt.Errorf("%s must be an integer", name)
+		}
+		if len(spec.Range) == 2 && (number < spec.Range[0] || number > spec.Range[1]) {
+			return fmt.Errorf("%s must be between %g and %g", name, spec.Range[0], spec.Range[1])
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", name)
+		}
+		if len(spec.Options) > 0 {
+			for _, option := range spec.Options {
+				if str == option {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s must be one of %v", name, spec.Options)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", name)
+		}
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("%s must be an object", name)
+		}
+	case "array":
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("%s must be an array", name)
+		}
+	}
+	return nil
+}
+
+// StrategyContext exposes market data, portfolio state and order entry to a strategy.
+// Index is the bar the strategy decides on; with a next-bar execution timing
+// its orders fill on a later bar the strategy cannot see.
+//
+// Single-asset strategies use Data, Bar, Price and the order methods without
+// an asset, which all refer to the first asset. In a single-asset backtest
+// Index indexes Data; in portfolio mode Index walks the aligned timeline of
+// all Assets and strategies use the Asset* helpers and target orders.
+type StrategyContext struct {
+	Request   models.BacktestRequest
+	Data      []models.OHLCV // Bars of the first asset
+	Index     int
+	Portfolio *Portfolio
+	Calendar  *calendar.Calendar // Exchange calendar of the first asset
+	Assets    []string           // Traded asset IDs in request order
+
+	dates     []time.Time             // Backtest timeline
+	series    map[string]*assetSeries // Bars of every asset mapped onto the timeline
+	execIndex int                     // Bar orders fill on
+	timing    models.ExecutionTiming  // Which price of the fill bar strategy orders get
+	risk      *riskManager            // Risk overlay applied to every order, may be nil
+	reason    string                  // Reason tagged on strategy orders for the current phase
+
+	actions    map[string][]models.CorporateAction // Corporate actions of every asset in ex-date order
+	nextAction map[string]int                      // First corporate action of each asset not yet booked
+	dividends  map[string]float64                  // Dividend cash booked per asset on the execution bar
+	reinvested bool                                // Whether the bar's dividends were reinvested
+	fxRates    map[string][]float64                // FX rates into the base currency on the timeline, foreign assets only
+
+	periodsPerYear float64 // Trading days a year returns annualize with, as in the performance metrics
+}
+
+// asset returns the first asset, the one single-asset strategies trade
+func (ctx *StrategyContext) asset() string {
+	return ctx.Assets[0]
+}
+
+// Bar returns the current bar
+func (ctx *StrategyContext) Bar() models.OHLCV {
+	bar, _ := ctx.series[ctx.asset()].bar(ctx.Index)
+	return bar
+}
+
+// Date returns the date of the current bar
+func (ctx *StrategyContext) Date() time.Time {
+	return ctx.dates[ctx.Index]
+}
+
+// PeriodsPerYear returns the trading days a year on the backtest's calendar,
+// which strategies annualize daily volatility with like the metrics do
+func (ctx *StrategyContext) PeriodsPerYear() float64 {
+	if ctx.periodsPerYear <= 0 {
+		return defaultPeriodsPerYear
+	}
+	return ctx.periodsPerYear
+}
+
+// Price returns the closing price of the current bar
+func (ctx *StrategyContext) Price() float64 {
+	return ctx.AssetPrice(ctx.asset())
+}
+
+// History returns all bars up to and including the current one
+func (ctx *StrategyContext) History() []models.OHLCV {
+	return ctx.AssetHistory(ctx.asset())
+}
+
+// Position returns the current position
+func (ctx *StrategyContext) Position() models.Position {
+	return ctx.Portfolio.Position(ctx.asset())
+}
+
+// AssetPrice returns an asset's latest close at the current bar, zero before
+// its first bar
+func (ctx *StrategyContext) AssetPrice(asset string) float64 {
+	bar, _ := ctx.series[asset].bar(ctx.Index)
+	return bar.Close
+}
+
+// AssetBar returns an asset's bar on the current date; ok is false while its
+// market is closed
+func (ctx *StrategyContext) AssetBar(asset string) (models.OHLCV, bool) {
+	series := ctx.series[asset]
+	bar, ok := series.bar(ctx.Index)
+	return bar, ok && series.tradable(ctx.Index, ctx.dates)
+}
+
+// AssetHistory returns an asset's own bars up to and including the current date
+func (ctx *StrategyContext) AssetHistory(asset string) []models.OHLCV {
+	return ctx.series[asset].history(ctx.Index)
+}
+
+// Tradable reports whether an asset's market is open on the bar orders fill on
+func (ctx *StrategyContext) Tradable(asset string) bool {
+	return ctx.series[asset].tradable(ctx.execIndex, ctx.dates)
+}
+
+// Buy buys the given quantity at the execution price
+func (ctx *StrategyContext) Buy(quantity float64) *models.Trade {
+	return ctx.fill(ctx.asset(), "buy", ctx.executionPrice(ctx.asset()), quantity, ctx.reason)
+}
+
+// BuyValue buys as much as the given amount of cash allows at the execution price
+func (ctx *StrategyContext) BuyValue(value float64) *models.Trade {
+	price := ctx.executionPrice(ctx.asset())
+	return ctx.fill(ctx.asset(), "buy", price, ctx.affordableQuantity(ctx.asset(), price, value), ctx.reason)
+}
+
+// Sell sells the given quantity at the execution price
+func (ctx *StrategyContext) Sell(quantity float64) *models.Trade {
+	return ctx.fill(ctx.asset(), "sell", ctx.executionPrice(ctx.asset()), quantity, ctx.reason)
+}
+
+// SellAll closes the whole long position at the execution price
+func (ctx *StrategyContext) SellAll() *models.Trade {
+	return ctx.Sell(ctx.Position().Quantity)
+}
+
+// Short sells the given quantity short at the execution price. Shorting
+// requires a margin account that allows it.
+func (ctx *StrategyContext) Short(quantity float64) *models.Trade {
+	return ctx.fill(ctx.asset(), "short", ctx.executionPrice(ctx.asset()), quantity, ctx.reason)
+}
+
+// ShortValue shorts up to the given notional value as far as the initial
+// margin allows at the execution price
+func (ctx *StrategyContext) ShortValue(value float64) *models.Trade {
+	// Size against the slipped price so the order stays within margin
+	asset := ctx.asset()
+	price := ctx.executionPrice(asset)
+	estimate := ctx.Portfolio.ShortableQuantity(asset, price, value)
+	fillPrice := ctx.Portfolio.assets[asset].costs.fillPrice("sell", price, estimate, ctx.series[asset].history(ctx.execIndex))
+	return ctx.Short(ctx.Portfolio.ShortableQuantity(asset, fillPrice, value))
+}
+
+// Cover buys back the given quantity of a short position at the execution price
+func (ctx *StrategyContext) Cover(quantity float64) *models.Trade {
+	return ctx.fill(ctx.asset(), "cover", ctx.executionPrice(ctx.asset()), quantity, ctx.reason)
+}
+
+// CoverAll buys back the whole short position at the execution price
+func (ctx *StrategyContext) CoverAll() *models.Trade {
+	return ctx.Cover(-ctx.Position().Quantity)
+}
+
+// ClosePosition flattens the position, long or short, at the execution price
+func (ctx *StrategyContext) ClosePosition() *models.Trade {
+	return ctx.closePosition(ctx.asset(), ctx.executionPrice(ctx.asset()), ctx.reason)
+}
+
+// CloseAll flattens every position at the execution price
+func (ctx *StrategyContext) CloseAll() {
+	ctx.closeAll(ctx.reason, ctx.executionPrice)
+}
+
+// OrderTargetQuantity trades an asset towards a target quantity at the
+// execution price; a negative target is a short. Positions are reduced or
+// closed before they are flipped, and cash-account buys are cut to the cash
+// available. It returns the last trade made.
+func (ctx *StrategyContext) OrderTargetQuantity(asset string, target float64) *models.Trade {
+	price := ctx.executionPrice(asset)
+	current := ctx.Portfolio.Position(asset).Quantity
+
+	var trade *models.Trade
+	switch {
+	case current > 0 && target < current:
+		trade = ctx.fill(asset, "sell", price, current-math.Max(target, 0), ctx.reason)
+	case current < 0 && target > current:
+		trade = ctx.fill(asset, "cover", price, math.Min(target, 0)-current, ctx.reason)
+	}
+
+	current = ctx.Portfolio.Position(asset).Quantity
+	switch {
+	case target > 0 && current >= 0 && target > current:
+		quantity := target - current
+		if ctx.Portfolio.margin == nil {
+			quantity = math.Min(quantity, ctx.affordableQuantity(asset, price, ctx.Portfolio.Cash))
+		}
+		if opened := ctx.fill(asset, "buy", price, quantity, ctx.reason); opened != nil {
+			trade = opened
+		}
+	case target < 0 && current <= 0 && target < current:
+		if opened := ctx.fill(asset, "short", price, current-target, ctx.reason); opened != nil {
+			trade = opened
+		}
+	}
+	return trade
+}
+
+// OrderTargetValue trades an asset towards a target market value at the
+// execution price; a negative value is a short
+func (ctx *StrategyContext) OrderTargetValue(asset string, value float64) *models.Trade {
+	price := ctx.executionPrice(asset)
+	if price <= 0 {
+		return nil
+	}
+	return ctx.OrderTargetQuantity(asset, value/(price*ctx.Portfolio.FXRate(asset)))
+}
+
+// OrderTargetWeights rebalances the portfolio to target weights of its value,
+// keyed by asset ID. Assets without a weight are closed, negative weights are
+// shorts and weights summing below one leave the rest in cash. Reductions are
+// traded first so their proceeds fund the increases; assets whose market is
+// closed keep their position.
+func (ctx *StrategyContext) OrderTargetWeights(weights map[string]float64) []*models.Trade {
+	value := ctx.Portfolio.Value()
+	targets := make(map[string]float64, len(ctx.Assets))
+	for _, asset := range ctx.Assets {
+		if price := ctx.executionPrice(asset); price > 0 {
+			targets[asset] = weights[asset] * value / (price * ctx.Portfolio.FXRate(asset))
+		}
+	}
+
+	var trades []*models.Trade
+	for _, reducing := range []bool{true, false} {
+		for _, asset := range ctx.Assets {
+			target, ok := targets[asset]
+			if !ok {
+				continue
+			}
+			current := ctx.Portfolio.Position(asset).Quantity
+			reduces := math.Abs(target) < math.Abs(current) || target*current < 0
+			if reduces != reducing {
+				continue
+			}
+			if trade := ctx.OrderTargetQuantity(asset, target); trade != nil {
+				trades = append(trades, trade)
+			}
+		}
+	}
+	return trades
+}
+
+// affordableQuantity sizes a buy of up to value in cash against the slipped
+// fill price so the order stays fundable
+func (ctx *StrategyContext) affordableQuantity(asset string, price, value float64) float64 {
+	estimate := ctx.Portfolio.AffordableQuantity(asset, price, value)
+	fillPrice := ctx.Portfolio.assets[asset].costs.fillPrice("buy", price, estimate, ctx.series[asset].history(ctx.execIndex))
+	return ctx.Portfolio.AffordableQuantity(asset, fillPrice, value)
+}
+
+// closePosition flattens the position in an asset at price, tagging the trade with reason
+func (ctx *StrategyContext) closePosition(asset string, price float64, reason string) *models.Trade {
+	quantity := ctx.Portfolio.Position(asset).Quantity
+	switch {
+	case quantity > 0:
+		return ctx.fill(asset, "sell", price, quantity, reason)
+	case quantity < 0:
+		return ctx.fill(asset, "cover", price, -quantity, reason)
+	}
+	return nil
+}
+
+// closeAll flattens every position at the price given per asset
+func (ctx *StrategyContext) closeAll(reason string, price func(asset string) float64) {
+	for _, asset := range ctx.Assets {
+		ctx.closePosition(asset, price(asset), reason)
+	}
+}
+
+// fill executes an order against the portfolio after the risk overlay has
+// sized it and slippage has moved the price onto the market's tick grid, and
+// tags the resulting trade. The portfolio rounds the quantity to whole lots.
+// Orders in an asset whose market is closed on the fill bar are rejected.
+func (ctx *StrategyContext) fill(asset, action string, price, quantity float64, reason string) *models.Trade {
+	series, ok := ctx.series[asset]
+	if !ok || !series.tradable(ctx.execIndex, ctx.dates) {
+		return nil
+	}
+
+	position := ctx.Portfolio.Position(asset)
+	switch action {
+	case "buy", "short":
+		if ctx.risk != nil {
+			quantity = ctx.risk.capEntryQuantity(ctx.Portfolio, asset, price, quantity)
+		}
+	case "sell":
+		quantity = math.Min(quantity, position.Quantity)
+	case "cover":
+		quantity = math.Min(quantity, -position.Quantity)
+	}
+	if quantity <= 0 {
+		return nil
+	}
+
+	side := orderSide(action)
+	rules := ctx.Portfolio.assets[asset]
+	bars := series.history(ctx.execIndex)
+	fillDate := bars[len(bars)-1].Date
+	fillPrice := rules.costs.fillPrice(side, price, quantity, bars)
+	fillPrice = rules.lots.RoundPrice(side, fillPrice)
+
+	var trade *models.Trade
+	switch action {
+	case "buy":
+		trade = ctx.Portfolio.Buy(asset, fillDate, fillPrice, quantity)
+	case "sell":
+		trade = ctx.Portfolio.Sell(asset, fillDate, fillPrice, quantity)
+	case "short":
+		trade = ctx.Portfolio.Short(asset, fillDate, fillPrice, quantity)
+	case "cover":
+		trade = ctx.Portfolio.Cover(asset, fillDate, fillPrice, quantity)
+	}
+
+	if trade != nil {
+		trade.Slippage = math.Abs(fillPrice-price) * trade.Quantity
+		trade.Reason = reason
+	}
+	return trade
+}
+
+// executionPrice returns the price strategy orders in an asset fill at: the
+// close of the decision bar, or the open, close or OHLC average of the next bar
+func (ctx *StrategyContext) executionPrice(asset string) float64 {
+	bar, ok := ctx.series[asset].bar(ctx.execIndex)
+	if !ok {
+		return 0
+	}
+	if ctx.execIndex == ctx.Index {
+		return bar.Close // Same-bar fills and end-of-backtest liquidation
+	}
+
+	switch ctx.timing {
+	case models.ExecutionTimingNextOpen:
+		if bar.Open > 0 {
+			return bar.Open
+		}
+	case models.ExecutionTimingNextVWAP:
+		if bar.Open > 0 && bar.High > 0 && bar.Low > 0 {
+			return (bar.Open + bar.High + bar.Low + bar.Close) / 4
+		}
+	}
+	return bar.Close
+}
+
+// markToMarket revalues every position at its asset's latest close
+func (ctx *StrategyContext) markToMarket() {
+	for _, asset := range ctx.Assets {
+		if price := ctx.AssetPrice(asset); price > 0 {
+			ctx.Portfolio.MarkToMarket(asset, price)
+		}
+	}
+}
+
+// IsPeriodStart reports whether the current bar is the first of a new daily,
+// weekly, monthly, quarterly or yearly period on the backtest timeline. The
+// first bar always starts a period.
+func (ctx *StrategyContext) IsPeriodStart(frequency string) bool {
+	if ctx.Index == 0 {
+		return true
+	}
+	return startsPeriod(ctx.dates[ctx.Index-1], ctx.dates[ctx.Index], frequency)
+}
+
+// Deposit injects external cash into the portfolio on the current bar
+func (ctx *StrategyContext) Deposit(amount float64) {
+	ctx.Portfolio.Deposit(amount)
+}
+
+// Parameter helpers shared by strategy factories
+
+// intParam reads an integer parameter, falling back to a default when absent
+func intParam(parameters map[string]interface{}, name string, defaultValue int) (int, error) {
+	raw, ok := parameters[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	value, ok := raw.(float64)
+	if !ok {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+	return int(value), nil
+}
+
+// floatParam reads a float parameter, falling back to a default when absent
+func floatParam(parameters map[string]interface{}, name string, defaultValue float64) (float64, error) {
+	raw, ok := parameters[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	value, ok := raw.(float64)
+	if !ok {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+	return value, nil
+}
+
+// stringParam reads a string parameter, falling back to a default when absent
+func stringParam(parameters map[string]interface{}, name string, defaultValue string) (string, error) {
+	raw, ok := parameters[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid %s parameter", name)
+	}
+	return value, nil
+}
+
+// boolParam reads a boolean parameter, falling back to a default when absent
+func boolParam(parameters map[string]interface{}, name string, defaultValue bool) (bool, error) {
+	raw, ok := parameters[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	value, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("invalid %s parameter", name)
+	}
+	return value, nil
+}
+
+// weightsParam reads an object parameter of numeric weights keyed by asset
+// ID, returning nil when absent
+func weightsParam(parameters map[string]interface{}, name string) (map[string]float64, error) {
+	raw, ok := parameters[name]
+	if !ok {
+		return nil, nil
+	}
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s parameter", name)
+	}
+	weights := make(map[string]float64, len(object))
+	for asset, value := range object {
+		weight, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s weight for %s", name, asset)
+		}
+		weights[asset] = weight
+	}
+	return weights, nil
+}
+
+// isPeriodStart reports whether bar i is the first bar of a new daily, weekly
+// or monthly period. The first bar always starts a period.
+func isPeriodStart(data []models.OHLCV, i int, frequency string) bool {
+	if i == 0 {
+		return true
+	}
+	return startsPeriod(data[i-1].Date, data[i].Date, frequency)
+}
+
+// startsPeriod reports whether cur falls in a later daily, weekly, monthly,
+// quarterly or yearly period than prev
+func startsPeriod(prev, cur time.Time, frequency string) bool {
+	switch frequency {
+	case "weekly":
+		prevYear, prevWeek := prev.ISOWeek()
+		curYear, curWeek := cur.ISOWeek()
+		return prevYear != curYear || prevWeek != curWeek
+	case "monthly":
+		return prev.Year() != cur.Year() || prev.Month() != cur.Month()
+	case "quarterly":
+		return prev.Year() != cur.Year() || (prev.Month()-1)/3 != (cur.Month()-1)/3
+	case "yearly":
+		return prev.Year() != cur.Year()
+	default: // daily
+		return true
+	}
+}
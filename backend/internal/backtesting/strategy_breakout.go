@@ -0,0 +1,138 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeBreakout,
+		Name:        "Breakout Strategy",
+		Description: "Donchian channel breakout: buy above the N-day high, exit below the exit channel low or an ATR trailing stop",
+		Parameters: map[string]ParameterSpec{
+			"entry_window": {
+				Type:        "integer",
+				Default:     20,
+				Range:       []float64{1, 252},
+				Description: "Close above the highest high of this many prior bars triggers an entry",
+			},
+			"exit_window": {
+				Type:        "integer",
+				Default:     10,
+				Range:       []float64{1, 252},
+				Description: "Close below the lowest low of this many prior bars triggers an exit",
+			},
+			"atr_period": {
+				Type:        "integer",
+				Default:     14,
+				Range:       []float64{1, 100},
+				Description: "Number of bars used for the average true range",
+			},
+			"atr_multiplier": {
+				Type:        "float",
+				Default:     3.0,
+				Range:       []float64{0, 10},
+				Description: "Trailing stop distance below the highest high since entry in ATRs, 0 disables it",
+			},
+		},
+		Factory: newBreakoutStrategy,
+	})
+}
+
+// breakoutStrategy trades Donchian channel breakouts on High/Low with an ATR trailing stop
+type breakoutStrategy struct {
+	params       *models.BreakoutParams
+	highestSince float64 // Highest high since entry, anchors the trailing stop
+}
+
+// newBreakoutStrategy creates a breakout strategy from parameters
+func newBreakoutStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseBreakoutParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &breakoutStrategy{params: params}, nil
+}
+
+// parseBreakoutParams parses and validates breakout strategy parameters
+func parseBreakoutParams(parameters map[string]interface{}) (*models.BreakoutParams, error) {
+	entryWindow, err := intParam(parameters, "entry_window", 20)
+	if err != nil {
+		return nil, err
+	}
+	exitWindow, err := intParam(parameters, "exit_window", 10)
+	if err != nil {
+		return nil, err
+	}
+	atrPeriod, err := intParam(parameters, "atr_period", 14)
+	if err != nil {
+		return nil, err
+	}
+	atrMultiplier, err := floatParam(parameters, "atr_multiplier", 3.0)
+	if err != nil {
+		return nil, err
+	}
+
+	if entryWindow < 1 || exitWindow < 1 || atrPeriod < 1 {
+		return nil, fmt.Errorf("entry_window, exit_window and atr_period must be at least 1")
+	}
+	if atrMultiplier < 0 {
+		return nil, fmt.Errorf("atr_multiplier must not be negative")
+	}
+
+	return &models.BreakoutParams{
+		EntryWindow:   entryWindow,
+		ExitWindow:    exitWindow,
+		ATRPeriod:     atrPeriod,
+		ATRMultiplier: atrMultiplier,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *breakoutStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *breakoutStrategy) OnBar(ctx *StrategyContext) error {
+	history := ctx.History()
+	prior := history[:len(history)-1] // Channels exclude the current bar
+	bar := ctx.Bar()
+
+	if ctx.Position().Quantity == 0 {
+		upper, ok := highestHigh(prior, s.params.EntryWindow)
+		if ok && bar.Close > upper {
+			if trade := ctx.BuyValue(ctx.Portfolio.Cash); trade != nil {
+				s.highestSince = bar.High
+			}
+		}
+		return nil
+	}
+
+	s.highestSince = math.Max(s.highestSince, bar.High)
+
+	if lower, ok := lowestLow(prior, s.params.ExitWindow); ok && bar.Close < lower {
+		ctx.SellAll()
+		return nil
+	}
+
+	if s.params.ATRMultiplier > 0 {
+		if atr, ok := averageTrueRange(history, s.params.ATRPeriod); ok {
+			trailingStop := s.highestSince - s.params.ATRMultiplier*atr
+			if bar.Close < trailingStop {
+				ctx.SellAll()
+			}
+		}
+	}
+
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *breakoutStrategy) Finalize(ctx *StrategyContext) error {
+	// Close the open position so the last round trip is counted
+	ctx.SellAll()
+	return nil
+}
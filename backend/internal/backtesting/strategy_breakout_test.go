@@ -0,0 +1,79 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestBreakoutStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]interface{}
+		closes     []float64
+		buy, sell  int // Bars of the entry and the exit
+	}{
+		{
+			// Exits below the 2-bar low of 12 on bar 7
+			name:       "channel exit",
+			parameters: map[string]interface{}{"entry_window": 3.0, "exit_window": 2.0, "atr_multiplier": 0.0},
+			closes:     []float64{10, 10, 10, 11, 12, 13, 12.5, 11.9, 11.5, 11},
+			buy:        3,
+			sell:       7,
+		},
+		{
+			// Exits below the high of 15 less the 2-bar ATR on bar 7
+			name:       "ATR trailing stop",
+			parameters: map[string]interface{}{"entry_window": 3.0, "exit_window": 20.0, "atr_period": 2.0, "atr_multiplier": 1.0},
+			closes:     []float64{10, 10, 10, 11, 13, 15, 14.5, 13.4, 13, 12.8},
+			buy:        3,
+			sell:       7,
+		},
+		{
+			// A one-bar channel enters on the first close above the previous high
+			name:       "one-bar channels",
+			parameters: map[string]interface{}{"entry_window": 1.0, "exit_window": 1.0, "atr_multiplier": 0.0},
+			closes:     []float64{10, 10, 10.5, 11, 10.8, 10.6},
+			buy:        2,
+			sell:       5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := dailyBars(models.MarketTypeUSStock, tt.closes...)
+			request := testRequest(models.StrategyTypeBreakout, tt.parameters, bars)
+			if err := ValidateStrategyConfig(request.Strategy); err != nil {
+				t.Fatalf("catalog rejects the parameters: %v", err)
+			}
+			result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+			buys, sells := tradesBy(result, "buy"), tradesBy(result, "sell")
+			if len(buys) != 1 || !buys[0].Date.Equal(bars[tt.buy].Date) || buys[0].Price != tt.closes[tt.buy] {
+				t.Errorf("buys = %+v, want one at %v on bar %d", buys, tt.closes[tt.buy], tt.buy)
+			}
+			if len(sells) != 1 || !sells[0].Date.Equal(bars[tt.sell].Date) || sells[0].Price != tt.closes[tt.sell] {
+				t.Errorf("sells = %+v, want one at %v on bar %d", sells, tt.closes[tt.sell], tt.sell)
+			}
+		})
+	}
+}
+
+func TestBreakoutParameters(t *testing.T) {
+	tests := []struct {
+		parameters map[string]interface{}
+		valid      bool
+	}{
+		{map[string]interface{}{"entry_window": 1.0, "exit_window": 1.0}, true},
+		{map[string]interface{}{"entry_window": 252.0}, true},
+		{map[string]interface{}{"entry_window": 0.0}, false},
+		{map[string]interface{}{"exit_window": 253.0}, false},
+		{map[string]interface{}{"atr_multiplier": -1.0}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.StrategyConfig{Type: models.StrategyTypeBreakout, Parameters: tt.parameters}
+		if err := ValidateStrategyConfig(config); (err == nil) != tt.valid {
+			t.Errorf("ValidateStrategyConfig(%v) error = %v, want valid %v", tt.parameters, err, tt.valid)
+		}
+	}
+}
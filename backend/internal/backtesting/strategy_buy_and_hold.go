@@ -0,0 +1,156 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+	"time"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeBuyAndHold,
+		Name:        "Buy and Hold Strategy",
+		Description: "Buy and hold with optional rebalancing",
+		Parameters: map[string]ParameterSpec{
+			"target_allocation": {
+				Type:        "float",
+				Default:     1.0,
+				Range:       []float64{0.1, 1.0},
+				Description: "Target allocation percentage (0.1 = 10%, 1.0 = 100%)",
+			},
+			"rebalance_frequency": {
+				Type:        "string",
+				Default:     "never",
+				Options:     []string{"never", "monthly", "quarterly", "yearly"},
+				Description: "How often to rebalance the portfolio",
+			},
+			"dividend_reinvest": {
+				Type:        "boolean",
+				Default:     false,
+				Description: "Reinvest cash dividends into the asset on the ex-date instead of keeping them in cash",
+			},
+		},
+		Factory: newBuyAndHoldStrategy,
+	})
+}
+
+// buyAndHoldStrategy buys on the first bar and optionally rebalances to a target allocation
+type buyAndHoldStrategy struct {
+	params             *models.BuyAndHoldParams
+	initialPurchase    bool
+	lastRebalanceMonth int
+}
+
+// newBuyAndHoldStrategy creates a buy and hold strategy from parameters
+func newBuyAndHoldStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseBuyAndHoldParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &buyAndHoldStrategy{params: params, lastRebalanceMonth: -1}, nil
+}
+
+// parseBuyAndHoldParams parses buy and hold strategy parameters
+func parseBuyAndHoldParams(parameters map[string]interface{}) (*models.BuyAndHoldParams, error) {
+	rebalanceFreq, err := stringParam(parameters, "rebalance_frequency", "never")
+	if err != nil {
+		return nil, err
+	}
+	dividendReinvest, err := boolParam(parameters, "dividend_reinvest", false)
+	if err != nil {
+		return nil, err
+	}
+	targetAllocation, err := floatParam(parameters, "target_allocation", 1.0) // 100% allocation by default
+	if err != nil {
+		return nil, err
+	}
+	if targetAllocation <= 0 || targetAllocation > 1.0 {
+		return nil, fmt.Errorf("target_allocation must be in (0, 1]")
+	}
+
+	return &models.BuyAndHoldParams{
+		RebalanceFrequency: rebalanceFreq,
+		DividendReinvest:   dividendReinvest,
+		TargetAllocation:   targetAllocation,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *buyAndHoldStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *buyAndHoldStrategy) OnBar(ctx *StrategyContext) error {
+	// Initial purchase on first day
+	if !s.initialPurchase {
+		if trade := ctx.BuyValue(ctx.Portfolio.Cash * s.params.TargetAllocation); trade != nil {
+			s.initialPurchase = true
+		}
+	}
+
+	if s.params.DividendReinvest {
+		ctx.ReinvestDividends()
+	}
+
+	// Check for rebalancing
+	if s.params.RebalanceFrequency == "never" || s.params.RebalanceFrequency == "" {
+		return nil
+	}
+	if !shouldRebalance(ctx.Date(), s.params.RebalanceFrequency, &s.lastRebalanceMonth) {
+		return nil
+	}
+
+	// Simple rebalancing: maintain target allocation
+	currentPrice := ctx.Price() * ctx.Portfolio.FXRate(ctx.asset()) // In the base currency
+	currentPositionValue := ctx.Position().Quantity * currentPrice
+	currentPortfolioValue := ctx.Portfolio.Cash + currentPositionValue
+	targetPositionValue := currentPortfolioValue * s.params.TargetAllocation
+
+	// If position value differs significantly from target, rebalance
+	if math.Abs(currentPositionValue-targetPositionValue) <= currentPortfolioValue*0.05 { // 5% threshold
+		return nil
+	}
+
+	if currentPositionValue > targetPositionValue {
+		// Sell excess
+		ctx.Sell((currentPositionValue - targetPositionValue) / currentPrice) // Rounded to whole lots
+	} else {
+		// Buy more
+		ctx.BuyValue(targetPositionValue - currentPositionValue)
+	}
+
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *buyAndHoldStrategy) Finalize(ctx *StrategyContext) error {
+	return nil
+}
+
+// shouldRebalance determines if rebalancing should occur
+func shouldRebalance(currentDate time.Time, frequency string, lastRebalanceMonth *int) bool {
+	currentMonth := int(currentDate.Month())
+	currentYear := currentDate.Year()
+
+	switch frequency {
+	case "monthly":
+		if *lastRebalanceMonth != currentMonth {
+			*lastRebalanceMonth = currentMonth
+			return true
+		}
+	case "quarterly":
+		if currentMonth%3 == 1 && *lastRebalanceMonth != currentMonth { // January, April, July, October
+			*lastRebalanceMonth = currentMonth
+			return true
+		}
+	case "yearly":
+		if currentMonth == 1 && *lastRebalanceMonth != currentYear*12+currentMonth {
+			*lastRebalanceMonth = currentYear*12 + currentMonth
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeDCA,
+		Name:        "Dollar Cost Averaging Strategy",
+		Description: "Contribute a fixed amount of new cash on a schedule and invest it immediately",
+		Parameters: map[string]ParameterSpec{
+			"investment_amount": {
+				Type:        "float",
+				Default:     1000.0,
+				Description: "New cash contributed and invested on every installment",
+			},
+			"frequency": {
+				Type:        "string",
+				Default:     "monthly",
+				Options:     []string{"daily", "weekly", "monthly"},
+				Description: "How often an installment is made",
+			},
+			"duration_months": {
+				Type:        "integer",
+				Default:     0,
+				Range:       []float64{0, 600},
+				Description: "Number of months to keep contributing, 0 contributes until the end",
+			},
+			"start_delay": {
+				Type:        "integer",
+				Default:     0,
+				Range:       []float64{0, 3650},
+				Description: "Calendar days to wait after the backtest start before the first installment",
+			},
+		},
+		Factory: newDCAStrategy,
+	})
+}
+
+// dcaStrategy injects external cash on a schedule and buys with it. The
+// initial cash is invested together with the first installment.
+type dcaStrategy struct {
+	params *models.DCAParams
+}
+
+// newDCAStrategy creates a dollar cost averaging strategy from parameters
+func newDCAStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseDCAParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &dcaStrategy{params: params}, nil
+}
+
+// parseDCAParams parses and validates DCA strategy parameters
+func parseDCAParams(parameters map[string]interface{}) (*models.DCAParams, error) {
+	amount, err := floatParam(parameters, "investment_amount", 1000)
+	if err != nil {
+		return nil, err
+	}
+	frequency, err := stringParam(parameters, "frequency", "monthly")
+	if err != nil {
+		return nil, err
+	}
+	duration, err := intParam(parameters, "duration_months", 0)
+	if err != nil {
+		return nil, err
+	}
+	startDelay, err := intParam(parameters, "start_delay", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("investment_amount must be positive")
+	}
+	if duration < 0 {
+		return nil, fmt.Errorf("duration_months must not be negative")
+	}
+	if startDelay < 0 {
+		return nil, fmt.Errorf("start_delay must not be negative")
+	}
+
+	return &models.DCAParams{
+		InvestmentAmount: amount,
+		Frequency:        frequency,
+		DurationMonths:   duration,
+		StartDelay:       startDelay,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *dcaStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *dcaStrategy) OnBar(ctx *StrategyContext) error {
+	firstInstallment := ctx.Data[0].Date.AddDate(0, 0, s.params.StartDelay)
+	currentDate := ctx.Date()
+	if currentDate.Before(firstInstallment) {
+		return nil
+	}
+	if s.params.DurationMonths > 0 && !currentDate.Before(firstInstallment.AddDate(0, s.params.DurationMonths, 0)) {
+		return nil
+	}
+
+	// The first bar on or after the delay always gets an installment
+	isFirst := ctx.Index == 0 || ctx.Data[ctx.Index-1].Date.Before(firstInstallment)
+	if !isFirst && !isPeriodStart(ctx.Data, ctx.Index, s.params.Frequency) {
+		return nil
+	}
+
+	ctx.Deposit(s.params.InvestmentAmount)
+	ctx.BuyValue(ctx.Portfolio.Cash)
+
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *dcaStrategy) Finalize(ctx *StrategyContext) error {
+	// Accumulated units are held to the end and marked to market
+	return nil
+}
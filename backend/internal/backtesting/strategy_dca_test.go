@@ -0,0 +1,43 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestDCAStrategy(t *testing.T) {
+	// Mondays 2024-01-08 and, after Martin Luther King Jr. Day, 01-16 start new weeks
+	closes := []float64{100, 100, 100, 100, 100, 100, 100, 100, 100, 110}
+	bars := dailyBars(models.MarketTypeUSStock, closes...)
+	parameters := map[string]interface{}{"investment_amount": 1000.0, "frequency": "weekly"}
+	result := runTestBacktest(t, testRequest(models.StrategyTypeDCA, parameters, bars), testMarket("x", models.MarketTypeUSStock, bars))
+
+	installments := []int{0, 4, 9}
+	buys := tradesBy(result, "buy")
+	if len(buys) != len(installments) {
+		t.Fatalf("buys = %+v, want one per installment on bars %v", buys, installments)
+	}
+	for i, bar := range installments {
+		if !buys[i].Date.Equal(bars[bar].Date) {
+			t.Errorf("installment %d on %s, want bar %d", i, buys[i].Date.Format("2006-01-02"), bar)
+		}
+		daily := result.DailyReturns[bar]
+		if daily.CashFlow != 1000 || daily.ContributedCapital != 10000+1000*float64(i+1) {
+			t.Errorf("bar %d cash flow %v contributed %v, want 1000 and %v", bar, daily.CashFlow, daily.ContributedCapital, 10000+1000*float64(i+1))
+		}
+	}
+
+	// The first installment is invested with the initial cash. Profit is
+	// measured on all capital put in and the time-weighted return strips the
+	// contributions.
+	if buys[0].Quantity != 110 {
+		t.Errorf("first installment bought %v, want 110", buys[0].Quantity)
+	}
+	metrics := result.PerformanceMetrics
+	if metrics.ContributedCapital != 13000 || !closeTo(metrics.NetProfit, 1200) || !closeTo(metrics.TotalReturn, 1200.0/13000) {
+		t.Errorf("contributed %v for a profit of %v (%v), want 13000 for 1200", metrics.ContributedCapital, metrics.NetProfit, metrics.TotalReturn)
+	}
+	if !closeTo(metrics.TimeWeightedReturn, 0.1) {
+		t.Errorf("time-weighted return = %v, want 0.1", metrics.TimeWeightedReturn)
+	}
+}
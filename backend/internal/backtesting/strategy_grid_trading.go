@@ -0,0 +1,186 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeGridTrading,
+		Name:        "Grid Trading Strategy",
+		Description: "Layer buys on grid levels below a base price and sell each layer one target above its level",
+		Parameters: map[string]ParameterSpec{
+			"grid_count": {
+				Type:        "integer",
+				Default:     10,
+				Range:       []float64{1, 100},
+				Description: "Number of grid levels below the base price",
+			},
+			"grid_spacing": {
+				Type:        "float",
+				Default:     0.02,
+				Range:       []float64{0.001, 0.5},
+				Description: "Distance between grid levels (0.02 = 2%)",
+			},
+			"base_price": {
+				Type:        "float",
+				Default:     0.0,
+				Description: "Grid base price, 0 uses the first close of the backtest",
+			},
+			"max_position": {
+				Type:        "float",
+				Default:     1.0,
+				Range:       []float64{0.01, 1.0},
+				Description: "Share of initial cash spread across all grid levels",
+			},
+			"profit_target": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{0, 1.0},
+				Description: "Take-profit per layer above its level, 0 uses grid_spacing",
+			},
+		},
+		Factory: newGridTradingStrategy,
+	})
+}
+
+// gridLevel is a single layer of the grid
+type gridLevel struct {
+	buyPrice  float64
+	sellPrice float64
+	quantity  float64 // Quantity currently held on this level
+}
+
+// gridTradingStrategy buys a fixed budget on each level the price falls through
+// and sells that layer once price recovers to the level's profit target
+type gridTradingStrategy struct {
+	params      *models.GridTradingParams
+	levels      []gridLevel
+	levelBudget float64
+}
+
+// newGridTradingStrategy creates a grid trading strategy from parameters
+func newGridTradingStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseGridTradingParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &gridTradingStrategy{params: params}, nil
+}
+
+// parseGridTradingParams parses and validates grid trading strategy parameters
+func parseGridTradingParams(parameters map[string]interface{}) (*models.GridTradingParams, error) {
+	gridCount, err := intParam(parameters, "grid_count", 10)
+	if err != nil {
+		return nil, err
+	}
+	gridSpacing, err := floatParam(parameters, "grid_spacing", 0.02)
+	if err != nil {
+		return nil, err
+	}
+	basePrice, err := floatParam(parameters, "base_price", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxPosition, err := floatParam(parameters, "max_position", 1.0)
+	if err != nil {
+		return nil, err
+	}
+	profitTarget, err := floatParam(parameters, "profit_target", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if gridCount < 1 {
+		return nil, fmt.Errorf("grid_count must be at least 1")
+	}
+	if gridSpacing <= 0 || gridSpacing >= 1 {
+		return nil, fmt.Errorf("grid_spacing must be in (0, 1)")
+	}
+	if float64(gridCount)*gridSpacing >= 1 {
+		return nil, fmt.Errorf("grid_count * grid_spacing must be below 1 so every level stays above zero")
+	}
+	if basePrice < 0 {
+		return nil, fmt.Errorf("base_price must not be negative")
+	}
+	if maxPosition <= 0 || maxPosition > 1 {
+		return nil, fmt.Errorf("max_position must be in (0, 1]")
+	}
+	if profitTarget < 0 {
+		return nil, fmt.Errorf("profit_target must not be negative")
+	}
+	if profitTarget == 0 {
+		profitTarget = gridSpacing
+	}
+
+	return &models.GridTradingParams{
+		GridCount:    gridCount,
+		GridSpacing:  gridSpacing,
+		BasePrice:    basePrice,
+		MaxPosition:  maxPosition,
+		ProfitTarget: profitTarget,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *gridTradingStrategy) Init(ctx *StrategyContext) error {
+	basePrice := s.params.BasePrice
+	if basePrice == 0 {
+		basePrice = ctx.Data[0].Close
+	}
+
+	s.levels = make([]gridLevel, s.params.GridCount)
+	for i := range s.levels {
+		buyPrice := basePrice * (1 - float64(i+1)*s.params.GridSpacing)
+		s.levels[i] = gridLevel{
+			buyPrice:  buyPrice,
+			sellPrice: buyPrice * (1 + s.params.ProfitTarget),
+		}
+	}
+	s.levelBudget = ctx.Request.InitialCash * s.params.MaxPosition / float64(s.params.GridCount)
+
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *gridTradingStrategy) OnBar(ctx *StrategyContext) error {
+	price := ctx.Price()
+
+	// A risk overlay exit may have closed every layer behind the grid's back
+	if ctx.Position().Quantity == 0 {
+		for i := range s.levels {
+			s.levels[i].quantity = 0
+		}
+	}
+
+	// Take profit on filled layers first so the cash can be reused further down
+	for i := range s.levels {
+		level := &s.levels[i]
+		if level.quantity > 0 && price >= level.sellPrice {
+			if trade := ctx.Sell(level.quantity); trade != nil {
+				trade.GridLevel = i + 1
+				level.quantity = 0
+			}
+		}
+	}
+
+	// Open a layer on every empty level the price is at or below
+	for i := range s.levels {
+		level := &s.levels[i]
+		if level.quantity == 0 && price <= level.buyPrice {
+			if trade := ctx.BuyValue(s.levelBudget); trade != nil {
+				trade.GridLevel = i + 1
+				level.quantity = trade.Quantity
+			}
+		}
+	}
+
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *gridTradingStrategy) Finalize(ctx *StrategyContext) error {
+	// Open layers are left in place and marked to market
+	return nil
+}
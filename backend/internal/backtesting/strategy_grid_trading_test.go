@@ -0,0 +1,45 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestGridTradingStrategy(t *testing.T) {
+	// Levels at 90 and 80 from the base of 100, each sold 10% above its level
+	closes := []float64{100, 95, 90, 80, 89, 100, 101}
+	bars := dailyBars(models.MarketTypeUSStock, closes...)
+	parameters := map[string]interface{}{"grid_count": 2.0, "grid_spacing": 0.1}
+	result := runTestBacktest(t, testRequest(models.StrategyTypeGridTrading, parameters, bars), testMarket("x", models.MarketTypeUSStock, bars))
+
+	want := []struct {
+		action string
+		bar    int
+		level  int
+	}{
+		{"buy", 2, 1},
+		{"buy", 3, 2},
+		{"sell", 4, 2},
+		{"sell", 5, 1},
+	}
+	trades := tradesBy(result, "")
+	if len(trades) != len(want) {
+		t.Fatalf("trades = %+v, want %d", trades, len(want))
+	}
+	for i, w := range want {
+		got := trades[i]
+		if got.Action != w.action || !got.Date.Equal(bars[w.bar].Date) || got.GridLevel != w.level {
+			t.Errorf("trade %d = %s level %d on %s, want %s level %d on bar %d", i,
+				got.Action, got.GridLevel, got.Date.Format("2006-01-02"), w.action, w.level, w.bar)
+		}
+	}
+
+	// Each layer is its own round trip
+	if trades[1].Quantity != trades[2].Quantity || trades[0].Quantity != trades[3].Quantity {
+		t.Errorf("layers sold %v and %v, bought %v and %v", trades[2].Quantity, trades[3].Quantity, trades[1].Quantity, trades[0].Quantity)
+	}
+	metrics := result.PerformanceMetrics
+	if metrics.TotalTrades != 2 || metrics.WinningTrades != 2 || !closeTo(metrics.MaxWinningTrade, 89.0/80-1) {
+		t.Errorf("round trips = %d with %d winners up to %v, want 2 winners up to %v", metrics.TotalTrades, metrics.WinningTrades, metrics.MaxWinningTrade, 89.0/80-1)
+	}
+}
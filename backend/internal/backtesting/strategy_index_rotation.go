@@ -0,0 +1,193 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+	"sort"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeIndexRotation,
+		Name:        "Index Rotation Strategy",
+		Description: "Cross-sectional rotation: rank the universe, e.g. csi300/csi500/csi1000/chinext, by trailing or risk-adjusted momentum and hold the top K at equal weight",
+		Parameters: map[string]ParameterSpec{
+			"lookback_period": {
+				Type:        "integer",
+				Default:     20,
+				Range:       []float64{2, 252},
+				Description: "Number of bars the trailing return is measured over",
+			},
+			"skip_period": {
+				Type:        "integer",
+				Default:     0,
+				Range:       []float64{0, 60},
+				Description: "Most recent bars left out of the trailing return, e.g. 21 for 12-1 momentum",
+			},
+			"ranking_method": {
+				Type:        "string",
+				Default:     "return",
+				Options:     []string{"return", "risk_adjusted"},
+				Description: "Rank by trailing return, or by trailing return divided by annualized volatility",
+			},
+			"top_k": {
+				Type:        "integer",
+				Default:     1,
+				Range:       []float64{1, 20},
+				Description: "Number of top-ranked assets held at equal weight",
+			},
+			"rebalance_frequency": {
+				Type:        "string",
+				Default:     "monthly",
+				Options:     []string{"weekly", "monthly", "quarterly"},
+				Description: "How often the universe is re-ranked and the holdings rotated",
+			},
+			"absolute_momentum": {
+				Type:        "boolean",
+				Default:     false,
+				Description: "Only hold selected assets with a positive trailing return, keeping their share in cash otherwise",
+			},
+		},
+		Factory:    newIndexRotationStrategy,
+		MultiAsset: true,
+	})
+}
+
+// indexRotationStrategy holds the top-ranked assets of its universe
+type indexRotationStrategy struct {
+	params   *models.IndexRotationParams
+	rankings []models.RankingSnapshot
+}
+
+// newIndexRotationStrategy creates an index rotation strategy from parameters
+func newIndexRotationStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseIndexRotationParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &indexRotationStrategy{params: params}, nil
+}
+
+// parseIndexRotationParams parses and validates index rotation strategy parameters
+func parseIndexRotationParams(parameters map[string]interface{}) (*models.IndexRotationParams, error) {
+	lookback, err := intParam(parameters, "lookback_period", 20)
+	if err != nil {
+		return nil, err
+	}
+	skip, err := intParam(parameters, "skip_period", 0)
+	if err != nil {
+		return nil, err
+	}
+	method, err := stringParam(parameters, "ranking_method", "return")
+	if err != nil {
+		return nil, err
+	}
+	topK, err := intParam(parameters, "top_k", 1)
+	if err != nil {
+		return nil, err
+	}
+	frequency, err := stringParam(parameters, "rebalance_frequency", "monthly")
+	if err != nil {
+		return nil, err
+	}
+	absolute, err := boolParam(parameters, "absolute_momentum", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if lookback < 2 {
+		return nil, fmt.Errorf("lookback_period must be at least 2")
+	}
+	if skip < 0 {
+		return nil, fmt.Errorf("skip_period must not be negative")
+	}
+	if topK < 1 {
+		return nil, fmt.Errorf("top_k must be at least 1")
+	}
+
+	return &models.IndexRotationParams{
+		LookbackPeriod:     lookback,
+		SkipPeriod:         skip,
+		RankingMethod:      method,
+		TopK:               topK,
+		RebalanceFrequency: frequency,
+		AbsoluteMomentum:   absolute,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *indexRotationStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *indexRotationStrategy) OnBar(ctx *StrategyContext) error {
+	// Rank as soon as the lookback is filled, then on the schedule
+	if len(s.rankings) > 0 && !ctx.IsPeriodStart(s.params.RebalanceFrequency) {
+		return nil
+	}
+
+	ranks := s.rank(ctx)
+	if len(ranks) == 0 {
+		return nil
+	}
+
+	weights := make(map[string]float64, s.params.TopK)
+	for i := range ranks {
+		if ranks[i].Rank > s.params.TopK || (s.params.AbsoluteMomentum && ranks[i].Return <= 0) {
+			continue
+		}
+		ranks[i].Selected = true
+		weights[ranks[i].AssetID] = 1 / float64(s.params.TopK)
+	}
+
+	ctx.OrderTargetWeights(weights)
+	s.rankings = append(s.rankings, models.RankingSnapshot{Date: ctx.Date(), Ranks: ranks})
+	return nil
+}
+
+// rank scores every asset with enough history and sorts them best first.
+// Ties keep the order of the request's assets.
+func (s *indexRotationStrategy) rank(ctx *StrategyContext) []models.AssetRank {
+	var ranks []models.AssetRank
+	for _, asset := range ctx.Assets {
+		history := ctx.AssetHistory(asset)
+		trailing, ok := trailingReturn(history, s.params.LookbackPeriod, s.params.SkipPeriod)
+		if !ok {
+			continue
+		}
+
+		rank := models.AssetRank{AssetID: asset, Score: trailing, Return: trailing}
+		if volatility, ok := returnVolatility(history[:len(history)-s.params.SkipPeriod], s.params.LookbackPeriod); ok {
+			rank.Volatility = volatility * math.Sqrt(ctx.PeriodsPerYear())
+		}
+		if s.params.RankingMethod == "risk_adjusted" {
+			if rank.Volatility <= 0 {
+				continue
+			}
+			rank.Score = trailing / rank.Volatility
+		}
+		ranks = append(ranks, rank)
+	}
+
+	sort.SliceStable(ranks, func(i, j int) bool {
+		return ranks[i].Score > ranks[j].Score
+	})
+	for i := range ranks {
+		ranks[i].Rank = i + 1
+	}
+	return ranks
+}
+
+// Finalize implements Strategy
+func (s *indexRotationStrategy) Finalize(ctx *StrategyContext) error {
+	// Close the open positions so the last rotation is counted
+	ctx.CloseAll()
+	return nil
+}
+
+// ReportResult implements ResultReporter
+func (s *indexRotationStrategy) ReportResult(result *models.BacktestResult) {
+	result.Rankings = s.rankings
+}
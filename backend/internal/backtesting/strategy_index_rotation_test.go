@@ -0,0 +1,81 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestIndexRotationStrategy(t *testing.T) {
+	// a leads over the first two bars, b over the two bars to Monday 2024-01-08
+	assets := []string{"a", "b", "c"}
+	markets := testMarkets(assets,
+		[]float64{100, 101, 102, 103, 103, 103, 103},
+		[]float64{100, 100, 100, 100, 110, 110, 110},
+		[]float64{100, 99, 99, 99, 99, 99, 99},
+	)
+	bars := markets[0].Data
+	parameters := map[string]interface{}{"lookback_period": 2.0, "top_k": 1.0, "rebalance_frequency": "weekly"}
+	request := testRequest(models.StrategyTypeIndexRotation, parameters, bars)
+	request.AssetIDs = assets
+	result := runTestBacktest(t, request, markets...)
+
+	// Ranked once the lookback fills and again at the start of the next week
+	if len(result.Rankings) != 2 {
+		t.Fatalf("got %d rankings, want 2", len(result.Rankings))
+	}
+	for i, want := range []struct {
+		bar      int
+		selected string
+	}{{2, "a"}, {4, "b"}} {
+		snapshot := result.Rankings[i]
+		if !snapshot.Date.Equal(bars[want.bar].Date) || len(snapshot.Ranks) != 3 {
+			t.Errorf("ranking %d on %s with %d ranks, want 3 on bar %d", i, snapshot.Date.Format("2006-01-02"), len(snapshot.Ranks), want.bar)
+			continue
+		}
+		top := snapshot.Ranks[0]
+		if top.AssetID != want.selected || top.Rank != 1 || !top.Selected || snapshot.Ranks[1].Selected {
+			t.Errorf("ranking %d = %+v, want only %s selected", i, snapshot.Ranks, want.selected)
+		}
+	}
+
+	// Holdings rotate from a into b and are closed at the end
+	want := []struct {
+		asset, action string
+		bar           int
+	}{
+		{"a", "buy", 2},
+		{"a", "sell", 4},
+		{"b", "buy", 4},
+		{"b", "sell", 6},
+	}
+	trades := tradesBy(result, "")
+	if len(trades) != len(want) {
+		t.Fatalf("trades = %+v, want %d", trades, len(want))
+	}
+	for i, w := range want {
+		if trades[i].AssetID != w.asset || trades[i].Action != w.action || !trades[i].Date.Equal(bars[w.bar].Date) {
+			t.Errorf("trade %d = %s %s on %s, want %s %s on bar %d", i,
+				trades[i].Action, trades[i].AssetID, trades[i].Date.Format("2006-01-02"), w.action, w.asset, w.bar)
+		}
+	}
+}
+
+func TestIndexRotationAbsoluteMomentum(t *testing.T) {
+	// Every asset falls, so absolute momentum keeps the portfolio in cash
+	assets := []string{"a", "b"}
+	markets := testMarkets(assets,
+		[]float64{100, 99, 98, 97},
+		[]float64{100, 95, 90, 85},
+	)
+	parameters := map[string]interface{}{"lookback_period": 2.0, "absolute_momentum": true}
+	request := testRequest(models.StrategyTypeIndexRotation, parameters, markets[0].Data)
+	request.AssetIDs = assets
+	result := runTestBacktest(t, request, markets...)
+
+	if len(result.Trades) != 0 {
+		t.Errorf("trades = %+v, want none", result.Trades)
+	}
+	if len(result.Rankings) != 1 || result.Rankings[0].Ranks[0].AssetID != "a" || result.Rankings[0].Ranks[0].Selected {
+		t.Errorf("rankings = %+v, want a ranked first but not selected", result.Rankings)
+	}
+}
@@ -0,0 +1,155 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeMeanReversion,
+		Name:        "Mean Reversion Strategy",
+		Description: "Buy when the close falls below its rolling mean by the entry z-score (lower Bollinger band) and exit on reversion, optionally shorting the upper band",
+		Parameters: map[string]ParameterSpec{
+			"window": {
+				Type:        "integer",
+				Default:     20,
+				Range:       []float64{2, 252},
+				Description: "Rolling window for the mean and standard deviation",
+			},
+			"entry_threshold": {
+				Type:        "float",
+				Default:     2.0,
+				Range:       []float64{0.1, 5},
+				Description: "Z-score below the mean that triggers an entry (Bollinger band width in standard deviations)",
+			},
+			"exit_threshold": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{-5, 5},
+				Description: "Z-score the close must recover to before exiting (0 = middle band)",
+			},
+			"max_holding_days": {
+				Type:        "integer",
+				Default:     0,
+				Range:       []float64{0, 252},
+				Description: "Maximum number of bars to hold a position, 0 disables the limit",
+			},
+			"allow_short": {
+				Type:        "boolean",
+				Default:     false,
+				Description: "Short when the close rises above its mean by the entry z-score; requires a margin account that allows shorting",
+			},
+		},
+		Factory: newMeanReversionStrategy,
+	})
+}
+
+// meanReversionStrategy trades deviations of the close from its rolling mean
+type meanReversionStrategy struct {
+	params     *models.MeanReversionParams
+	entryIndex int
+}
+
+// newMeanReversionStrategy creates a mean reversion strategy from parameters
+func newMeanReversionStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseMeanReversionParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &meanReversionStrategy{params: params, entryIndex: -1}, nil
+}
+
+// parseMeanReversionParams parses and validates mean reversion strategy parameters
+func parseMeanReversionParams(parameters map[string]interface{}) (*models.MeanReversionParams, error) {
+	window, err := intParam(parameters, "window", 20)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := floatParam(parameters, "entry_threshold", 2.0)
+	if err != nil {
+		return nil, err
+	}
+	exit, err := floatParam(parameters, "exit_threshold", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxHolding, err := intParam(parameters, "max_holding_days", 0)
+	if err != nil {
+		return nil, err
+	}
+	allowShort, err := boolParam(parameters, "allow_short", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if window < 2 {
+		return nil, fmt.Errorf("window must be at least 2")
+	}
+	if entry <= 0 {
+		return nil, fmt.Errorf("entry_threshold must be positive")
+	}
+	if exit <= -entry {
+		return nil, fmt.Errorf("exit_threshold must be above -entry_threshold")
+	}
+	if maxHolding < 0 {
+		return nil, fmt.Errorf("max_holding_days must not be negative")
+	}
+
+	return &models.MeanReversionParams{
+		Window:         window,
+		EntryThreshold: entry,
+		ExitThreshold:  exit,
+		MaxHoldingDays: maxHolding,
+		AllowShort:     allowShort,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *meanReversionStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *meanReversionStrategy) OnBar(ctx *StrategyContext) error {
+	mean, std, ok := closeMeanStd(ctx.History(), s.params.Window)
+	if !ok || std == 0 {
+		return nil
+	}
+	zScore := (ctx.Price() - mean) / std
+
+	quantity := ctx.Position().Quantity
+	if quantity == 0 {
+		var trade *models.Trade
+		switch {
+		case zScore <= -s.params.EntryThreshold:
+			trade = ctx.BuyValue(ctx.Portfolio.Cash)
+		case s.params.AllowShort && zScore >= s.params.EntryThreshold:
+			trade = ctx.ShortValue(ctx.Portfolio.Value())
+		}
+		if trade != nil {
+			s.entryIndex = ctx.Index
+		}
+		return nil
+	}
+
+	// Shorts mirror longs and revert once the z-score falls to -exit_threshold
+	reverted := zScore >= s.params.ExitThreshold
+	if quantity < 0 {
+		reverted = zScore <= -s.params.ExitThreshold
+	}
+	expired := s.params.MaxHoldingDays > 0 && ctx.Index-s.entryIndex >= s.params.MaxHoldingDays
+	if reverted || expired {
+		ctx.ClosePosition()
+		s.entryIndex = -1
+	}
+
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *meanReversionStrategy) Finalize(ctx *StrategyContext) error {
+	// Close the open position so the last round trip is counted
+	ctx.ClosePosition()
+	return nil
+}
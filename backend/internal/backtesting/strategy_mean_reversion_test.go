@@ -0,0 +1,63 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestMeanReversionStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]interface{}
+		closes     []float64
+		open       string
+		entry      int // Bars of the entry and the exit
+		exit       int
+	}{
+		{
+			// Enters below the 3-bar mean and exits when the close is back on it
+			name:       "long reverts to the mean",
+			parameters: map[string]interface{}{},
+			closes:     []float64{100, 100, 100, 90, 95, 96},
+			open:       "buy",
+			entry:      3,
+			exit:       4,
+		},
+		{
+			name:       "short reverts to the mean",
+			parameters: map[string]interface{}{"allow_short": true},
+			closes:     []float64{100, 100, 100, 110, 105, 104},
+			open:       "short",
+			entry:      3,
+			exit:       4,
+		},
+		{
+			name:       "holding limit",
+			parameters: map[string]interface{}{"max_holding_days": 2.0},
+			closes:     []float64{100, 100, 100, 90, 85, 84},
+			open:       "buy",
+			entry:      3,
+			exit:       5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parameters["window"] = 3.0
+			tt.parameters["entry_threshold"] = 1.0
+			bars := dailyBars(models.MarketTypeUSStock, tt.closes...)
+			request := testRequest(models.StrategyTypeMeanReversion, tt.parameters, bars)
+			request.Margin = &models.MarginConfig{AllowShort: true}
+			result := runTestBacktest(t, request, testMarket("x", models.MarketTypeUSStock, bars))
+
+			close := map[string]string{"buy": "sell", "short": "cover"}[tt.open]
+			trades := tradesBy(result, "")
+			if len(trades) != 2 || trades[0].Action != tt.open || trades[1].Action != close {
+				t.Fatalf("trades = %+v, want a %s closed by a %s", trades, tt.open, close)
+			}
+			if !trades[0].Date.Equal(bars[tt.entry].Date) || !trades[1].Date.Equal(bars[tt.exit].Date) || trades[1].Reason != models.TradeReasonSignal {
+				t.Errorf("traded on %s and %s, want bars %d and %d on signals", trades[0].Date.Format("2006-01-02"), trades[1].Date.Format("2006-01-02"), tt.entry, tt.exit)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeMinVariance,
+		Name:        "Minimum Variance Strategy",
+		Description: "Hold the global minimum variance portfolio of the universe, estimated from the covariance of daily returns over a rolling lookback, and rebalance on a schedule",
+		Parameters: map[string]ParameterSpec{
+			"lookback_period": {
+				Type:        "integer",
+				Default:     60,
+				Range:       []float64{10, 504},
+				Description: "Number of daily returns the covariance is estimated over",
+			},
+			"rebalance_frequency": {
+				Type:        "string",
+				Default:     "monthly",
+				Options:     []string{"daily", "weekly", "monthly", "quarterly", "yearly"},
+				Description: "How often the weights are recomputed and the portfolio rebalanced",
+			},
+			"long_only": {
+				Type:        "boolean",
+				Default:     true,
+				Description: "Keep every weight non-negative; otherwise assets may be shorted, which requires a margin account that allows shorting",
+			},
+			"min_weight": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{-1, 1},
+				Description: "Lower bound on each asset's weight, negative only without long_only; 0 disables the bound",
+			},
+			"max_weight": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{0, 2},
+				Description: "Upper bound on each asset's weight, 0 disables the bound",
+			},
+		},
+		Factory:    newMinVarianceStrategy,
+		MultiAsset: true,
+	})
+}
+
+// newMinVarianceStrategy creates a global minimum variance strategy from parameters
+func newMinVarianceStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseAllocationParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &allocationStrategy{params: params, allocate: minVarianceWeights}, nil
+}
@@ -0,0 +1,138 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeMomentum,
+		Name:        "Momentum Strategy",
+		Description: "Time-series momentum: enter when the lookback return exceeds the threshold and hold for a fixed period",
+		Parameters: map[string]ParameterSpec{
+			"lookback_period": {
+				Type:        "integer",
+				Default:     20,
+				Range:       []float64{1, 252},
+				Description: "Number of bars used to measure momentum",
+			},
+			"momentum_threshold": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{-1, 1},
+				Description: "Minimum lookback return to enter (0.05 = 5%)",
+			},
+			"holding_period": {
+				Type:        "integer",
+				Default:     20,
+				Range:       []float64{1, 252},
+				Description: "Minimum number of bars to hold after entry",
+			},
+			"rebalance_freq": {
+				Type:        "string",
+				Default:     "daily",
+				Options:     []string{"daily", "weekly", "monthly"},
+				Description: "How often the momentum signal is evaluated",
+			},
+		},
+		Factory: newMomentumStrategy,
+	})
+}
+
+// momentumStrategy holds the asset while its lookback return stays above the threshold
+type momentumStrategy struct {
+	params     *models.MomentumParams
+	entryIndex int
+}
+
+// newMomentumStrategy creates a momentum strategy from parameters
+func newMomentumStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseMomentumParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &momentumStrategy{params: params, entryIndex: -1}, nil
+}
+
+// parseMomentumParams parses and validates momentum strategy parameters
+func parseMomentumParams(parameters map[string]interface{}) (*models.MomentumParams, error) {
+	lookback, err := intParam(parameters, "lookback_period", 20)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := floatParam(parameters, "momentum_threshold", 0)
+	if err != nil {
+		return nil, err
+	}
+	holding, err := intParam(parameters, "holding_period", 20)
+	if err != nil {
+		return nil, err
+	}
+	rebalanceFreq, err := stringParam(parameters, "rebalance_freq", "daily")
+	if err != nil {
+		return nil, err
+	}
+
+	if lookback < 1 {
+		return nil, fmt.Errorf("lookback_period must be at least 1")
+	}
+	if holding < 1 {
+		return nil, fmt.Errorf("holding_period must be at least 1")
+	}
+	if threshold <= -1 {
+		return nil, fmt.Errorf("momentum_threshold must be above -1")
+	}
+
+	return &models.MomentumParams{
+		LookbackPeriod:    lookback,
+		MomentumThreshold: threshold,
+		HoldingPeriod:     holding,
+		RebalanceFreq:     rebalanceFreq,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *momentumStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *momentumStrategy) OnBar(ctx *StrategyContext) error {
+	// Warm-up: no signal until the lookback window is filled
+	if ctx.Index < s.params.LookbackPeriod {
+		return nil
+	}
+	if !isPeriodStart(ctx.Data, ctx.Index, s.params.RebalanceFreq) {
+		return nil
+	}
+
+	history := ctx.History()
+	pastPrice := history[len(history)-1-s.params.LookbackPeriod].Close
+	if pastPrice <= 0 {
+		return nil
+	}
+	momentum := ctx.Price()/pastPrice - 1
+	signal := momentum > s.params.MomentumThreshold
+
+	holding := ctx.Position().Quantity > 0
+	switch {
+	case !holding && signal:
+		if trade := ctx.BuyValue(ctx.Portfolio.Cash); trade != nil {
+			s.entryIndex = ctx.Index
+		}
+	case holding && !signal && ctx.Index-s.entryIndex >= s.params.HoldingPeriod:
+		// Exit only once the holding period has elapsed; a persisting signal keeps the position
+		ctx.SellAll()
+		s.entryIndex = -1
+	}
+
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *momentumStrategy) Finalize(ctx *StrategyContext) error {
+	// Close the open position so the last round trip is counted
+	ctx.SellAll()
+	return nil
+}
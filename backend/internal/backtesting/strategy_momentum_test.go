@@ -0,0 +1,48 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+)
+
+func TestMomentumStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		closes    []float64
+		buy, sell int // Bars of the entry and the exit
+		reason    string
+	}{
+		{
+			// 6% over two bars enters; the signal fades on bar 4 but the
+			// position is held for three bars
+			name:   "exit after the holding period",
+			closes: []float64{100, 100, 100, 106, 103, 101, 100, 99},
+			buy:    3,
+			sell:   6,
+			reason: models.TradeReasonSignal,
+		},
+		{
+			name:   "persisting signal holds to the end",
+			closes: []float64{100, 100, 100, 106, 112, 118, 125},
+			buy:    3,
+			sell:   6,
+			reason: models.TradeReasonEndOfBacktest,
+		},
+	}
+
+	parameters := map[string]interface{}{"lookback_period": 2.0, "momentum_threshold": 0.05, "holding_period": 3.0}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := dailyBars(models.MarketTypeUSStock, tt.closes...)
+			result := runTestBacktest(t, testRequest(models.StrategyTypeMomentum, parameters, bars), testMarket("x", models.MarketTypeUSStock, bars))
+
+			buys, sells := tradesBy(result, "buy"), tradesBy(result, "sell")
+			if len(buys) != 1 || !buys[0].Date.Equal(bars[tt.buy].Date) {
+				t.Errorf("buys = %+v, want one on bar %d", buys, tt.buy)
+			}
+			if len(sells) != 1 || !sells[0].Date.Equal(bars[tt.sell].Date) || sells[0].Reason != tt.reason {
+				t.Errorf("sells = %+v, want one on bar %d for %s", sells, tt.sell, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/calendar"
+	"macro_strategy/internal/models"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeMonthlyRotation,
+		Name:        "Monthly Rotation Strategy",
+		Description: "Buy before month-end, sell after month-start",
+		Parameters: map[string]ParameterSpec{
+			"buy_days_before_month_end": {
+				Type:        "integer",
+				Default:     1,
+				Range:       []float64{1, 20},
+				Description: "Number of days before month-end to buy",
+			},
+			"sell_days_after_month_start": {
+				Type:        "integer",
+				Default:     1,
+				Range:       []float64{1, 20},
+				Description: "Number of days after month-start to sell",
+			},
+		},
+		Factory: newMonthlyRotationStrategy,
+	})
+}
+
+// monthlyRotationStrategy buys before month-end and sells after month-start.
+// Month-end is projected from the exchange calendar so the strategy never
+// needs bars it has not seen yet.
+type monthlyRotationStrategy struct {
+	params     *models.MonthlyRotationParams
+	dayOfMonth []int // 1-based trading day of the month per bar, counted from observed bars
+}
+
+// newMonthlyRotationStrategy creates a monthly rotation strategy from parameters
+func newMonthlyRotationStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseMonthlyRotationParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &monthlyRotationStrategy{params: params}, nil
+}
+
+// parseMonthlyRotationParams parses monthly rotation strategy parameters
+func parseMonthlyRotationParams(parameters map[string]interface{}) (*models.MonthlyRotationParams, error) {
+	buyDays, err := intParam(parameters, "buy_days_before_month_end", 1)
+	if err != nil {
+		return nil, err
+	}
+	sellDays, err := intParam(parameters, "sell_days_after_month_start", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MonthlyRotationParams{
+		BuyDaysBeforeMonthEnd:   buyDays,
+		SellDaysAfterMonthStart: sellDays,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *monthlyRotationStrategy) Init(ctx *StrategyContext) error {
+	if ctx.Calendar == nil {
+		ctx.Calendar = calendar.New("", nil) // Weekdays only
+	}
+
+	// Each bar's ordinal only depends on the bars before it
+	s.dayOfMonth = make([]int, len(ctx.Data))
+	for i := range ctx.Data {
+		if isPeriodStart(ctx.Data, i, "monthly") {
+			s.dayOfMonth[i] = 1
+		} else {
+			s.dayOfMonth[i] = s.dayOfMonth[i-1] + 1
+		}
+	}
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *monthlyRotationStrategy) OnBar(ctx *StrategyContext) error {
+	// Buy on the N-th last trading day of the month
+	if s.shouldBuy(ctx) && ctx.Position().Quantity == 0 {
+		// Buy with all available cash
+		ctx.BuyValue(ctx.Portfolio.Cash)
+	}
+
+	// Sell on the N-th trading day of the month
+	if s.shouldSell(ctx) && ctx.Position().Quantity > 0 {
+		ctx.SellAll()
+	}
+
+	return nil
+}
+
+// Finalize implements Strategy
+func (s *monthlyRotationStrategy) Finalize(ctx *StrategyContext) error {
+	// Force sell any remaining positions at the end of backtest period
+	ctx.SellAll()
+	return nil
+}
+
+// shouldBuy reports whether the current bar is BuyDaysBeforeMonthEnd trading
+// days from the end of its month, e.g. the last trading day for 1
+func (s *monthlyRotationStrategy) shouldBuy(ctx *StrategyContext) bool {
+	return ctx.Calendar.RemainingInMonth(ctx.Date()) == s.params.BuyDaysBeforeMonthEnd-1
+}
+
+// shouldSell reports whether the current bar is the SellDaysAfterMonthStart-th
+// trading day of its month, e.g. the first trading day for 1
+func (s *monthlyRotationStrategy) shouldSell(ctx *StrategyContext) bool {
+	return s.dayOfMonth[ctx.Index] == s.params.SellDaysAfterMonthStart
+}
@@ -0,0 +1,75 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+	"time"
+)
+
+func TestMonthlyRotationStrategy(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		parameters map[string]interface{}
+		end        time.Time
+		buys       []time.Time
+		sells      []time.Time
+	}{
+		{
+			// Good Friday closes 2024-03-29, so March ends on the 28th
+			name:       "last and first trading days",
+			parameters: map[string]interface{}{},
+			end:        day(time.April, 5),
+			buys:       []time.Time{day(time.January, 31), day(time.February, 29), day(time.March, 28)},
+			sells:      []time.Time{day(time.February, 1), day(time.March, 1), day(time.April, 1)},
+		},
+		{
+			name:       "days before the end and after the start",
+			parameters: map[string]interface{}{"buy_days_before_month_end": 3.0, "sell_days_after_month_start": 2.0},
+			end:        day(time.February, 14),
+			buys:       []time.Time{day(time.January, 29)},
+			sells:      []time.Time{day(time.February, 2)},
+		},
+		{
+			// Month-end comes from the calendar, not from bars after it
+			name:       "data ending on the month's last day",
+			parameters: map[string]interface{}{},
+			end:        day(time.January, 31),
+			buys:       []time.Time{day(time.January, 31)},
+			sells:      []time.Time{day(time.January, 31)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closes := make([]float64, 80)
+			for i := range closes {
+				closes[i] = 100
+			}
+			var bars []models.OHLCV
+			for _, bar := range dailyBars(models.MarketTypeUSStock, closes...) {
+				if !bar.Date.After(tt.end) {
+					bars = append(bars, bar)
+				}
+			}
+			result := runTestBacktest(t, testRequest(models.StrategyTypeMonthlyRotation, tt.parameters, bars), testMarket("x", models.MarketTypeUSStock, bars))
+
+			for _, side := range []struct {
+				action string
+				want   []time.Time
+			}{{"buy", tt.buys}, {"sell", tt.sells}} {
+				trades := tradesBy(result, side.action)
+				if len(trades) != len(side.want) {
+					t.Errorf("%d %s trades, want %d: %+v", len(trades), side.action, len(side.want), trades)
+					continue
+				}
+				for i, want := range side.want {
+					if !trades[i].Date.Equal(want) {
+						t.Errorf("%s %d on %s, want %s", side.action, i, trades[i].Date.Format("2006-01-02"), want.Format("2006-01-02"))
+					}
+				}
+			}
+		})
+	}
+}
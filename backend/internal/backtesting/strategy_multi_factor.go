@@ -0,0 +1,254 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+	"sort"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeMultiFactor,
+		Name:        "Multi-Factor Strategy",
+		Description: "Score the universe, e.g. a basket of A-share stocks, on weighted OHLCV factors (momentum, volatility, volume trend, turnover) by composite cross-sectional z-score and hold the top bucket at equal weight",
+		Parameters: map[string]ParameterSpec{
+			"factors": {
+				Type: "array",
+				Default: []map[string]interface{}{
+					{"type": "momentum", "lookback": 60, "weight": 1.0},
+					{"type": "volatility", "lookback": 60, "weight": -0.5},
+				},
+				Description: "Factors as {\"type\", \"lookback\", \"weight\", optional \"name\"}; types are momentum (trailing return), volatility (annualized), volume_trend (daily growth of volume) and turnover (average turnover rate); a negative weight favors low values",
+			},
+			"buckets": {
+				Type:        "integer",
+				Default:     5,
+				Range:       []float64{1, 20},
+				Description: "Number of equal buckets the ranking is split into; the top bucket is held, e.g. 5 holds the top quintile",
+			},
+			"rebalance_frequency": {
+				Type:        "string",
+				Default:     "monthly",
+				Options:     []string{"weekly", "monthly", "quarterly"},
+				Description: "How often the universe is re-scored and the holdings rotated",
+			},
+		},
+		Factory:    newMultiFactorStrategy,
+		MultiAsset: true,
+	})
+}
+
+// factorTypes lists the supported factor types
+var factorTypes = []string{"momentum", "volatility", "volume_trend", "turnover"}
+
+// multiFactorStrategy holds the top bucket of its universe by composite factor score
+type multiFactorStrategy struct {
+	params   *models.MultiFactorParams
+	rankings []models.RankingSnapshot
+}
+
+// newMultiFactorStrategy creates a multi-factor strategy from parameters
+func newMultiFactorStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseMultiFactorParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &multiFactorStrategy{params: params}, nil
+}
+
+// parseMultiFactorParams parses and validates multi-factor strategy parameters
+func parseMultiFactorParams(parameters map[string]interface{}) (*models.MultiFactorParams, error) {
+	factors, err := factorsParam(parameters, "factors")
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := intParam(parameters, "buckets", 5)
+	if err != nil {
+		return nil, err
+	}
+	frequency, err := stringParam(parameters, "rebalance_frequency", "monthly")
+	if err != nil {
+		return nil, err
+	}
+
+	if buckets < 1 {
+		return nil, fmt.Errorf("buckets must be at least 1")
+	}
+
+	return &models.MultiFactorParams{
+		Factors:            factors,
+		Buckets:            buckets,
+		RebalanceFrequency: frequency,
+	}, nil
+}
+
+// factorsParam reads and validates a list of factor definitions, falling back
+// to 60-day momentum less half of 60-day volatility when absent
+func factorsParam(parameters map[string]interface{}, name string) ([]models.FactorDefinition, error) {
+	raw, ok := parameters[name]
+	if !ok {
+		return []models.FactorDefinition{
+			{Name: "momentum_60", Type: "momentum", Lookback: 60, Weight: 1},
+			{Name: "volatility_60", Type: "volatility", Lookback: 60, Weight: -0.5},
+		}, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("%s must be a non-empty array", name)
+	}
+
+	factors := make([]models.FactorDefinition, 0, len(list))
+	names := make(map[string]bool, len(list))
+	for i, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be an object", name, i)
+		}
+		factorType, err := stringParam(object, "type", "")
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		lookback, err := intParam(object, "lookback", 20)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		weight, err := floatParam(object, "weight", 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		factorName, err := stringParam(object, "name", fmt.Sprintf("%s_%d", factorType, lookback))
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+
+		known := false
+		for _, t := range factorTypes {
+			known = known || factorType == t
+		}
+		if !known {
+			return nil, fmt.Errorf("%s[%d]: type must be one of %v", name, i, factorTypes)
+		}
+		if lookback < 2 {
+			return nil, fmt.Errorf("%s[%d]: lookback must be at least 2", name, i)
+		}
+		if weight == 0 {
+			return nil, fmt.Errorf("%s[%d]: weight must not be zero", name, i)
+		}
+		if names[factorName] {
+			return nil, fmt.Errorf("%s: duplicate factor name %s", name, factorName)
+		}
+		names[factorName] = true
+
+		factors = append(factors, models.FactorDefinition{Name: factorName, Type: factorType, Lookback: lookback, Weight: weight})
+	}
+	return factors, nil
+}
+
+// factorValue computes one factor from an asset's bars, annualizing
+// volatility over periodsPerYear
+func factorValue(bars []models.OHLCV, factor models.FactorDefinition, periodsPerYear float64) (float64, bool) {
+	switch factor.Type {
+	case "momentum":
+		return trailingReturn(bars, factor.Lookback, 0)
+	case "volatility":
+		volatility, ok := returnVolatility(bars, factor.Lookback)
+		return volatility * math.Sqrt(periodsPerYear), ok
+	case "volume_trend":
+		return volumeTrend(bars, factor.Lookback)
+	case "turnover":
+		return averageTurnover(bars, factor.Lookback)
+	}
+	return 0, false
+}
+
+// Init implements Strategy
+func (s *multiFactorStrategy) Init(ctx *StrategyContext) error {
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *multiFactorStrategy) OnBar(ctx *StrategyContext) error {
+	// Score as soon as the factors can be computed, then on the schedule
+	if len(s.rankings) > 0 && !ctx.IsPeriodStart(s.params.RebalanceFrequency) {
+		return nil
+	}
+
+	ranks := s.rank(ctx)
+	if len(ranks) == 0 {
+		return nil
+	}
+
+	held := int(math.Ceil(float64(len(ranks)) / float64(s.params.Buckets)))
+	weights := make(map[string]float64, held)
+	for i := 0; i < held; i++ {
+		ranks[i].Selected = true
+		weights[ranks[i].AssetID] = 1 / float64(held)
+	}
+
+	ctx.OrderTargetWeights(weights)
+	s.rankings = append(s.rankings, models.RankingSnapshot{Date: ctx.Date(), Ranks: ranks})
+	return nil
+}
+
+// rank scores every asset on which all factors can be computed by the
+// weighted sum of its cross-sectional factor z-scores, best first. Ties keep
+// the order of the request's assets.
+func (s *multiFactorStrategy) rank(ctx *StrategyContext) []models.AssetRank {
+	var ranks []models.AssetRank
+	var values [][]float64
+	for _, asset := range ctx.Assets {
+		history := ctx.AssetHistory(asset)
+		row := make([]float64, len(s.params.Factors))
+		complete := true
+		for i, factor := range s.params.Factors {
+			value, ok := factorValue(history, factor, ctx.PeriodsPerYear())
+			if !ok {
+				complete = false
+				break
+			}
+			row[i] = value
+		}
+		if complete {
+			ranks = append(ranks, models.AssetRank{AssetID: asset, Factors: make(map[string]float64, len(row))})
+			values = append(values, row)
+		}
+	}
+
+	// A factor without cross-sectional dispersion adds nothing to the score
+	for i, factor := range s.params.Factors {
+		column := make([]float64, len(values))
+		for j := range values {
+			column[j] = values[j][i]
+		}
+		mean, std, ok := meanStd(column)
+		for j := range ranks {
+			zScore := 0.0
+			if ok && std > 0 {
+				zScore = (column[j] - mean) / std
+			}
+			ranks[j].Factors[factor.Name] = zScore
+			ranks[j].Score += factor.Weight * zScore
+		}
+	}
+
+	sort.SliceStable(ranks, func(i, j int) bool {
+		return ranks[i].Score > ranks[j].Score
+	})
+	for i := range ranks {
+		ranks[i].Rank = i + 1
+	}
+	return ranks
+}
+
+// Finalize implements Strategy
+func (s *multiFactorStrategy) Finalize(ctx *StrategyContext) error {
+	// Close the open positions so the last holding period is counted
+	ctx.CloseAll()
+	return nil
+}
+
+// ReportResult implements ResultReporter
+func (s *multiFactorStrategy) ReportResult(result *models.BacktestResult) {
+	result.Rankings = s.rankings
+}
@@ -0,0 +1,59 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"math"
+	"testing"
+)
+
+func TestMultiFactorStrategy(t *testing.T) {
+	// Over two bars a rises steadily, b choppily, c is flat and d falls
+	assets := []string{"a", "b", "c", "d"}
+	markets := testMarkets(assets,
+		[]float64{100, 105, 110, 110, 110},
+		[]float64{100, 110, 105, 105, 105},
+		[]float64{100, 100, 100, 100, 100},
+		[]float64{100, 90, 95, 95, 95},
+	)
+	bars := markets[0].Data
+
+	tests := []struct {
+		name    string
+		factor  map[string]interface{}
+		ranking []string
+	}{
+		{"momentum", map[string]interface{}{"type": "momentum", "lookback": 2.0, "weight": 1.0}, []string{"a", "b", "c", "d"}},
+		{"low volatility", map[string]interface{}{"type": "volatility", "lookback": 2.0, "weight": -1.0}, []string{"c", "a", "b", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parameters := map[string]interface{}{"factors": []interface{}{tt.factor}, "buckets": 2.0}
+			request := testRequest(models.StrategyTypeMultiFactor, parameters, bars)
+			request.AssetIDs = assets
+			result := runTestBacktest(t, request, markets...)
+
+			// Scored once the factor can be computed and not again within the month
+			if len(result.Rankings) != 1 || !result.Rankings[0].Date.Equal(bars[2].Date) {
+				t.Fatalf("rankings = %+v, want one on bar 2", result.Rankings)
+			}
+			ranks := result.Rankings[0].Ranks
+			if len(ranks) != len(tt.ranking) {
+				t.Fatalf("got %d ranks, want %d", len(ranks), len(tt.ranking))
+			}
+			for i, asset := range tt.ranking {
+				if ranks[i].AssetID != asset || ranks[i].Selected != (i < 2) {
+					t.Errorf("rank %d = %s selected %v, want %s selected %v", i+1, ranks[i].AssetID, ranks[i].Selected, asset, i < 2)
+				}
+			}
+
+			// The top half is held at equal weight
+			daily := result.DailyReturns[2]
+			for i, asset := range tt.ranking {
+				weight := daily.Positions[asset].MarketValue / daily.PortfolioValue
+				if want := map[bool]float64{true: 0.5, false: 0}[i < 2]; math.Abs(weight-want) > 0.01 {
+					t.Errorf("%s held at %v, want %v", asset, weight, want)
+				}
+			}
+		})
+	}
+}
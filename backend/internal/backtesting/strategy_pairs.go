@@ -0,0 +1,288 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypePairs,
+		Name:        "Pairs Trading Strategy",
+		Description: "Trade the spread between two assets: regress the first on the second over a rolling window, short the spread when its z-score is high and buy it when low, exiting on reversion. Needs a margin account that allows shorting",
+		Parameters: map[string]ParameterSpec{
+			"window": {
+				Type:        "integer",
+				Default:     60,
+				Range:       []float64{10, 504},
+				Description: "Rolling window for the hedge ratio regression and the spread z-score",
+			},
+			"entry_threshold": {
+				Type:        "float",
+				Default:     2.0,
+				Range:       []float64{0.1, 5},
+				Description: "Absolute spread z-score that opens a position",
+			},
+			"exit_threshold": {
+				Type:        "float",
+				Default:     0.5,
+				Range:       []float64{-5, 5},
+				Description: "Absolute spread z-score the spread must revert to before exiting (0 = mean)",
+			},
+			"stop_threshold": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{0, 10},
+				Description: "Absolute spread z-score that stops out a position as the spread diverges further, 0 disables the stop",
+			},
+			"position_size": {
+				Type:        "float",
+				Default:     0.5,
+				Range:       []float64{0.01, 2},
+				Description: "Market value of the first leg as a fraction of the portfolio value; the second leg is sized by the hedge ratio",
+			},
+			"max_holding_days": {
+				Type:        "integer",
+				Default:     0,
+				Range:       []float64{0, 252},
+				Description: "Maximum number of bars to hold a position, 0 disables the limit",
+			},
+			"use_log_prices": {
+				Type:        "boolean",
+				Default:     true,
+				Description: "Estimate the hedge ratio on log prices, so the legs are hedged in value; otherwise on prices, hedged in units",
+			},
+		},
+		Factory:    newPairsStrategy,
+		MultiAsset: true,
+	})
+}
+
+// pairsStrategy trades the mean reverting spread y - hedge_ratio * x between
+// the first (y) and second (x) asset of the backtest
+type pairsStrategy struct {
+	params     *models.PairsParams
+	assetY     string
+	assetX     string
+	y          []float64 // Prices of both legs on the days both traded
+	x          []float64
+	series     []models.PairsPoint
+	side       int // 1 = long the spread, -1 = short the spread, 0 = flat
+	entryIndex int
+}
+
+// newPairsStrategy creates a pairs trading strategy from parameters
+func newPairsStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parsePairsParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &pairsStrategy{params: params, entryIndex: -1}, nil
+}
+
+// parsePairsParams parses and validates pairs trading strategy parameters
+func parsePairsParams(parameters map[string]interface{}) (*models.PairsParams, error) {
+	window, err := intParam(parameters, "window", 60)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := floatParam(parameters, "entry_threshold", 2.0)
+	if err != nil {
+		return nil, err
+	}
+	exit, err := floatParam(parameters, "exit_threshold", 0.5)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := floatParam(parameters, "stop_threshold", 0)
+	if err != nil {
+		return nil, err
+	}
+	size, err := floatParam(parameters, "position_size", 0.5)
+	if err != nil {
+		return nil, err
+	}
+	maxHolding, err := intParam(parameters, "max_holding_days", 0)
+	if err != nil {
+		return nil, err
+	}
+	useLog, err := boolParam(parameters, "use_log_prices", true)
+	if err != nil {
+		return nil, err
+	}
+
+	if window < 10 {
+		return nil, fmt.Errorf("window must be at least 10")
+	}
+	if entry <= 0 {
+		return nil, fmt.Errorf("entry_threshold must be positive")
+	}
+	if exit >= entry {
+		return nil, fmt.Errorf("exit_threshold must be below entry_threshold")
+	}
+	if stop < 0 || (stop > 0 && stop <= entry) {
+		return nil, fmt.Errorf("stop_threshold must be 0 or above entry_threshold")
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("position_size must be positive")
+	}
+	if maxHolding < 0 {
+		return nil, fmt.Errorf("max_holding_days must not be negative")
+	}
+
+	return &models.PairsParams{
+		Window:         window,
+		EntryThreshold: entry,
+		ExitThreshold:  exit,
+		StopThreshold:  stop,
+		PositionSize:   size,
+		MaxHoldingDays: maxHolding,
+		UseLogPrices:   useLog,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *pairsStrategy) Init(ctx *StrategyContext) error {
+	if len(ctx.Assets) != 2 {
+		return fmt.Errorf("pairs trading needs exactly two assets, got %d", len(ctx.Assets))
+	}
+	if margin := ctx.Portfolio.margin; margin == nil || !margin.AllowShort {
+		return fmt.Errorf("pairs trading needs a margin account that allows shorting")
+	}
+	s.assetY, s.assetX = ctx.Assets[0], ctx.Assets[1]
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *pairsStrategy) OnBar(ctx *StrategyContext) error {
+	// The spread is only observed on days both legs trade
+	barY, okY := ctx.AssetBar(s.assetY)
+	barX, okX := ctx.AssetBar(s.assetX)
+	if !okY || !okX || barY.Close <= 0 || barX.Close <= 0 {
+		return nil
+	}
+	s.y = append(s.y, s.price(barY.Close))
+	s.x = append(s.x, s.price(barX.Close))
+	if len(s.y) < s.params.Window {
+		return nil
+	}
+
+	y := s.y[len(s.y)-s.params.Window:]
+	x := s.x[len(s.x)-s.params.Window:]
+	hedgeRatio, intercept, ok := linearRegression(x, y)
+	if !ok {
+		return nil
+	}
+	spreads := spreadSeries(y, x, hedgeRatio, intercept)
+	mean, std, ok := meanStd(spreads)
+	if !ok || std == 0 {
+		return nil
+	}
+	spread := spreads[len(spreads)-1]
+	zScore := (spread - mean) / std
+
+	// A leg closed by the risk overlay or a margin call ends the trade
+	if s.side != 0 && !s.hedged(ctx) {
+		ctx.CloseAll()
+		s.side = 0
+	}
+
+	if s.side == 0 {
+		switch {
+		case zScore >= s.params.EntryThreshold:
+			s.open(ctx, -1, hedgeRatio, barY.Close, barX.Close)
+		case zScore <= -s.params.EntryThreshold:
+			s.open(ctx, 1, hedgeRatio, barY.Close, barX.Close)
+		}
+	} else {
+		// Signed so that a reverting spread falls towards -exit_threshold
+		deviation := -float64(s.side) * zScore
+		reverted := deviation <= s.params.ExitThreshold
+		stopped := s.params.StopThreshold > 0 && deviation >= s.params.StopThreshold
+		expired := s.params.MaxHoldingDays > 0 && ctx.Index-s.entryIndex >= s.params.MaxHoldingDays
+		if reverted || stopped || expired {
+			ctx.CloseAll()
+			s.side = 0
+		}
+	}
+
+	s.series = append(s.series, models.PairsPoint{
+		Date:       ctx.Date(),
+		Spread:     spread,
+		ZScore:     zScore,
+		HedgeRatio: hedgeRatio,
+		HalfLife:   halfLife(spreads),
+		Position:   s.side,
+	})
+	return nil
+}
+
+// open buys (side 1) or shorts (side -1) the spread. The hedge ratio is fixed
+// for the life of the trade; if either leg cannot be filled the other is
+// unwound so the strategy never holds an unhedged leg.
+func (s *pairsStrategy) open(ctx *StrategyContext, side int, hedgeRatio, priceY, priceX float64) {
+	if hedgeRatio <= 0 {
+		return
+	}
+
+	// Value of the x leg per unit value of the y leg
+	hedgeValue := hedgeRatio
+	if !s.params.UseLogPrices {
+		hedgeValue = hedgeRatio * priceX * ctx.Portfolio.FXRate(s.assetX) / (priceY * ctx.Portfolio.FXRate(s.assetY))
+	}
+	ctx.OrderTargetWeights(map[string]float64{
+		s.assetY: float64(side) * s.params.PositionSize,
+		s.assetX: -float64(side) * s.params.PositionSize * hedgeValue,
+	})
+
+	s.side = side
+	if !s.hedged(ctx) {
+		ctx.CloseAll()
+		s.side = 0
+		return
+	}
+	s.entryIndex = ctx.Index
+}
+
+// hedged reports whether both legs are open on the sides of the spread trade
+func (s *pairsStrategy) hedged(ctx *StrategyContext) bool {
+	quantityY := ctx.Portfolio.Position(s.assetY).Quantity
+	quantityX := ctx.Portfolio.Position(s.assetX).Quantity
+	return quantityY*float64(s.side) > 0 && quantityX*float64(s.side) < 0
+}
+
+// price transforms a close into the scale the hedge ratio is estimated on
+func (s *pairsStrategy) price(close float64) float64 {
+	if s.params.UseLogPrices {
+		return math.Log(close)
+	}
+	return close
+}
+
+// spreadSeries returns the regression residuals y - hedgeRatio * x - intercept
+func spreadSeries(y, x []float64, hedgeRatio, intercept float64) []float64 {
+	spreads := make([]float64, len(y))
+	for i := range y {
+		spreads[i] = y[i] - hedgeRatio*x[i] - intercept
+	}
+	return spreads
+}
+
+// Finalize implements Strategy
+func (s *pairsStrategy) Finalize(ctx *StrategyContext) error {
+	// Close both legs so the last round trip is counted
+	ctx.CloseAll()
+	return nil
+}
+
+// ReportResult implements ResultReporter
+func (s *pairsStrategy) ReportResult(result *models.BacktestResult) {
+	analysis := &models.PairsAnalysis{AssetY: s.assetY, AssetX: s.assetX, Series: s.series}
+	if hedgeRatio, intercept, ok := linearRegression(s.x, s.y); ok {
+		analysis.HedgeRatio = hedgeRatio
+		analysis.Intercept = intercept
+		analysis.HalfLife = halfLife(spreadSeries(s.y, s.x, hedgeRatio, intercept))
+	}
+	result.Pairs = analysis
+}
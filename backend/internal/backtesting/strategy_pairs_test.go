@@ -0,0 +1,72 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestPairsStrategy(t *testing.T) {
+	// y tracks twice x until it jumps 3 above on bar 10 and reverts on bar 11
+	x := []float64{50, 51, 49, 52, 48, 50, 53, 47, 51, 49, 50, 50, 50}
+	y := make([]float64, len(x))
+	for i := range x {
+		y[i] = 2 * x[i]
+		if i < 10 {
+			y[i] += 0.1 * float64(1-2*(i%2))
+		}
+	}
+	y[10] += 3
+	markets := testMarkets([]string{"y", "x"}, y, x)
+	bars := markets[0].Data
+
+	parameters := map[string]interface{}{"window": 10.0, "entry_threshold": 2.0, "exit_threshold": 0.5, "use_log_prices": false}
+	request := testRequest(models.StrategyTypePairs, parameters, bars)
+	request.AssetIDs = []string{"y", "x"}
+	request.Margin = &models.MarginConfig{AllowShort: true}
+	result := runTestBacktest(t, request, markets...)
+
+	// Short the spread when y is rich, then close both legs on reversion
+	want := []struct {
+		asset, action string
+		bar           int
+	}{
+		{"y", "short", 10},
+		{"x", "buy", 10},
+		{"y", "cover", 11},
+		{"x", "sell", 11},
+	}
+	trades := tradesBy(result, "")
+	if len(trades) != len(want) {
+		t.Fatalf("trades = %+v, want %d", trades, len(want))
+	}
+	for i, w := range want {
+		if trades[i].AssetID != w.asset || trades[i].Action != w.action || !trades[i].Date.Equal(bars[w.bar].Date) {
+			t.Errorf("trade %d = %s %s on %s, want %s %s on bar %d", i,
+				trades[i].Action, trades[i].AssetID, trades[i].Date.Format("2006-01-02"), w.action, w.asset, w.bar)
+		}
+	}
+
+	// The x leg is hedged in units by the ratio of about 2
+	if ratio := trades[1].Quantity / trades[0].Quantity; ratio < 1.8 || ratio > 2.2 {
+		t.Errorf("hedged %v x against %v y, want about twice as many", trades[1].Quantity, trades[0].Quantity)
+	}
+
+	pairs := result.Pairs
+	if pairs == nil || pairs.AssetY != "y" || pairs.AssetX != "x" || len(pairs.Series) != 4 {
+		t.Fatalf("pairs analysis = %+v, want 4 points of y against x", pairs)
+	}
+	if pairs.Series[1].Position != -1 || pairs.Series[1].ZScore < 2 || pairs.Series[2].Position != 0 {
+		t.Errorf("series = %+v, want short the spread on bar 10 only", pairs.Series)
+	}
+}
+
+func TestPairsStrategyNeedsShorting(t *testing.T) {
+	markets := testMarkets([]string{"y", "x"}, []float64{100, 100}, []float64{50, 50})
+	request := testRequest(models.StrategyTypePairs, nil, markets[0].Data)
+	request.AssetIDs = []string{"y", "x"}
+	_, err := NewBacktestEngine().RunPortfolioBacktest(request, markets)
+	if err == nil || !strings.Contains(err.Error(), "margin account that allows shorting") {
+		t.Errorf("error = %v, want a margin account required", err)
+	}
+}
@@ -0,0 +1,147 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"math"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeRebalance,
+		Name:        "Portfolio Rebalance Strategy",
+		Description: "Hold a portfolio of assets at fixed target weights, equal weight by default, and rebalance back to them on a schedule",
+		Parameters: map[string]ParameterSpec{
+			"target_weights": {
+				Type:        "object",
+				Default:     map[string]float64{},
+				Description: "Target weight per asset ID, e.g. {\"csi300\": 0.5, \"csi500\": 0.5}; empty holds all assets at equal weight and weights summing below 1 keep the rest in cash",
+			},
+			"rebalance_frequency": {
+				Type:        "string",
+				Default:     "monthly",
+				Options:     []string{"daily", "weekly", "monthly", "quarterly", "yearly"},
+				Description: "How often the portfolio is checked and rebalanced",
+			},
+			"drift_threshold": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{0, 1},
+				Description: "Only rebalance once an asset's weight drifts this far from its target, 0 rebalances on every scheduled date",
+			},
+		},
+		Factory:    newRebalanceStrategy,
+		MultiAsset: true,
+	})
+}
+
+// rebalanceStrategy holds fixed target weights across the traded assets
+type rebalanceStrategy struct {
+	params   *models.RebalanceParams
+	weights  map[string]float64
+	invested bool
+}
+
+// newRebalanceStrategy creates a rebalance strategy from parameters
+func newRebalanceStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseRebalanceParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return &rebalanceStrategy{params: params}, nil
+}
+
+// parseRebalanceParams parses and validates rebalance strategy parameters
+func parseRebalanceParams(parameters map[string]interface{}) (*models.RebalanceParams, error) {
+	weights, err := weightsParam(parameters, "target_weights")
+	if err != nil {
+		return nil, err
+	}
+	frequency, err := stringParam(parameters, "rebalance_frequency", "monthly")
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := floatParam(parameters, "drift_threshold", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0.0
+	for asset, weight := range weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("target weight of %s must not be negative", asset)
+		}
+		total += weight
+	}
+	if total > 1+1e-9 {
+		return nil, fmt.Errorf("target_weights must not sum above 1")
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("drift_threshold must be between 0 and 1")
+	}
+
+	return &models.RebalanceParams{
+		TargetWeights:      weights,
+		RebalanceFrequency: frequency,
+		DriftThreshold:     threshold,
+	}, nil
+}
+
+// Init implements Strategy
+func (s *rebalanceStrategy) Init(ctx *StrategyContext) error {
+	if len(s.params.TargetWeights) == 0 {
+		s.weights = make(map[string]float64, len(ctx.Assets))
+		for _, asset := range ctx.Assets {
+			s.weights[asset] = 1 / float64(len(ctx.Assets))
+		}
+		return nil
+	}
+
+	traded := make(map[string]bool, len(ctx.Assets))
+	for _, asset := range ctx.Assets {
+		traded[asset] = true
+	}
+	for asset := range s.params.TargetWeights {
+		if !traded[asset] {
+			return fmt.Errorf("target_weights names %s, which is not in the backtest's assets", asset)
+		}
+	}
+	s.weights = s.params.TargetWeights
+	return nil
+}
+
+// OnBar implements Strategy
+func (s *rebalanceStrategy) OnBar(ctx *StrategyContext) error {
+	// Invest on the first bar the orders fill, then on the schedule
+	if s.invested && !ctx.IsPeriodStart(s.params.RebalanceFrequency) {
+		return nil
+	}
+	if s.invested && s.maxDrift(ctx) < s.params.DriftThreshold {
+		return nil
+	}
+
+	if trades := ctx.OrderTargetWeights(s.weights); len(trades) > 0 {
+		s.invested = true
+	}
+	return nil
+}
+
+// maxDrift returns the largest distance of an asset's weight from its target
+func (s *rebalanceStrategy) maxDrift(ctx *StrategyContext) float64 {
+	value := ctx.Portfolio.Value()
+	if value <= 0 {
+		return 0
+	}
+
+	drift := 0.0
+	for _, asset := range ctx.Assets {
+		weight := ctx.Portfolio.Position(asset).MarketValue / value
+		drift = math.Max(drift, math.Abs(weight-s.weights[asset]))
+	}
+	return drift
+}
+
+// Finalize implements Strategy
+func (s *rebalanceStrategy) Finalize(ctx *StrategyContext) error {
+	return nil
+}
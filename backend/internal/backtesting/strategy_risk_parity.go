@@ -0,0 +1,52 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+)
+
+func init() {
+	RegisterStrategy(StrategyDefinition{
+		Type:        models.StrategyTypeRiskParity,
+		Name:        "Risk Parity Strategy",
+		Description: "Weight the universe so every asset contributes an equal share of portfolio risk, estimated from the covariance of daily returns over a rolling lookback, and rebalance on a schedule",
+		Parameters: map[string]ParameterSpec{
+			"lookback_period": {
+				Type:        "integer",
+				Default:     60,
+				Range:       []float64{10, 504},
+				Description: "Number of daily returns the covariance is estimated over",
+			},
+			"rebalance_frequency": {
+				Type:        "string",
+				Default:     "monthly",
+				Options:     []string{"daily", "weekly", "monthly", "quarterly", "yearly"},
+				Description: "How often the weights are recomputed and the portfolio rebalanced",
+			},
+			"min_weight": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{0, 1},
+				Description: "Lower bound on each asset's weight, 0 disables the bound",
+			},
+			"max_weight": {
+				Type:        "float",
+				Default:     0.0,
+				Range:       []float64{0, 1},
+				Description: "Upper bound on each asset's weight, 0 disables the bound; bounded weights no longer contribute equal risk",
+			},
+		},
+		Factory:    newRiskParityStrategy,
+		MultiAsset: true,
+	})
+}
+
+// newRiskParityStrategy creates an equal risk contribution strategy from
+// parameters. Its weights are always long only.
+func newRiskParityStrategy(parameters map[string]interface{}) (Strategy, error) {
+	params, err := parseAllocationParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	params.LongOnly = true
+	return &allocationStrategy{params: params, allocate: equalRiskWeights}, nil
+}
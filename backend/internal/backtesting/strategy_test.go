@@ -0,0 +1,110 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestStrategyRegistry(t *testing.T) {
+	defs := SupportedStrategies()
+	for i := 1; i < len(defs); i++ {
+		if defs[i-1].Type >= defs[i].Type {
+			t.Fatalf("strategies not sorted by type: %s before %s", defs[i-1].Type, defs[i].Type)
+		}
+	}
+	for _, strategyType := range []models.StrategyType{models.StrategyTypeBuyAndHold, models.StrategyTypeMonthlyRotation} {
+		if def, ok := GetStrategyDefinition(strategyType); !ok || def.Factory == nil {
+			t.Errorf("%s is not registered", strategyType)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  models.StrategyConfig
+		wantErr string
+	}{
+		{"registered", models.StrategyConfig{Type: models.StrategyTypeBuyAndHold}, ""},
+		{"missing type", models.StrategyConfig{}, "strategy type is required"},
+		{"unregistered type", models.StrategyConfig{Type: models.StrategyTypeML}, "unsupported strategy type"},
+		{
+			"parameter of the wrong type",
+			models.StrategyConfig{Type: models.StrategyTypeBuyAndHold, Parameters: map[string]interface{}{"target_allocation": "all"}},
+			"target_allocation must be a number",
+		},
+		{
+			"parameter out of range",
+			models.StrategyConfig{Type: models.StrategyTypeBuyAndHold, Parameters: map[string]interface{}{"rebalance_frequency": "daily"}},
+			"rebalance_frequency must be one of",
+		},
+	}
+	for _, tt := range tests {
+		err := ValidateStrategyConfig(tt.config)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExecuteStrategy(t *testing.T) {
+	bars := dailyBars(models.MarketTypeUSStock, 100, 110, 120)
+	market := testMarket("x", models.MarketTypeUSStock, bars)
+
+	// The strategy trades through the portfolio and every bar is snapshotted
+	result := runTestBacktest(t, testRequest(testAllIn, nil, bars), market)
+	if len(result.DailyReturns) != len(bars) {
+		t.Fatalf("got %d daily returns, want %d", len(result.DailyReturns), len(bars))
+	}
+	buys := tradesBy(result, "buy")
+	if len(buys) != 1 || !buys[0].Date.Equal(bars[0].Date) || buys[0].Quantity != 100 || buys[0].Reason != models.TradeReasonSignal {
+		t.Errorf("buys = %+v, want 100 units on the first bar", buys)
+	}
+	if last := result.DailyReturns[len(bars)-1]; !closeTo(last.PortfolioValue, 12000) || last.Position.Quantity != 100 {
+		t.Errorf("final value = %v holding %v, want 12000 holding 100", last.PortfolioValue, last.Position.Quantity)
+	}
+
+	// Unregistered strategies are rejected and single-asset ones cannot run a portfolio
+	unknown := testRequest(models.StrategyTypeML, nil, bars)
+	if _, err := NewBacktestEngine().RunBacktest(unknown, market); err == nil {
+		t.Error("unregistered strategy ran")
+	}
+	portfolio := testRequest(testAllIn, nil, bars)
+	portfolio.AssetIDs = []string{"x", "y"}
+	_, err := NewBacktestEngine().RunPortfolioBacktest(portfolio, []*models.MarketData{market, testMarket("y", models.MarketTypeUSStock, bars)})
+	if err == nil || !strings.Contains(err.Error(), "cannot run in portfolio mode") {
+		t.Errorf("single-asset strategy in portfolio mode: error = %v", err)
+	}
+}
+
+func TestBuyAndHoldStrategy(t *testing.T) {
+	bars := dailyBars(models.MarketTypeUSStock, 100, 90, 120, 110)
+
+	tests := []struct {
+		name       string
+		allocation float64
+		quantity   float64
+		final      float64
+	}{
+		{"fully invested", 1.0, 100, 11000},
+		{"half in cash", 0.5, 50, 10500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parameters := map[string]interface{}{"target_allocation": tt.allocation, "rebalance_frequency": "never"}
+			result := runTestBacktest(t, testRequest(models.StrategyTypeBuyAndHold, parameters, bars), testMarket("x", models.MarketTypeUSStock, bars))
+
+			if trades := tradesBy(result, ""); len(trades) != 1 || trades[0].Action != "buy" || trades[0].Quantity != tt.quantity {
+				t.Fatalf("trades = %+v, want one buy of %v", trades, tt.quantity)
+			}
+			if got := result.PerformanceMetrics.TotalReturn; !closeTo(got, tt.final/10000-1) {
+				t.Errorf("total return = %v, want %v", got, tt.final/10000-1)
+			}
+		})
+	}
+}
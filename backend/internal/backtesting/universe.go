@@ -0,0 +1,83 @@
+package backtesting
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"sort"
+	"time"
+)
+
+// assetSeries is one asset's bars mapped onto the backtest timeline
+type assetSeries struct {
+	id       string
+	data     []models.OHLCV
+	barIndex []int // Latest bar at or before each timeline date, -1 before the first bar
+}
+
+// bar returns the latest bar of the asset at a timeline index
+func (s *assetSeries) bar(index int) (models.OHLCV, bool) {
+	i := s.barIndex[index]
+	if i < 0 {
+		return models.OHLCV{}, false
+	}
+	return s.data[i], true
+}
+
+// history returns the asset's bars up to and including a timeline index
+func (s *assetSeries) history(index int) []models.OHLCV {
+	return s.data[:s.barIndex[index]+1]
+}
+
+// tradable reports whether the asset has a bar on the timeline date at index
+func (s *assetSeries) tradable(index int, dates []time.Time) bool {
+	bar, ok := s.bar(index)
+	return ok && civilDate(bar.Date).Equal(dates[index])
+}
+
+// alignSeries merges the assets' trading days into one timeline. Union keeps
+// every date any asset trades, so assets on a holiday or weekend carry their
+// last close and cannot trade; intersection keeps only dates all assets trade.
+// Bars are matched on their calendar day, since providers stamp them in
+// different locations and times of day; timeline dates are UTC midnights.
+func alignSeries(data map[string][]models.OHLCV, assets []string, alignment models.DateAlignment) ([]time.Time, map[string]*assetSeries, error) {
+	counts := make(map[time.Time]int)
+	for _, asset := range assets {
+		bars := data[asset]
+		for i, bar := range bars {
+			day := civilDate(bar.Date)
+			if i > 0 && civilDate(bars[i-1].Date).Equal(day) {
+				continue // Count each asset once per day
+			}
+			counts[day]++
+		}
+	}
+
+	var dates []time.Time
+	for date, count := range counts {
+		if alignment == models.DateAlignmentIntersection && count < len(assets) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	if len(dates) == 0 {
+		return nil, nil, fmt.Errorf("assets have no common trading dates")
+	}
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	series := make(map[string]*assetSeries, len(assets))
+	for _, asset := range assets {
+		bars := data[asset]
+		s := &assetSeries{id: asset, data: bars, barIndex: make([]int, len(dates))}
+		next := 0
+		for i, date := range dates {
+			for next < len(bars) && !civilDate(bars[next].Date).After(date) {
+				next++
+			}
+			s.barIndex[i] = next - 1
+		}
+		series[asset] = s
+	}
+	return dates, series, nil
+}
@@ -0,0 +1,164 @@
+package backtesting
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+	"time"
+)
+
+// barsOn returns one bar per date, each stamped at a time of day in loc
+func barsOn(loc *time.Location, hour int, dates ...string) []models.OHLCV {
+	bars := make([]models.OHLCV, len(dates))
+	for i, value := range dates {
+		day, _ := time.Parse("2006-01-02", value)
+		bars[i] = models.OHLCV{
+			Date:  time.Date(day.Year(), day.Month(), day.Day(), hour, 30, 0, 0, loc),
+			Close: float64(100 + i),
+		}
+	}
+	return bars
+}
+
+func TestAlignSeries(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	newYork := time.FixedZone("EST", -5*3600)
+
+	// A-shares stamped at UTC midnight, crypto at 08:00 Shanghai time and US
+	// stocks at the New York open
+	days := map[string][]string{
+		"a": {"2024-01-02", "2024-01-03", "2024-01-05"},
+		"c": {"2024-01-02", "2024-01-03", "2024-01-04", "2024-01-05", "2024-01-06"},
+		"u": {"2024-01-02", "2024-01-04", "2024-01-05"},
+	}
+	data := map[string][]models.OHLCV{
+		"a": barsOn(time.UTC, 0, days["a"]...),
+		"c": barsOn(shanghai, 8, days["c"]...),
+		"u": barsOn(newYork, 9, days["u"]...),
+	}
+	assets := []string{"a", "c", "u"}
+
+	tests := []struct {
+		alignment models.DateAlignment
+		want      []string
+	}{
+		{models.DateAlignmentIntersection, []string{"2024-01-02", "2024-01-05"}},
+		{models.DateAlignmentUnion, []string{"2024-01-02", "2024-01-03", "2024-01-04", "2024-01-05", "2024-01-06"}},
+	}
+	for _, tt := range tests {
+		dates, series, err := alignSeries(data, assets, tt.alignment)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.alignment, err)
+		}
+		if len(dates) != len(tt.want) {
+			t.Fatalf("%s: got %d dates %v, want %v", tt.alignment, len(dates), dates, tt.want)
+		}
+		for i, date := range dates {
+			if got := date.Format("2006-01-02"); got != tt.want[i] || date.Location() != time.UTC || date.Hour() != 0 {
+				t.Errorf("%s: date %d = %s, want %s at UTC midnight", tt.alignment, i, date, tt.want[i])
+			}
+		}
+
+		// Every asset trades on exactly the days it has a bar for
+		for _, asset := range assets {
+			for i, date := range dates {
+				want := false
+				for _, day := range days[asset] {
+					want = want || day == date.Format("2006-01-02")
+				}
+				if got := series[asset].tradable(i, dates); got != want {
+					t.Errorf("%s: %s tradable on %s = %v, want %v", tt.alignment, asset, date.Format("2006-01-02"), got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAlignSeriesCarriesLastBar(t *testing.T) {
+	data := map[string][]models.OHLCV{
+		"a": barsOn(time.UTC, 0, "2024-01-05", "2024-01-08"),
+		"c": barsOn(time.Local, 8, "2024-01-05", "2024-01-06", "2024-01-07", "2024-01-08"),
+	}
+	dates, series, err := alignSeries(data, []string{"a", "c"}, models.DateAlignmentUnion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dates) != 4 {
+		t.Fatalf("got %d dates, want 4", len(dates))
+	}
+
+	// On the weekend the A-share carries Friday's close and cannot trade
+	for _, i := range []int{1, 2} {
+		bar, ok := series["a"].bar(i)
+		if !ok || bar.Close != 100 {
+			t.Errorf("weekend bar %d = %v, %v, want Friday's close", i, bar, ok)
+		}
+		if series["a"].tradable(i, dates) {
+			t.Errorf("A-share tradable on %s", dates[i].Format("2006-01-02"))
+		}
+	}
+	if !series["a"].tradable(3, dates) {
+		t.Errorf("A-share not tradable on Monday")
+	}
+}
+
+func TestAlignSeriesNoCommonDates(t *testing.T) {
+	data := map[string][]models.OHLCV{
+		"a": barsOn(time.UTC, 0, "2024-01-02"),
+		"b": barsOn(time.UTC, 0, "2024-01-03"),
+	}
+	if _, _, err := alignSeries(data, []string{"a", "b"}, models.DateAlignmentIntersection); err == nil {
+		t.Error("expected an error for assets without common dates")
+	}
+}
+
+func TestPortfolioBacktest(t *testing.T) {
+	// b has no bar on 2024-01-04
+	barsA := dailyBars(models.MarketTypeUSStock, 100, 100, 120, 120)
+	barsB := dailyBars(models.MarketTypeUSStock, 50, 50, 50, 50)
+	barsB = append(barsB[:2], barsB[3])
+	markets := []*models.MarketData{testMarket("a", models.MarketTypeUSStock, barsA), testMarket("b", models.MarketTypeUSStock, barsB)}
+
+	tests := []struct {
+		alignment models.DateAlignment
+		dates     int
+	}{
+		{models.DateAlignmentUnion, 4},
+		{models.DateAlignmentIntersection, 3},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.alignment), func(t *testing.T) {
+			request := testRequest(models.StrategyTypeRebalance, map[string]interface{}{"rebalance_frequency": "daily"}, barsA)
+			request.AssetIDs = []string{"a", "b"}
+			request.DateAlignment = tt.alignment
+			result := runTestBacktest(t, request, markets...)
+
+			if len(result.DailyReturns) != tt.dates || len(result.Holdings) != 2 {
+				t.Fatalf("got %d days and %d holdings, want %d days of both assets", len(result.DailyReturns), len(result.Holdings), tt.dates)
+			}
+			first := result.DailyReturns[0].Positions
+			if first["a"].Quantity != 50 || first["b"].Quantity != 100 {
+				t.Errorf("first positions = %+v, want 50 a and 100 b at equal weight", first)
+			}
+
+			// b only trades on its own bars and keeps its last close in between
+			for _, trade := range tradesBy(result, "") {
+				if trade.AssetID == "b" && trade.Date.Equal(barsA[2].Date) {
+					t.Errorf("traded b while its market was closed: %+v", trade)
+				}
+			}
+			for i, point := range result.Holdings[1].Series {
+				closed := point.Date.Equal(barsA[2].Date)
+				if point.Tradable == closed || point.Price != 50 {
+					t.Errorf("b on day %d tradable %v at %v, want %v at 50", i, point.Tradable, point.Price, !closed)
+				}
+			}
+
+			// a rallies to 120 and is sold back to half of the 11000 at the close,
+			// the 4.17 units to sell rounded down to whole shares
+			last := result.Holdings[0].Series[tt.dates-1]
+			if last.Quantity != 46 || !closeTo(last.Weight, 46*120/11000.0) {
+				t.Errorf("a ends at %v units weighing %v, want 46", last.Quantity, last.Weight)
+			}
+		})
+	}
+}
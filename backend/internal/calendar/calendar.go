@@ -0,0 +1,210 @@
+package calendar
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"sort"
+	"time"
+)
+
+// Calendar knows which days an exchange is open without looking at market
+// data, so strategies can reason about upcoming sessions. Trading days are the
+// days outside the market's weekend days and holiday table.
+type Calendar struct {
+	marketType models.MarketType
+	timezone   string
+	weekend    map[time.Weekday]bool
+	holidays   HolidayRule
+
+	holidayCache map[int]map[time.Time]string // Holiday names per year
+	holidayErrs  map[int]error                // Years the holiday rule has no table for
+	monthCache   map[int][]time.Time          // Trading days per year*12+month
+}
+
+// marketHours holds the default trading hours of each supported market
+var marketHours = map[models.MarketType]models.TradingHours{
+	models.MarketTypeAShareIndex: {Timezone: "Asia/Shanghai", OpenTime: "09:30", CloseTime: "15:00", WeekendDays: []int{0, 6}},
+	models.MarketTypeAShareStock: {Timezone: "Asia/Shanghai", OpenTime: "09:30", CloseTime: "15:00", WeekendDays: []int{0, 6}},
+	models.MarketTypeUSIndex:     {Timezone: "America/New_York", OpenTime: "09:30", CloseTime: "16:00", WeekendDays: []int{0, 6}},
+	models.MarketTypeUSStock:     {Timezone: "America/New_York", OpenTime: "09:30", CloseTime: "16:00", WeekendDays: []int{0, 6}},
+	models.MarketTypeETF:         {Timezone: "America/New_York", OpenTime: "09:30", CloseTime: "16:00", WeekendDays: []int{0, 6}},
+	models.MarketTypeHKIndex:     {Timezone: "Asia/Hong_Kong", OpenTime: "09:30", CloseTime: "16:00", WeekendDays: []int{0, 6}},
+	models.MarketTypeHKStock:     {Timezone: "Asia/Hong_Kong", OpenTime: "09:30", CloseTime: "16:00", WeekendDays: []int{0, 6}},
+	models.MarketTypeCrypto:      {Timezone: "UTC", OpenTime: "00:00", CloseTime: "23:59", WeekendDays: []int{}}, // 24/7 trading
+}
+
+// New builds the calendar for a market from an asset's trading hours. Without
+// trading hours the market's default hours apply, and markets without defaults
+// close on Saturday and Sunday only.
+func New(marketType models.MarketType, hours *models.TradingHours) *Calendar {
+	if hours == nil {
+		if defaults, ok := marketHours[marketType]; ok {
+			hours = &defaults
+		}
+	}
+
+	cal := &Calendar{
+		marketType:   marketType,
+		timezone:     "UTC",
+		weekend:      map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		holidays:     holidayRules[marketType],
+		holidayCache: make(map[int]map[time.Time]string),
+		holidayErrs:  make(map[int]error),
+		monthCache:   make(map[int][]time.Time),
+	}
+	if hours != nil {
+		cal.timezone = hours.Timezone
+		cal.weekend = make(map[time.Weekday]bool)
+		for _, day := range hours.WeekendDays {
+			cal.weekend[time.Weekday(day)] = true
+		}
+	}
+	return cal
+}
+
+// ForMarket returns the default calendar of a supported market type
+func ForMarket(marketType models.MarketType) (*Calendar, error) {
+	if _, ok := marketHours[marketType]; !ok {
+		return nil, fmt.Errorf("no trading calendar for market type: %s", marketType)
+	}
+	return New(marketType, nil), nil
+}
+
+// MarketType returns the market the calendar belongs to
+func (c *Calendar) MarketType() models.MarketType {
+	return c.marketType
+}
+
+// Timezone returns the exchange timezone
+func (c *Calendar) Timezone() string {
+	return c.timezone
+}
+
+// WeekendDays returns the closed weekdays, 0=Sunday
+func (c *Calendar) WeekendDays() []int {
+	days := make([]int, 0, len(c.weekend))
+	for day := range c.weekend {
+		days = append(days, int(day))
+	}
+	sort.Ints(days)
+	return days
+}
+
+// Validate checks that the calendar knows the holidays of every year from
+// start to end. The day queries below treat a year without a holiday table
+// as having no holidays, so callers validate their range first.
+func (c *Calendar) Validate(start, end time.Time) error {
+	for year := start.Year(); year <= end.Year(); year++ {
+		c.yearHolidays(year)
+		if err := c.holidayErrs[year]; err != nil {
+			return fmt.Errorf("no %s trading calendar for %d: %w", c.marketType, year, err)
+		}
+	}
+	return nil
+}
+
+// IsTradingDay reports whether the exchange is open on date
+func (c *Calendar) IsTradingDay(date time.Time) bool {
+	day := civilDate(date)
+	if c.weekend[day.Weekday()] {
+		return false
+	}
+	_, holiday := c.yearHolidays(day.Year())[day]
+	return !holiday
+}
+
+// TradingDays returns the trading days between start and end inclusive
+func (c *Calendar) TradingDays(start, end time.Time) []time.Time {
+	var days []time.Time
+	for day := civilDate(start); !day.After(civilDate(end)); day = day.AddDate(0, 0, 1) {
+		if c.IsTradingDay(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// Holidays returns the weekday exchange holidays between start and end
+// inclusive in date order
+func (c *Calendar) Holidays(start, end time.Time) []Holiday {
+	from, to := civilDate(start), civilDate(end)
+
+	var holidays []Holiday
+	for year := from.Year(); year <= to.Year(); year++ {
+		for day, name := range c.yearHolidays(year) {
+			if day.Before(from) || day.After(to) || c.weekend[day.Weekday()] {
+				continue
+			}
+			holidays = append(holidays, Holiday{Date: day, Name: name})
+		}
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+	return holidays
+}
+
+// MonthTradingDays returns the trading days in the month of date in order
+func (c *Calendar) MonthTradingDays(date time.Time) []time.Time {
+	year, month, _ := date.Date()
+	key := year*12 + int(month)
+	if days, ok := c.monthCache[key]; ok {
+		return days
+	}
+
+	first := ymd(year, month, 1)
+	days := c.TradingDays(first, first.AddDate(0, 1, -1))
+	c.monthCache[key] = days
+	return days
+}
+
+// RemainingInMonth returns the number of trading days after date in its month
+func (c *Calendar) RemainingInMonth(date time.Time) int {
+	days := c.MonthTradingDays(date)
+	day := civilDate(date)
+	after := sort.Search(len(days), func(i int) bool {
+		return days[i].After(day)
+	})
+	return len(days) - after
+}
+
+// TradingDaysPerYear returns the average number of trading days a year over
+// the calendar years from start to end, the factor returns sampled on this
+// calendar annualize with: about 252 on NYSE, 242 in China and 365 for crypto
+func (c *Calendar) TradingDaysPerYear(start, end time.Time) float64 {
+	first, last := start.Year(), end.Year()
+	if last < first {
+		first, last = last, first
+	}
+	days := c.TradingDays(ymd(first, time.January, 1), ymd(last, time.December, 31))
+	return float64(len(days)) / float64(last-first+1)
+}
+
+// yearHolidays returns the holidays of a year by date
+func (c *Calendar) yearHolidays(year int) map[time.Time]string {
+	if holidays, ok := c.holidayCache[year]; ok {
+		return holidays
+	}
+
+	holidays := make(map[time.Time]string)
+	if c.holidays != nil {
+		rule, err := c.holidays(year)
+		if err != nil {
+			c.holidayErrs[year] = err
+		}
+		for _, holiday := range rule {
+			day := civilDate(holiday.Date)
+			if _, exists := holidays[day]; !exists {
+				holidays[day] = holiday.Name
+			}
+		}
+	}
+	c.holidayCache[year] = holidays
+	return holidays
+}
+
+// civilDate strips the time of day, keeping the calendar date
+func civilDate(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
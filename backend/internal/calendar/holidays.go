@@ -0,0 +1,475 @@
+package calendar
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"sort"
+	"time"
+)
+
+// Holiday is a full-day exchange closure
+type Holiday struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+}
+
+// HolidayRule returns the exchange holidays of a year, or an error for a year
+// its tables do not cover
+type HolidayRule func(year int) ([]Holiday, error)
+
+// holidayRules maps markets to their exchange holiday tables. Markets without
+// a rule, such as 24/7 crypto, only close on their weekend days.
+var holidayRules = map[models.MarketType]HolidayRule{
+	models.MarketTypeAShareIndex: chinaHolidays,
+	models.MarketTypeAShareStock: chinaHolidays,
+	models.MarketTypeUSIndex:     nyseHolidays,
+	models.MarketTypeUSStock:     nyseHolidays,
+	models.MarketTypeETF:         nyseHolidays,
+	models.MarketTypeHKIndex:     hkexHolidays,
+	models.MarketTypeHKStock:     hkexHolidays,
+}
+
+// Lunar calendar festivals fall on different Gregorian dates every year and
+// are tabulated from firstLunarYear to lastLunarYear. Markets observing them
+// have no calendar for other years.
+const (
+	firstLunarYear = 2000
+	lastLunarYear  = 2030
+)
+
+// lunarNewYear holds the first day of the lunar new year (春节) by year
+var lunarNewYear = map[int]time.Time{
+	2000: ymd(2000, 2, 5), 2001: ymd(2001, 1, 24), 2002: ymd(2002, 2, 12), 2003: ymd(2003, 2, 1),
+	2004: ymd(2004, 1, 22), 2005: ymd(2005, 2, 9), 2006: ymd(2006, 1, 29), 2007: ymd(2007, 2, 18),
+	2008: ymd(2008, 2, 7), 2009: ymd(2009, 1, 26), 2010: ymd(2010, 2, 14), 2011: ymd(2011, 2, 3),
+	2012: ymd(2012, 1, 23), 2013: ymd(2013, 2, 10), 2014: ymd(2014, 1, 31), 2015: ymd(2015, 2, 19),
+	2016: ymd(2016, 2, 8), 2017: ymd(2017, 1, 28), 2018: ymd(2018, 2, 16), 2019: ymd(2019, 2, 5),
+	2020: ymd(2020, 1, 25), 2021: ymd(2021, 2, 12), 2022: ymd(2022, 2, 1), 2023: ymd(2023, 1, 22),
+	2024: ymd(2024, 2, 10), 2025: ymd(2025, 1, 29), 2026: ymd(2026, 2, 17), 2027: ymd(2027, 2, 6),
+	2028: ymd(2028, 1, 26), 2029: ymd(2029, 2, 13), 2030: ymd(2030, 2, 3),
+}
+
+// dragonBoat holds the Dragon Boat Festival (端午节, 5th day of the 5th month) by year
+var dragonBoat = map[int]time.Time{
+	2000: ymd(2000, 6, 6), 2001: ymd(2001, 6, 25), 2002: ymd(2002, 6, 15), 2003: ymd(2003, 6, 4),
+	2004: ymd(2004, 6, 22), 2005: ymd(2005, 6, 11), 2006: ymd(2006, 5, 31), 2007: ymd(2007, 6, 19),
+	2008: ymd(2008, 6, 8), 2009: ymd(2009, 5, 28), 2010: ymd(2010, 6, 16), 2011: ymd(2011, 6, 6),
+	2012: ymd(2012, 6, 23), 2013: ymd(2013, 6, 12), 2014: ymd(2014, 6, 2), 2015: ymd(2015, 6, 20),
+	2016: ymd(2016, 6, 9), 2017: ymd(2017, 5, 30), 2018: ymd(2018, 6, 18), 2019: ymd(2019, 6, 7),
+	2020: ymd(2020, 6, 25), 2021: ymd(2021, 6, 14), 2022: ymd(2022, 6, 3), 2023: ymd(2023, 6, 22),
+	2024: ymd(2024, 6, 10), 2025: ymd(2025, 5, 31), 2026: ymd(2026, 6, 19), 2027: ymd(2027, 6, 9),
+	2028: ymd(2028, 5, 28), 2029: ymd(2029, 6, 16), 2030: ymd(2030, 6, 5),
+}
+
+// midAutumn holds the Mid-Autumn Festival (中秋节, 15th day of the 8th month) by year
+var midAutumn = map[int]time.Time{
+	2000: ymd(2000, 9, 12), 2001: ymd(2001, 10, 1), 2002: ymd(2002, 9, 21), 2003: ymd(2003, 9, 11),
+	2004: ymd(2004, 9, 28), 2005: ymd(2005, 9, 18), 2006: ymd(2006, 10, 6), 2007: ymd(2007, 9, 25),
+	2008: ymd(2008, 9, 14), 2009: ymd(2009, 10, 3), 2010: ymd(2010, 9, 22), 2011: ymd(2011, 9, 12),
+	2012: ymd(2012, 9, 30), 2013: ymd(2013, 9, 19), 2014: ymd(2014, 9, 8), 2015: ymd(2015, 9, 27),
+	2016: ymd(2016, 9, 15), 2017: ymd(2017, 10, 4), 2018: ymd(2018, 9, 24), 2019: ymd(2019, 9, 13),
+	2020: ymd(2020, 10, 1), 2021: ymd(2021, 9, 21), 2022: ymd(2022, 9, 10), 2023: ymd(2023, 9, 29),
+	2024: ymd(2024, 9, 17), 2025: ymd(2025, 10, 6), 2026: ymd(2026, 9, 25), 2027: ymd(2027, 9, 15),
+	2028: ymd(2028, 10, 3), 2029: ymd(2029, 9, 22), 2030: ymd(2030, 9, 12),
+}
+
+// buddhasBirthday holds Buddha's Birthday (8th day of the 4th month) by year
+var buddhasBirthday = map[int]time.Time{
+	2000: ymd(2000, 5, 11), 2001: ymd(2001, 4, 30), 2002: ymd(2002, 5, 19), 2003: ymd(2003, 5, 8),
+	2004: ymd(2004, 5, 26), 2005: ymd(2005, 5, 15), 2006: ymd(2006, 5, 5), 2007: ymd(2007, 5, 24),
+	2008: ymd(2008, 5, 12), 2009: ymd(2009, 5, 2), 2010: ymd(2010, 5, 21), 2011: ymd(2011, 5, 10),
+	2012: ymd(2012, 4, 28), 2013: ymd(2013, 5, 17), 2014: ymd(2014, 5, 6), 2015: ymd(2015, 5, 25),
+	2016: ymd(2016, 5, 14), 2017: ymd(2017, 5, 3), 2018: ymd(2018, 5, 22), 2019: ymd(2019, 5, 12),
+	2020: ymd(2020, 4, 30), 2021: ymd(2021, 5, 19), 2022: ymd(2022, 5, 8), 2023: ymd(2023, 5, 26),
+	2024: ymd(2024, 5, 15), 2025: ymd(2025, 5, 5), 2026: ymd(2026, 5, 24), 2027: ymd(2027, 5, 13),
+	2028: ymd(2028, 5, 2), 2029: ymd(2029, 5, 20), 2030: ymd(2030, 5, 9),
+}
+
+// chungYeung holds the Chung Yeung Festival (重阳节, 9th day of the 9th month) by year
+var chungYeung = map[int]time.Time{
+	2000: ymd(2000, 10, 6), 2001: ymd(2001, 10, 25), 2002: ymd(2002, 10, 14), 2003: ymd(2003, 10, 4),
+	2004: ymd(2004, 10, 22), 2005: ymd(2005, 10, 11), 2006: ymd(2006, 10, 30), 2007: ymd(2007, 10, 19),
+	2008: ymd(2008, 10, 7), 2009: ymd(2009, 10, 26), 2010: ymd(2010, 10, 16), 2011: ymd(2011, 10, 5),
+	2012: ymd(2012, 10, 23), 2013: ymd(2013, 10, 13), 2014: ymd(2014, 10, 2), 2015: ymd(2015, 10, 21),
+	2016: ymd(2016, 10, 9), 2017: ymd(2017, 10, 28), 2018: ymd(2018, 10, 17), 2019: ymd(2019, 10, 7),
+	2020: ymd(2020, 10, 25), 2021: ymd(2021, 10, 14), 2022: ymd(2022, 10, 4), 2023: ymd(2023, 10, 23),
+	2024: ymd(2024, 10, 11), 2025: ymd(2025, 10, 29), 2026: ymd(2026, 10, 18), 2027: ymd(2027, 10, 8),
+	2028: ymd(2028, 10, 26), 2029: ymd(2029, 10, 16), 2030: ymd(2030, 10, 5),
+}
+
+// lunarDate looks up the Gregorian date of a lunar festival in a year
+func lunarDate(table map[int]time.Time, year int) (time.Time, error) {
+	day, ok := table[year]
+	if !ok {
+		return time.Time{}, fmt.Errorf("lunar holidays are only tabulated for %d-%d, not %d", firstLunarYear, lastLunarYear, year)
+	}
+	return day, nil
+}
+
+// nyseHolidays returns the NYSE full-day closures of a year
+func nyseHolidays(year int) ([]Holiday, error) {
+	holidays := []Holiday{
+		{nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King Jr. Day"},
+		{nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday"},
+		{easter(year).AddDate(0, 0, -2), "Good Friday"},
+		{lastWeekday(year, time.May, time.Monday), "Memorial Day"},
+		{observed(ymd(year, 7, 4)), "Independence Day"},
+		{nthWeekday(year, time.September, time.Monday, 1), "Labor Day"},
+		{nthWeekday(year, time.November, time.Thursday, 4), "Thanksgiving Day"},
+		{observed(ymd(year, 12, 25)), "Christmas Day"},
+	}
+
+	// A Saturday New Year's Day is not moved to the Friday before
+	if newYear := ymd(year, 1, 1); newYear.Weekday() != time.Saturday {
+		holidays = append(holidays, Holiday{observed(newYear), "New Year's Day"})
+	}
+	if year >= 2022 {
+		holidays = append(holidays, Holiday{observed(ymd(year, 6, 19)), "Juneteenth"})
+	}
+	return holidays, nil
+}
+
+// Shanghai and Shenzhen closures follow the State Council's holiday
+// arrangements, which the exchanges publish as closure notices each year;
+// they are tabulated from firstChinaYear to lastChinaYear.
+const (
+	firstChinaYear = 2007
+	lastChinaYear  = 2026
+)
+
+// closure is an exchange closure from its first to its last calendar day
+type closure struct {
+	name  string
+	first time.Time
+	last  time.Time
+}
+
+// chinaClosures holds the SSE/SZSE holiday closures by the year their days
+// fall in, so the New Year closure of one year can start in December of the
+// year before. Weekends inside a closure are listed as announced; make-up
+// working weekends do not trade.
+var chinaClosures = map[int][]closure{
+	2007: {
+		{"元旦", ymd(2007, 1, 1), ymd(2007, 1, 3)},
+		{"春节", ymd(2007, 2, 18), ymd(2007, 2, 24)},
+		{"劳动节", ymd(2007, 5, 1), ymd(2007, 5, 7)},
+		{"国庆节", ymd(2007, 10, 1), ymd(2007, 10, 7)},
+		{"元旦", ymd(2007, 12, 30), ymd(2007, 12, 31)},
+	},
+	2008: {
+		{"元旦", ymd(2008, 1, 1), ymd(2008, 1, 1)},
+		{"春节", ymd(2008, 2, 6), ymd(2008, 2, 12)},
+		{"清明节", ymd(2008, 4, 4), ymd(2008, 4, 6)},
+		{"劳动节", ymd(2008, 5, 1), ymd(2008, 5, 3)},
+		{"端午节", ymd(2008, 6, 7), ymd(2008, 6, 9)},
+		{"中秋节", ymd(2008, 9, 13), ymd(2008, 9, 15)},
+		{"国庆节", ymd(2008, 9, 29), ymd(2008, 10, 5)},
+	},
+	2009: {
+		{"元旦", ymd(2009, 1, 1), ymd(2009, 1, 3)},
+		{"春节", ymd(2009, 1, 25), ymd(2009, 1, 31)},
+		{"清明节", ymd(2009, 4, 4), ymd(2009, 4, 6)},
+		{"劳动节", ymd(2009, 5, 1), ymd(2009, 5, 3)},
+		{"端午节", ymd(2009, 5, 28), ymd(2009, 5, 30)},
+		{"国庆节、中秋节", ymd(2009, 10, 1), ymd(2009, 10, 8)},
+	},
+	2010: {
+		{"元旦", ymd(2010, 1, 1), ymd(2010, 1, 3)},
+		{"春节", ymd(2010, 2, 13), ymd(2010, 2, 19)},
+		{"清明节", ymd(2010, 4, 3), ymd(2010, 4, 5)},
+		{"劳动节", ymd(2010, 5, 1), ymd(2010, 5, 3)},
+		{"端午节", ymd(2010, 6, 14), ymd(2010, 6, 16)},
+		{"中秋节", ymd(2010, 9, 22), ymd(2010, 9, 24)},
+		{"国庆节", ymd(2010, 10, 1), ymd(2010, 10, 7)},
+	},
+	2011: {
+		{"元旦", ymd(2011, 1, 1), ymd(2011, 1, 3)},
+		{"春节", ymd(2011, 2, 2), ymd(2011, 2, 8)},
+		{"清明节", ymd(2011, 4, 3), ymd(2011, 4, 5)},
+		{"劳动节", ymd(2011, 4, 30), ymd(2011, 5, 2)},
+		{"端午节", ymd(2011, 6, 4), ymd(2011, 6, 6)},
+		{"中秋节", ymd(2011, 9, 10), ymd(2011, 9, 12)},
+		{"国庆节", ymd(2011, 10, 1), ymd(2011, 10, 7)},
+	},
+	2012: {
+		{"元旦", ymd(2012, 1, 1), ymd(2012, 1, 3)},
+		{"春节", ymd(2012, 1, 22), ymd(2012, 1, 28)},
+		{"清明节", ymd(2012, 4, 2), ymd(2012, 4, 4)},
+		{"劳动节", ymd(2012, 4, 29), ymd(2012, 5, 1)},
+		{"端午节", ymd(2012, 6, 22), ymd(2012, 6, 24)},
+		{"中秋节、国庆节", ymd(2012, 9, 30), ymd(2012, 10, 7)},
+	},
+	2013: {
+		{"元旦", ymd(2013, 1, 1), ymd(2013, 1, 3)},
+		{"春节", ymd(2013, 2, 9), ymd(2013, 2, 15)},
+		{"清明节", ymd(2013, 4, 4), ymd(2013, 4, 6)},
+		{"劳动节", ymd(2013, 4, 29), ymd(2013, 5, 1)},
+		{"端午节", ymd(2013, 6, 10), ymd(2013, 6, 12)},
+		{"中秋节", ymd(2013, 9, 19), ymd(2013, 9, 21)},
+		{"国庆节", ymd(2013, 10, 1), ymd(2013, 10, 7)},
+	},
+	2014: {
+		{"元旦", ymd(2014, 1, 1), ymd(2014, 1, 1)},
+		{"春节", ymd(2014, 1, 31), ymd(2014, 2, 6)},
+		{"清明节", ymd(2014, 4, 5), ymd(2014, 4, 7)},
+		{"劳动节", ymd(2014, 5, 1), ymd(2014, 5, 3)},
+		{"端午节", ymd(2014, 5, 31), ymd(2014, 6, 2)},
+		{"中秋节", ymd(2014, 9, 6), ymd(2014, 9, 8)},
+		{"国庆节", ymd(2014, 10, 1), ymd(2014, 10, 7)},
+	},
+	2015: {
+		{"元旦", ymd(2015, 1, 1), ymd(2015, 1, 3)},
+		{"春节", ymd(2015, 2, 18), ymd(2015, 2, 24)},
+		{"清明节", ymd(2015, 4, 4), ymd(2015, 4, 6)},
+		{"劳动节", ymd(2015, 5, 1), ymd(2015, 5, 3)},
+		{"端午节", ymd(2015, 6, 20), ymd(2015, 6, 22)},
+		{"抗战胜利纪念日", ymd(2015, 9, 3), ymd(2015, 9, 5)},
+		{"中秋节", ymd(2015, 9, 26), ymd(2015, 9, 27)},
+		{"国庆节", ymd(2015, 10, 1), ymd(2015, 10, 7)},
+	},
+	2016: {
+		{"元旦", ymd(2016, 1, 1), ymd(2016, 1, 3)},
+		{"春节", ymd(2016, 2, 7), ymd(2016, 2, 13)},
+		{"清明节", ymd(2016, 4, 2), ymd(2016, 4, 4)},
+		{"劳动节", ymd(2016, 4, 30), ymd(2016, 5, 2)},
+		{"端午节", ymd(2016, 6, 9), ymd(2016, 6, 11)},
+		{"中秋节", ymd(2016, 9, 15), ymd(2016, 9, 17)},
+		{"国庆节", ymd(2016, 10, 1), ymd(2016, 10, 7)},
+		{"元旦", ymd(2016, 12, 31), ymd(2016, 12, 31)},
+	},
+	2017: {
+		{"元旦", ymd(2017, 1, 1), ymd(2017, 1, 2)},
+		{"春节", ymd(2017, 1, 27), ymd(2017, 2, 2)},
+		{"清明节", ymd(2017, 4, 2), ymd(2017, 4, 4)},
+		{"劳动节", ymd(2017, 4, 29), ymd(2017, 5, 1)},
+		{"端午节", ymd(2017, 5, 28), ymd(2017, 5, 30)},
+		{"国庆节、中秋节", ymd(2017, 10, 1), ymd(2017, 10, 8)},
+		{"元旦", ymd(2017, 12, 30), ymd(2017, 12, 31)},
+	},
+	2018: {
+		{"元旦", ymd(2018, 1, 1), ymd(2018, 1, 1)},
+		{"春节", ymd(2018, 2, 15), ymd(2018, 2, 21)},
+		{"清明节", ymd(2018, 4, 5), ymd(2018, 4, 7)},
+		{"劳动节", ymd(2018, 4, 29), ymd(2018, 5, 1)},
+		{"端午节", ymd(2018, 6, 16), ymd(2018, 6, 18)},
+		{"中秋节", ymd(2018, 9, 22), ymd(2018, 9, 24)},
+		{"国庆节", ymd(2018, 10, 1), ymd(2018, 10, 7)},
+		{"元旦", ymd(2018, 12, 30), ymd(2018, 12, 31)},
+	},
+	2019: {
+		{"元旦", ymd(2019, 1, 1), ymd(2019, 1, 1)},
+		{"春节", ymd(2019, 2, 4), ymd(2019, 2, 10)},
+		{"清明节", ymd(2019, 4, 5), ymd(2019, 4, 7)},
+		{"劳动节", ymd(2019, 5, 1), ymd(2019, 5, 4)},
+		{"端午节", ymd(2019, 6, 7), ymd(2019, 6, 9)},
+		{"中秋节", ymd(2019, 9, 13), ymd(2019, 9, 15)},
+		{"国庆节", ymd(2019, 10, 1), ymd(2019, 10, 7)},
+	},
+	2020: {
+		{"元旦", ymd(2020, 1, 1), ymd(2020, 1, 1)},
+		{"春节", ymd(2020, 1, 24), ymd(2020, 2, 2)},
+		{"清明节", ymd(2020, 4, 4), ymd(2020, 4, 6)},
+		{"劳动节", ymd(2020, 5, 1), ymd(2020, 5, 5)},
+		{"端午节", ymd(2020, 6, 25), ymd(2020, 6, 27)},
+		{"国庆节、中秋节", ymd(2020, 10, 1), ymd(2020, 10, 8)},
+	},
+	2021: {
+		{"元旦", ymd(2021, 1, 1), ymd(2021, 1, 3)},
+		{"春节", ymd(2021, 2, 11), ymd(2021, 2, 17)},
+		{"清明节", ymd(2021, 4, 3), ymd(2021, 4, 5)},
+		{"劳动节", ymd(2021, 5, 1), ymd(2021, 5, 5)},
+		{"端午节", ymd(2021, 6, 12), ymd(2021, 6, 14)},
+		{"中秋节", ymd(2021, 9, 19), ymd(2021, 9, 21)},
+		{"国庆节", ymd(2021, 10, 1), ymd(2021, 10, 7)},
+	},
+	2022: {
+		{"元旦", ymd(2022, 1, 1), ymd(2022, 1, 3)},
+		{"春节", ymd(2022, 1, 31), ymd(2022, 2, 6)},
+		{"清明节", ymd(2022, 4, 3), ymd(2022, 4, 5)},
+		{"劳动节", ymd(2022, 4, 30), ymd(2022, 5, 4)},
+		{"端午节", ymd(2022, 6, 3), ymd(2022, 6, 5)},
+		{"中秋节", ymd(2022, 9, 10), ymd(2022, 9, 12)},
+		{"国庆节", ymd(2022, 10, 1), ymd(2022, 10, 7)},
+		{"元旦", ymd(2022, 12, 31), ymd(2022, 12, 31)},
+	},
+	2023: {
+		{"元旦", ymd(2023, 1, 1), ymd(2023, 1, 2)},
+		{"春节", ymd(2023, 1, 21), ymd(2023, 1, 27)},
+		{"清明节", ymd(2023, 4, 5), ymd(2023, 4, 5)},
+		{"劳动节", ymd(2023, 4, 29), ymd(2023, 5, 3)},
+		{"端午节", ymd(2023, 6, 22), ymd(2023, 6, 24)},
+		{"中秋节、国庆节", ymd(2023, 9, 29), ymd(2023, 10, 6)},
+		{"元旦", ymd(2023, 12, 30), ymd(2023, 12, 31)},
+	},
+	2024: {
+		{"元旦", ymd(2024, 1, 1), ymd(2024, 1, 1)},
+		{"春节", ymd(2024, 2, 9), ymd(2024, 2, 17)},
+		{"清明节", ymd(2024, 4, 4), ymd(2024, 4, 6)},
+		{"劳动节", ymd(2024, 5, 1), ymd(2024, 5, 5)},
+		{"端午节", ymd(2024, 6, 8), ymd(2024, 6, 10)},
+		{"中秋节", ymd(2024, 9, 15), ymd(2024, 9, 17)},
+		{"国庆节", ymd(2024, 10, 1), ymd(2024, 10, 7)},
+	},
+	2025: {
+		{"元旦", ymd(2025, 1, 1), ymd(2025, 1, 1)},
+		{"春节", ymd(2025, 1, 28), ymd(2025, 2, 4)},
+		{"清明节", ymd(2025, 4, 4), ymd(2025, 4, 6)},
+		{"劳动节", ymd(2025, 5, 1), ymd(2025, 5, 5)},
+		{"端午节", ymd(2025, 5, 31), ymd(2025, 6, 2)},
+		{"国庆节、中秋节", ymd(2025, 10, 1), ymd(2025, 10, 8)},
+	},
+	2026: {
+		{"元旦", ymd(2026, 1, 1), ymd(2026, 1, 3)},
+		{"春节", ymd(2026, 2, 15), ymd(2026, 2, 23)},
+		{"清明节", ymd(2026, 4, 4), ymd(2026, 4, 6)},
+		{"劳动节", ymd(2026, 5, 1), ymd(2026, 5, 5)},
+		{"端午节", ymd(2026, 6, 19), ymd(2026, 6, 21)},
+		{"中秋节", ymd(2026, 9, 25), ymd(2026, 9, 27)},
+		{"国庆节", ymd(2026, 10, 1), ymd(2026, 10, 7)},
+	},
+}
+
+// chinaHolidays returns the Shanghai and Shenzhen exchange closures of a year
+func chinaHolidays(year int) ([]Holiday, error) {
+	closures, ok := chinaClosures[year]
+	if !ok {
+		return nil, fmt.Errorf("SSE/SZSE closures are only tabulated for %d-%d, not %d", firstChinaYear, lastChinaYear, year)
+	}
+
+	var holidays []Holiday
+	for _, c := range closures {
+		days := int(c.last.Sub(c.first).Hours()/24) + 1
+		holidays = append(holidays, span(c.first, days, c.name)...)
+	}
+	return holidays, nil
+}
+
+// hkexHolidays returns the Hong Kong exchange closures of a year. A holiday
+// on a Sunday is substituted by the next day that is not already a holiday;
+// Saturday holidays are not substituted.
+func hkexHolidays(year int) ([]Holiday, error) {
+	newYear, err := lunarDate(lunarNewYear, year)
+	if err != nil {
+		return nil, err
+	}
+	var festivals [4]time.Time
+	for i, table := range []map[int]time.Time{buddhasBirthday, dragonBoat, midAutumn, chungYeung} {
+		if festivals[i], err = lunarDate(table, year); err != nil {
+			return nil, err
+		}
+	}
+
+	// The first weekday after Christmas Day is Boxing Day unless that is a Sunday
+	boxingDay := ymd(year, 12, 26)
+	if boxingDay.Weekday() == time.Sunday {
+		boxingDay = boxingDay.AddDate(0, 0, 1)
+	}
+
+	holidays := []Holiday{
+		{ymd(year, 1, 1), "The first day of January"},
+		{easter(year).AddDate(0, 0, -2), "Good Friday"},
+		{easter(year).AddDate(0, 0, 1), "Easter Monday"},
+		{qingming(year), "Ching Ming Festival"},
+		{ymd(year, 5, 1), "Labour Day"},
+		{festivals[0], "Buddha's Birthday"},
+		{festivals[1], "Tuen Ng Festival"},
+		{ymd(year, 7, 1), "HKSAR Establishment Day"},
+		{festivals[2].AddDate(0, 0, 1), "The day following Mid-Autumn Festival"},
+		{ymd(year, 10, 1), "National Day"},
+		{festivals[3], "Chung Yeung Festival"},
+		{ymd(year, 12, 25), "Christmas Day"},
+		{boxingDay, "The first weekday after Christmas Day"},
+	}
+	holidays = append(holidays, span(newYear, 3, "Lunar New Year")...)
+	return substituteSundays(holidays), nil
+}
+
+// substituteSundays adds a substitute holiday for every holiday on a Sunday,
+// on the next day that is neither a Sunday nor already a holiday
+func substituteSundays(holidays []Holiday) []Holiday {
+	sort.SliceStable(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+	taken := make(map[time.Time]bool, len(holidays))
+	for _, holiday := range holidays {
+		taken[holiday.Date] = true
+	}
+
+	for _, holiday := range holidays {
+		if holiday.Date.Weekday() != time.Sunday {
+			continue
+		}
+		day := holiday.Date.AddDate(0, 0, 1)
+		for taken[day] || day.Weekday() == time.Sunday {
+			day = day.AddDate(0, 0, 1)
+		}
+		taken[day] = true
+		holidays = append(holidays, Holiday{day, holiday.Name + " (substitute)"})
+	}
+	return holidays
+}
+
+// Date helpers
+
+// ymd returns midnight UTC of a calendar date
+func ymd(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// span returns n consecutive holidays starting at start
+func span(start time.Time, n int, name string) []Holiday {
+	holidays := make([]Holiday, n)
+	for i := range holidays {
+		holidays[i] = Holiday{Date: start.AddDate(0, 0, i), Name: name}
+	}
+	return holidays
+}
+
+// nthWeekday returns the n-th given weekday of a month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := ymd(year, month, 1)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last given weekday of a month
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := ymd(year, month+1, 0)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// observed moves a Saturday holiday to Friday and a Sunday holiday to Monday
+func observed(day time.Time) time.Time {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.AddDate(0, 0, -1)
+	case time.Sunday:
+		return day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// qingming approximates the Qingming solar term, April 4 or 5
+func qingming(year int) time.Time {
+	if year%4 == 0 || year%4 == 1 {
+		return ymd(year, 4, 4)
+	}
+	return ymd(year, 4, 5)
+}
+
+// easter returns Easter Sunday using the anonymous Gregorian algorithm
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return ymd(year, time.Month(month), day)
+}
@@ -0,0 +1,146 @@
+package calendar
+
+import (
+	"macro_strategy/internal/models"
+	"testing"
+	"time"
+)
+
+func TestIsTradingDay(t *testing.T) {
+	tests := []struct {
+		name   string
+		market models.MarketType
+		date   string
+		open   bool
+	}{
+		{"NYSE Independence Day", models.MarketTypeUSStock, "2023-07-04", false},
+		{"NYSE Sunday Christmas observed Monday", models.MarketTypeUSStock, "2022-12-26", false},
+		{"NYSE Saturday Christmas observed Friday", models.MarketTypeUSStock, "2021-12-24", false},
+		{"NYSE Saturday New Year not moved", models.MarketTypeUSStock, "2021-12-31", true},
+		{"NYSE Juneteenth observed Monday", models.MarketTypeUSStock, "2022-06-20", false},
+		{"NYSE Good Friday", models.MarketTypeUSStock, "2024-03-29", false},
+		{"NYSE regular day", models.MarketTypeUSStock, "2024-03-28", true},
+		{"NYSE Saturday", models.MarketTypeUSStock, "2024-03-30", false},
+
+		{"SSE Spring Festival", models.MarketTypeAShareIndex, "2024-02-12", false},
+		{"SSE New Year's Eve", models.MarketTypeAShareIndex, "2024-02-09", false},
+		{"SSE after Spring Festival", models.MarketTypeAShareIndex, "2024-02-19", true},
+		{"SSE Dragon Boat", models.MarketTypeAShareIndex, "2024-06-10", false},
+		{"SSE Mid-Autumn", models.MarketTypeAShareIndex, "2024-09-17", false},
+		{"SSE Golden Week", models.MarketTypeAShareIndex, "2024-10-07", false},
+		{"SSE after Golden Week", models.MarketTypeAShareIndex, "2024-10-08", true},
+		{"SSE early table year", models.MarketTypeAShareIndex, "2010-06-16", false},
+		{"SSE Qingming on a Friday", models.MarketTypeAShareIndex, "2024-04-05", false},
+		{"SSE Mid-Autumn Monday", models.MarketTypeAShareIndex, "2024-09-16", false},
+		{"SSE eighth day of a combined Golden Week", models.MarketTypeAShareIndex, "2020-10-08", false},
+		{"SSE extended Spring Festival", models.MarketTypeAShareIndex, "2020-01-31", false},
+		{"SSE Victory Day closure", models.MarketTypeAShareIndex, "2015-09-03", false},
+		{"SSE New Year closure in December", models.MarketTypeAShareIndex, "2018-12-31", false},
+		{"SSE Dragon Boat before 2008", models.MarketTypeAShareIndex, "2007-06-19", true},
+		{"SSE week-long Labour Day before 2008", models.MarketTypeAShareIndex, "2007-05-07", false},
+		{"SSE make-up working Sunday", models.MarketTypeAShareIndex, "2024-02-18", false},
+
+		{"HKEX Saturday holiday not substituted", models.MarketTypeHKStock, "2023-07-03", true},
+		{"HKEX Saturday Christmas moves Boxing Day", models.MarketTypeHKStock, "2021-12-27", false},
+		{"HKEX Sunday Christmas substitute", models.MarketTypeHKStock, "2022-12-27", false},
+		{"HKEX Sunday Ching Ming after Easter Monday", models.MarketTypeHKStock, "2021-04-06", false},
+		{"HKEX fourth day of Lunar New Year", models.MarketTypeHKStock, "2023-01-25", false},
+		{"HKEX day following Mid-Autumn on Sunday", models.MarketTypeHKStock, "2022-09-12", false},
+		{"HKEX Sunday New Year substitute", models.MarketTypeHKStock, "2023-01-02", false},
+		{"HKEX Saturday New Year not substituted", models.MarketTypeHKStock, "2022-01-03", true},
+
+		{"crypto weekend", models.MarketTypeCrypto, "2024-02-10", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.market, nil).IsTradingDay(parseDate(t, tt.date)); got != tt.open {
+				t.Errorf("IsTradingDay(%s) = %v, want %v", tt.date, got, tt.open)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		market  models.MarketType
+		start   string
+		end     string
+		wantErr bool
+	}{
+		{models.MarketTypeAShareIndex, "2007-01-01", "2026-12-31", false},
+		{models.MarketTypeAShareIndex, "2006-06-01", "2007-12-31", true},
+		{models.MarketTypeAShareStock, "2026-01-01", "2027-01-31", true},
+		{models.MarketTypeHKStock, "2000-01-01", "2030-12-31", false},
+		{models.MarketTypeHKStock, "2030-01-01", "2031-01-31", true},
+		{models.MarketTypeUSStock, "1990-01-01", "2040-12-31", false},
+		{models.MarketTypeCrypto, "1990-01-01", "2040-12-31", false},
+	}
+
+	for _, tt := range tests {
+		err := New(tt.market, nil).Validate(parseDate(t, tt.start), parseDate(t, tt.end))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s Validate(%s, %s) error = %v, want error %v", tt.market, tt.start, tt.end, err, tt.wantErr)
+		}
+	}
+}
+
+func TestChinaTradingDaysPerYear(t *testing.T) {
+	// Sessions per year as counted by the Shanghai exchange
+	sessions := map[int]int{
+		2007: 242, 2008: 246, 2009: 244, 2010: 242, 2011: 244, 2012: 243, 2013: 238,
+		2014: 245, 2015: 244, 2016: 244, 2017: 244, 2018: 243, 2019: 244, 2020: 243,
+		2021: 243, 2022: 242, 2023: 242, 2024: 242, 2025: 243,
+	}
+	cal := New(models.MarketTypeAShareIndex, nil)
+	for year, want := range sessions {
+		days := cal.TradingDays(ymd(year, time.January, 1), ymd(year, time.December, 31))
+		if len(days) != want {
+			t.Errorf("%d has %d trading days, want %d", year, len(days), want)
+		}
+	}
+}
+
+func TestLunarTablesCoverSupportedYears(t *testing.T) {
+	for year := firstLunarYear; year <= lastLunarYear; year++ {
+		newYear, ok := lunarNewYear[year]
+		if !ok {
+			t.Fatalf("lunarNewYear has no %d", year)
+		}
+
+		// Each festival falls whole lunar months of 29 or 30 days after the
+		// new year, one month later after a leap month
+		checks := []struct {
+			name   string
+			table  map[int]time.Time
+			months int // Whole months before the festival's month
+			day    int
+		}{
+			{"buddhasBirthday", buddhasBirthday, 3, 8},
+			{"dragonBoat", dragonBoat, 4, 5},
+			{"midAutumn", midAutumn, 7, 15},
+			{"chungYeung", chungYeung, 8, 9},
+		}
+		for _, check := range checks {
+			day, ok := check.table[year]
+			if !ok {
+				t.Fatalf("%s has no %d", check.name, year)
+			}
+			days := int(day.Sub(newYear).Hours() / 24)
+			min := check.months*29 + check.day - 1
+			max := (check.months+1)*30 + check.day - 1
+			if days < min || days > max {
+				t.Errorf("%s %d is %d days after the lunar new year, want %d-%d", check.name, year, days, min, max)
+			}
+		}
+	}
+}
+
+func parseDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
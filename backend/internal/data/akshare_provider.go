@@ -75,6 +75,48 @@ func (a *AKShareProvider) GetHistoricalData(symbol string, startDate, endDate ti
 	return data, nil
 }
 
+// GetRiskFreeRates fetches the daily 3-month SHIBOR fixing as annual rates
+func (a *AKShareProvider) GetRiskFreeRates(source models.RiskFreeSource, startDate, endDate time.Time) ([]models.InterestRate, error) {
+	if source != models.RiskFreeSourceShibor3M {
+		return nil, fmt.Errorf("unsupported risk-free source for AKShare: %s", source)
+	}
+
+	cmd := exec.Command(a.pythonPath, a.scriptPath, "get_shibor", "3月",
+		startDate.Format("20060102"), endDate.Format("20060102"))
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute AKShare command: %w", err)
+	}
+
+	var rawData []map[string]interface{}
+	if err := json.Unmarshal(output, &rawData); err != nil {
+		return nil, fmt.Errorf("failed to parse AKShare output: %w", err)
+	}
+
+	var rates []models.InterestRate
+	for _, row := range rawData {
+		dateStr, ok := row["日期"].(string)
+		if !ok {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			continue
+		}
+		rate, err := a.parseFloat(row["利率"])
+		if err != nil {
+			continue
+		}
+		rates = append(rates, models.InterestRate{Date: date, Rate: rate / 100}) // Convert percentage to decimal
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		return rates[i].Date.Before(rates[j].Date)
+	})
+	return rates, nil
+}
+
 // GetLatestPrice fetches the latest price using AKShare
 func (a *AKShareProvider) GetLatestPrice(symbol string) (float64, error) {
 	// Get recent data (last 5 days) and return the most recent close price
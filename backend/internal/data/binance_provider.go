@@ -45,6 +45,13 @@ type BinanceExchangeInfoResponse struct {
 		Status     string `json:"status"`
 		BaseAsset  string `json:"baseAsset"`
 		QuoteAsset string `json:"quoteAsset"`
+		Filters    []struct {
+			FilterType  string `json:"filterType"`
+			MinQty      string `json:"minQty"`
+			StepSize    string `json:"stepSize"`
+			TickSize    string `json:"tickSize"`
+			MinNotional string `json:"minNotional"`
+		} `json:"filters"`
 	} `json:"symbols"`
 }
 
@@ -252,7 +259,7 @@ func (bp *BinanceProvider) GetExchangeInfo(symbol string) (map[string]interface{
 	// Find symbol info
 	for _, symbolInfo := range exchangeInfo.Symbols {
 		if symbolInfo.Symbol == binanceSymbol {
-			return map[string]interface{}{
+			info := map[string]interface{}{
 				"symbol":      symbolInfo.Symbol,
 				"status":      symbolInfo.Status,
 				"base_asset":  symbolInfo.BaseAsset,
@@ -260,7 +267,29 @@ func (bp *BinanceProvider) GetExchangeInfo(symbol string) (map[string]interface{
 				"timezone":    exchangeInfo.Timezone,
 				"server_time": time.UnixMilli(exchangeInfo.ServerTime),
 				"exchange":    "Binance",
-			}, nil
+			}
+
+			// Order quantity and price filters
+			for _, filter := range symbolInfo.Filters {
+				switch filter.FilterType {
+				case "LOT_SIZE":
+					if stepSize, err := strconv.ParseFloat(filter.StepSize, 64); err == nil {
+						info["step_size"] = stepSize
+					}
+					if minQty, err := strconv.ParseFloat(filter.MinQty, 64); err == nil {
+						info["min_qty"] = minQty
+					}
+				case "PRICE_FILTER":
+					if tickSize, err := strconv.ParseFloat(filter.TickSize, 64); err == nil {
+						info["tick_size"] = tickSize
+					}
+				case "NOTIONAL", "MIN_NOTIONAL":
+					if minNotional, err := strconv.ParseFloat(filter.MinNotional, 64); err == nil {
+						info["min_notional"] = minNotional
+					}
+				}
+			}
+			return info, nil
 		}
 	}
 
@@ -271,6 +300,32 @@ func (bp *BinanceProvider) GetExchangeInfo(symbol string) (map[string]interface{
 	}, nil
 }
 
+// GetTradingRules returns the LOT_SIZE, PRICE_FILTER and notional rules
+// Binance enforces on orders for a symbol
+func (bp *BinanceProvider) GetTradingRules(symbol string) (*models.TradingRules, error) {
+	info, err := bp.GetExchangeInfo(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	stepSize, ok := info["step_size"].(float64)
+	if !ok || stepSize <= 0 {
+		return nil, fmt.Errorf("no LOT_SIZE filter found for %s", symbol)
+	}
+
+	rules := &models.TradingRules{LotSize: stepSize}
+	if minQty, ok := info["min_qty"].(float64); ok {
+		rules.MinQuantity = minQty
+	}
+	if tickSize, ok := info["tick_size"].(float64); ok {
+		rules.TickSize = tickSize
+	}
+	if minNotional, ok := info["min_notional"].(float64); ok {
+		rules.MinNotional = minNotional
+	}
+	return rules, nil
+}
+
 // Helper functions
 
 // convertToBinanceSymbol converts symbol format for Binance API
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"fmt"
+	"macro_strategy/internal/models"
+	"strings"
+	"time"
+)
+
+// FXProvider implements DataProvider for exchange rates. Symbols are currency
+// pairs such as "USD/CNY", priced as the value of one unit of the first
+// currency in the second; daily rates come from Yahoo Finance.
+type FXProvider struct {
+	yahoo *YahooProvider
+}
+
+// NewFXProvider creates an FX rate provider on top of a Yahoo Finance provider
+func NewFXProvider(yahoo *YahooProvider) *FXProvider {
+	return &FXProvider{yahoo: yahoo}
+}
+
+// cryptoCurrencies are the currencies Yahoo quotes as "BTC-USD" crypto pairs
+// rather than "USDCNY=X" currency pairs
+var cryptoCurrencies = map[models.Currency]bool{
+	models.CurrencyBTC: true,
+	models.CurrencyETH: true,
+	models.CurrencyBNB: true,
+	models.CurrencyADA: true,
+	models.CurrencySOL: true,
+}
+
+// parseFXPair splits a "FROM/TO" pair symbol. USDT is priced as the US
+// dollar it is pegged to.
+func parseFXPair(symbol string) (from, to models.Currency, err error) {
+	parts := strings.Split(strings.ToUpper(symbol), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid FX pair %q, expected e.g. USD/CNY", symbol)
+	}
+	from, to = models.Currency(parts[0]), models.Currency(parts[1])
+	if from == models.CurrencyUSDT {
+		from = models.CurrencyUSD
+	}
+	if to == models.CurrencyUSDT {
+		to = models.CurrencyUSD
+	}
+	return from, to, nil
+}
+
+// yahooFXSymbol returns the Yahoo symbol quoting a pair and whether its
+// prices must be inverted. Yahoo only lists crypto priced in another
+// currency, so a crypto quote currency is fetched the other way round.
+func yahooFXSymbol(from, to models.Currency) (string, bool) {
+	switch {
+	case cryptoCurrencies[from]:
+		return fmt.Sprintf("%s-%s", from, to), false
+	case cryptoCurrencies[to]:
+		return fmt.Sprintf("%s-%s", to, from), true
+	default:
+		return fmt.Sprintf("%s%s=X", from, to), false
+	}
+}
+
+// GetHistoricalData fetches the daily rates of a currency pair. A pair of
+// identical currencies has a constant rate of 1 on every calendar day.
+func (fp *FXProvider) GetHistoricalData(symbol string, startDate, endDate time.Time) ([]models.OHLCV, error) {
+	from, to, err := parseFXPair(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == to {
+		var bars []models.OHLCV
+		for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+			bars = append(bars, models.OHLCV{Date: date, Open: 1, High: 1, Low: 1, Close: 1})
+		}
+		return bars, nil
+	}
+
+	yahooSymbol, invert := yahooFXSymbol(from, to)
+	bars, err := fp.yahoo.GetHistoricalData(yahooSymbol, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if invert {
+		for i, bar := range bars {
+			bars[i] = models.OHLCV{
+				Date:   bar.Date,
+				Open:   1 / bar.Open,
+				High:   1 / bar.Low,
+				Low:    1 / bar.High,
+				Close:  1 / bar.Close,
+				Volume: bar.Volume,
+			}
+		}
+	}
+	return bars, nil
+}
+
+// GetLatestPrice gets the latest rate of a currency pair
+func (fp *FXProvider) GetLatestPrice(symbol string) (float64, error) {
+	from, to, err := parseFXPair(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 1, nil
+	}
+
+	yahooSymbol, invert := yahooFXSymbol(from, to)
+	price, err := fp.yahoo.GetLatestPrice(yahooSymbol)
+	if err != nil {
+		return 0, err
+	}
+	if invert {
+		if price == 0 {
+			return 0, fmt.Errorf("invalid rate for %s", symbol)
+		}
+		return 1 / price, nil
+	}
+	return price, nil
+}
+
+// IsValidSymbol checks if a symbol is a well-formed currency pair
+func (fp *FXProvider) IsValidSymbol(symbol string) bool {
+	_, _, err := parseFXPair(symbol)
+	return err == nil
+}
@@ -2,6 +2,7 @@ package data
 
 import (
 	"fmt"
+	"macro_strategy/internal/calendar"
 	"macro_strategy/internal/models"
 	"math"
 	"math/rand"
@@ -11,22 +12,56 @@ import (
 // MockDataProvider implements DataProvider interface for testing
 // This simulates realistic market data without requiring external APIs
 type MockDataProvider struct {
-	seed int64
+	seed     int64
+	calendar *calendar.Calendar // Overrides the symbol's market calendar when set
 }
 
-// NewMockDataProvider creates a new mock data provider
+// NewMockDataProvider creates a new mock data provider that generates bars on
+// the trading calendar of each symbol's market
 func NewMockDataProvider() *MockDataProvider {
 	return &MockDataProvider{
 		seed: time.Now().UnixNano(),
 	}
 }
 
+// SetCalendar makes every symbol generate bars on one trading calendar
+func (m *MockDataProvider) SetCalendar(cal *calendar.Calendar) {
+	m.calendar = cal
+}
+
+// calendarFor returns the trading calendar bars of a symbol are generated on:
+// the calendar set on the provider, else that of the symbol's market
+func (m *MockDataProvider) calendarFor(symbol string) *calendar.Calendar {
+	if m.calendar != nil {
+		return m.calendar
+	}
+	if asset := mockAsset(symbol); asset != nil {
+		return calendar.New(asset.MarketType, asset.TradingHours)
+	}
+	return calendar.New(models.MarketTypeAShareIndex, nil)
+}
+
+// mockAsset returns the predefined asset trading under a symbol, nil if none
+func mockAsset(symbol string) *models.Index {
+	for _, asset := range models.GetAllAssets() {
+		if asset.Symbol == symbol {
+			return &asset
+		}
+	}
+	return nil
+}
+
 // GetHistoricalData generates simulated historical data
 func (m *MockDataProvider) GetHistoricalData(symbol string, startDate, endDate time.Time) ([]models.OHLCV, error) {
 	if !m.IsValidSymbol(symbol) {
 		return nil, fmt.Errorf("invalid symbol: %s", symbol)
 	}
 
+	cal := m.calendarFor(symbol)
+	if err := cal.Validate(startDate, endDate); err != nil {
+		return nil, err
+	}
+
 	// Use symbol as seed for consistent data generation
 	rng := rand.New(rand.NewSource(m.generateSeed(symbol)))
 
@@ -35,8 +70,8 @@ func (m *MockDataProvider) GetHistoricalData(symbol string, startDate, endDate t
 	basePrice := m.getBasePriceForSymbol(symbol)
 
 	for currentDate.Before(endDate) || currentDate.Equal(endDate) {
-		// Skip weekends for stock data
-		if currentDate.Weekday() == time.Saturday || currentDate.Weekday() == time.Sunday {
+		// Skip weekends and exchange holidays
+		if !cal.IsTradingDay(currentDate) {
 			currentDate = currentDate.AddDate(0, 0, 1)
 			continue
 		}
@@ -66,18 +101,9 @@ func (m *MockDataProvider) GetLatestPrice(symbol string) (float64, error) {
 	return basePrice * (1 + variation), nil
 }
 
-// IsValidSymbol checks if a symbol is valid (for our predefined indexes)
+// IsValidSymbol checks if a symbol is valid (for our predefined assets)
 func (m *MockDataProvider) IsValidSymbol(symbol string) bool {
-	validSymbols := map[string]bool{
-		"000300.SH": true, // CSI 300
-		"000016.SH": true, // SSE 50
-		"000905.SH": true, // CSI 500
-		"000852.SH": true, // CSI 1000
-		"000688.SH": true, // STAR 50
-		"399006.SZ": true, // ChiNext
-		"399330.SZ": true, // SZSE 100
-	}
-	return validSymbols[symbol]
+	return mockAsset(symbol) != nil
 }
 
 // generateSeed creates a consistent seed based on symbol
@@ -2,6 +2,7 @@ package data
 
 import (
 	"fmt"
+	"log"
 	"macro_strategy/internal/models"
 	"time"
 )
@@ -13,6 +14,36 @@ type DataProvider interface {
 	IsValidSymbol(symbol string) bool
 }
 
+// TradingRulesProvider is implemented by providers that can report the
+// exchange's lot and tick rules for a symbol
+type TradingRulesProvider interface {
+	GetTradingRules(symbol string) (*models.TradingRules, error)
+}
+
+// CorporateActionsProvider is implemented by providers that can report the
+// dividends and splits of a symbol on the same basis as its prices
+type CorporateActionsProvider interface {
+	GetCorporateActions(symbol string, startDate, endDate time.Time) ([]models.CorporateAction, error)
+}
+
+// HistoricalActionsProvider is implemented by providers that return an
+// asset's corporate actions from the same request as its prices
+type HistoricalActionsProvider interface {
+	GetHistoricalDataWithActions(symbol string, startDate, endDate time.Time) ([]models.OHLCV, []models.CorporateAction, error)
+}
+
+// RiskFreeRateProvider is implemented by providers that can report a daily
+// series of annual risk-free interest rates
+type RiskFreeRateProvider interface {
+	GetRiskFreeRates(source models.RiskFreeSource, startDate, endDate time.Time) ([]models.InterestRate, error)
+}
+
+// riskFreeMarkets maps risk-free rate sources to the market whose provider serves them
+var riskFreeMarkets = map[models.RiskFreeSource]models.MarketType{
+	models.RiskFreeSourceShibor3M:  models.MarketTypeAShareIndex,
+	models.RiskFreeSourceUSTBill3M: models.MarketTypeUSIndex,
+}
+
 // DataSourceManager manages different data providers
 type DataSourceManager struct {
 	providers map[models.MarketType]DataProvider
@@ -46,6 +77,9 @@ func NewDataSourceManager() *DataSourceManager {
 	// Register ETF provider (Yahoo can handle most ETFs)
 	dsm.RegisterProvider(models.MarketTypeETF, yahooProvider)
 
+	// Register FX rate provider for currency conversion (also using Yahoo)
+	dsm.RegisterProvider(models.MarketTypeFX, NewFXProvider(yahooProvider))
+
 	return dsm
 }
 
@@ -70,19 +104,132 @@ func (dsm *DataSourceManager) GetMarketData(index *models.Index, startDate, endD
 		return nil, err
 	}
 
-	data, err := provider.GetHistoricalData(index.Symbol, startDate, endDate)
+	// Providers without corporate actions leave dividends uncredited
+	var data []models.OHLCV
+	var corporateActions []models.CorporateAction
+	actionsProvider, fetchActions := provider.(CorporateActionsProvider)
+	if combined, ok := provider.(HistoricalActionsProvider); ok {
+		data, corporateActions, err = combined.GetHistoricalDataWithActions(index.Symbol, startDate, endDate)
+		fetchActions = false
+	} else {
+		data, err = provider.GetHistoricalData(index.Symbol, startDate, endDate)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch data for %s: %w", index.Symbol, err)
 	}
 
+	// Exchange-reported rules take precedence over the asset's configured ones;
+	// without either the engine falls back to the market's default lot rules
+	tradingRules := index.TradingRules
+	if rulesProvider, ok := provider.(TradingRulesProvider); ok {
+		rules, err := rulesProvider.GetTradingRules(index.Symbol)
+		if err != nil {
+			log.Printf("trading rules for %s unavailable, using configured or market default lot rules: %v", index.Symbol, err)
+		} else {
+			tradingRules = rules
+		}
+	}
+
+	// A provider that reports corporate actions must deliver them, or the
+	// backtest would silently miss dividends and splits
+	if fetchActions {
+		corporateActions, err = actionsProvider.GetCorporateActions(index.Symbol, startDate, endDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch corporate actions for %s: %w", index.Symbol, err)
+		}
+	}
+
 	return &models.MarketData{
-		AssetID:    index.ID,
-		Symbol:     index.Symbol,
-		MarketType: index.MarketType,
-		AssetClass: index.AssetClass,
-		Currency:   index.Currency,
-		Data:       data,
-		LastUpdate: time.Now(),
-		Metadata:   make(map[string]interface{}),
+		AssetID:          index.ID,
+		Symbol:           index.Symbol,
+		MarketType:       index.MarketType,
+		AssetClass:       index.AssetClass,
+		Currency:         index.Currency,
+		Data:             data,
+		TradingRules:     tradingRules,
+		TradingHours:     index.TradingHours,
+		CorporateActions: corporateActions,
+		LastUpdate:       time.Now(),
+		Metadata:         make(map[string]interface{}),
 	}, nil
 }
+
+// GetFXRates fetches the daily value of one unit of a currency in another
+func (dsm *DataSourceManager) GetFXRates(from, to models.Currency, startDate, endDate time.Time) ([]models.FXRate, error) {
+	provider, err := dsm.GetProvider(models.MarketTypeFX)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := fmt.Sprintf("%s/%s", from, to)
+	data, err := provider.GetHistoricalData(pair, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch FX rates for %s: %w", pair, err)
+	}
+
+	rates := make([]models.FXRate, 0, len(data))
+	for _, bar := range data {
+		if bar.Close > 0 {
+			rates = append(rates, models.FXRate{Date: bar.Date, Rate: bar.Close})
+		}
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no FX rates available for %s", pair)
+	}
+	return rates, nil
+}
+
+// AttachFXRates attaches the rates converting market data's currency into the
+// base currency, if they differ. Rates start ten days early so the first bars
+// have a rate across FX holidays and weekends.
+func (dsm *DataSourceManager) AttachFXRates(md *models.MarketData, base models.Currency, startDate, endDate time.Time) error {
+	if base == "" || md.Currency == "" || md.Currency == base {
+		return nil
+	}
+	rates, err := dsm.GetFXRates(md.Currency, base, startDate.AddDate(0, 0, -10), endDate)
+	if err != nil {
+		return err
+	}
+	md.FXRates = rates
+	return nil
+}
+
+// GetRiskFreeRates fetches a risk-free rate series from the provider serving it
+func (dsm *DataSourceManager) GetRiskFreeRates(source models.RiskFreeSource, startDate, endDate time.Time) ([]models.InterestRate, error) {
+	marketType, ok := riskFreeMarkets[source]
+	if !ok {
+		return nil, fmt.Errorf("unsupported risk-free source: %s", source)
+	}
+	provider, err := dsm.GetProvider(marketType)
+	if err != nil {
+		return nil, err
+	}
+	ratesProvider, ok := provider.(RiskFreeRateProvider)
+	if !ok {
+		return nil, fmt.Errorf("no risk-free rate provider for source: %s", source)
+	}
+
+	rates, err := ratesProvider.GetRiskFreeRates(source, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch risk-free rates for %s: %w", source, err)
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no risk-free rates available for %s", source)
+	}
+	return rates, nil
+}
+
+// LoadRiskFreeRates fills in the rates of a risk-free config that names a
+// source and has no rates yet. Rates start ten days early so the first bars
+// have a fixing across holidays.
+func (dsm *DataSourceManager) LoadRiskFreeRates(config *models.RiskFreeConfig, startDate, endDate time.Time) error {
+	if config == nil || config.Source == "" || len(config.Rates) > 0 {
+		return nil
+	}
+	rates, err := dsm.GetRiskFreeRates(config.Source, startDate.AddDate(0, 0, -10), endDate)
+	if err != nil {
+		return err
+	}
+	config.Rates = rates
+	return nil
+}
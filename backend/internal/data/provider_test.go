@@ -0,0 +1,82 @@
+package data
+
+import (
+	"errors"
+	"macro_strategy/internal/models"
+	"testing"
+	"time"
+)
+
+// stubProvider serves fixed bars, and corporate actions or an error fetching them
+type stubProvider struct {
+	actions    []models.CorporateAction
+	actionsErr error
+}
+
+func (p *stubProvider) GetHistoricalData(symbol string, startDate, endDate time.Time) ([]models.OHLCV, error) {
+	return []models.OHLCV{{Date: startDate, Close: 100}}, nil
+}
+
+func (p *stubProvider) GetLatestPrice(symbol string) (float64, error) {
+	return 100, nil
+}
+
+func (p *stubProvider) IsValidSymbol(symbol string) bool {
+	return true
+}
+
+func (p *stubProvider) GetCorporateActions(symbol string, startDate, endDate time.Time) ([]models.CorporateAction, error) {
+	return p.actions, p.actionsErr
+}
+
+// combinedProvider serves its corporate actions with the bars
+type combinedProvider struct {
+	stubProvider
+}
+
+func (p *combinedProvider) GetHistoricalDataWithActions(symbol string, startDate, endDate time.Time) ([]models.OHLCV, []models.CorporateAction, error) {
+	if p.actionsErr != nil {
+		return nil, nil, p.actionsErr
+	}
+	data, _ := p.GetHistoricalData(symbol, startDate, endDate)
+	return data, p.actions, nil
+}
+
+func TestGetMarketDataCorporateActions(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	dividend := []models.CorporateAction{{Date: start, Type: models.CorporateActionDividend, Amount: 1}}
+	fetchErr := errors.New("rate limited")
+
+	tests := []struct {
+		name     string
+		provider DataProvider
+		want     int
+		wantErr  bool
+	}{
+		{"separate request", &stubProvider{actions: dividend}, 1, false},
+		{"separate request fails", &stubProvider{actionsErr: fetchErr}, 0, true},
+		{"same request as the bars", &combinedProvider{stubProvider{actions: dividend}}, 1, false},
+		{"same request fails", &combinedProvider{stubProvider{actionsErr: fetchErr}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		dsm := &DataSourceManager{providers: make(map[models.MarketType]DataProvider)}
+		dsm.RegisterProvider(models.MarketTypeUSStock, tt.provider)
+		index := &models.Index{ID: "spy", Symbol: "SPY", MarketType: models.MarketTypeUSStock}
+
+		md, err := dsm.GetMarketData(index, start, start.AddDate(0, 1, 0))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, fetchErr) {
+				t.Errorf("%s: error %v does not wrap the fetch error", tt.name, err)
+			}
+			continue
+		}
+		if len(md.CorporateActions) != tt.want {
+			t.Errorf("%s: got %d corporate actions, want %d", tt.name, len(md.CorporateActions), tt.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"macro_strategy/internal/models"
 	"net/http"
+	"sort"
 	"time"
 )
 
@@ -56,60 +57,54 @@ type YahooResponse struct {
 					Adjclose []float64 `json:"adjclose"`
 				} `json:"adjclose"`
 			} `json:"indicators"`
+			Events struct {
+				Dividends map[string]struct {
+					Amount float64 `json:"amount"`
+					Date   int64   `json:"date"`
+				} `json:"dividends"`
+			} `json:"events"`
 		} `json:"result"`
 		Error interface{} `json:"error"`
 	} `json:"chart"`
 }
 
-// GetHistoricalData fetches historical data from Yahoo Finance
+// GetHistoricalData fetches historical data from Yahoo Finance. Prices are
+// split-adjusted; AdjClose additionally adjusts for dividends.
 func (yp *YahooProvider) GetHistoricalData(symbol string, startDate, endDate time.Time) ([]models.OHLCV, error) {
-	// Rate limiting
-	if time.Since(yp.lastCall) < yp.rateLimit {
-		time.Sleep(yp.rateLimit - time.Since(yp.lastCall))
-	}
-	yp.lastCall = time.Now()
-
-	// Convert dates to Unix timestamps
-	startTimestamp := startDate.Unix()
-	endTimestamp := endDate.Unix()
-
-	// Build API URL
-	url := fmt.Sprintf("%s/%s?period1=%d&period2=%d&interval=1d&includePrePost=false",
-		yp.baseURL, symbol, startTimestamp, endTimestamp)
-
-	// Make HTTP request
-	resp, err := yp.httpClient.Get(url)
+	yahooResp, err := yp.fetchChart(symbol, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data from Yahoo Finance: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Yahoo Finance API returned status %d", resp.StatusCode)
+		return nil, err
 	}
+	return parseBars(symbol, yahooResp)
+}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+// GetCorporateActions fetches the dividends of a symbol from Yahoo Finance.
+// Yahoo's prices and dividend amounts are already split-adjusted, so splits
+// are not reported as actions; applying them again would double count.
+func (yp *YahooProvider) GetCorporateActions(symbol string, startDate, endDate time.Time) ([]models.CorporateAction, error) {
+	yahooResp, err := yp.fetchChart(symbol, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Parse JSON response
-	var yahooResp YahooResponse
-	if err := json.Unmarshal(body, &yahooResp); err != nil {
-		return nil, fmt.Errorf("failed to parse Yahoo Finance response: %w", err)
+		return nil, err
 	}
+	return parseDividends(yahooResp), nil
+}
 
-	// Check for API errors
-	if yahooResp.Chart.Error != nil {
-		return nil, fmt.Errorf("Yahoo Finance API error: %v", yahooResp.Chart.Error)
+// GetHistoricalDataWithActions fetches the bars and dividends of a symbol
+// from one chart request, halving the calls against Yahoo's rate limit
+func (yp *YahooProvider) GetHistoricalDataWithActions(symbol string, startDate, endDate time.Time) ([]models.OHLCV, []models.CorporateAction, error) {
+	yahooResp, err := yp.fetchChart(symbol, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	// Check if we have results
-	if len(yahooResp.Chart.Result) == 0 {
-		return nil, fmt.Errorf("no data found for symbol %s", symbol)
+	bars, err := parseBars(symbol, yahooResp)
+	if err != nil {
+		return nil, nil, err
 	}
+	return bars, parseDividends(yahooResp), nil
+}
 
+// parseBars extracts the daily bars of a chart response
+func parseBars(symbol string, yahooResp *YahooResponse) ([]models.OHLCV, error) {
 	result := yahooResp.Chart.Result[0]
 	if len(result.Timestamp) == 0 {
 		return nil, fmt.Errorf("no historical data available for symbol %s", symbol)
@@ -118,6 +113,10 @@ func (yp *YahooProvider) GetHistoricalData(symbol string, startDate, endDate tim
 	// Extract OHLCV data
 	var ohlcvData []models.OHLCV
 	quotes := result.Indicators.Quote[0]
+	var adjCloses []float64
+	if len(result.Indicators.Adjclose) > 0 {
+		adjCloses = result.Indicators.Adjclose[0].Adjclose
+	}
 
 	for i, timestamp := range result.Timestamp {
 		// Skip if any required data is missing
@@ -140,6 +139,9 @@ func (yp *YahooProvider) GetHistoricalData(symbol string, startDate, endDate tim
 			Close:  quotes.Close[i],
 			Volume: quotes.Volume[i],
 		}
+		if i < len(adjCloses) {
+			ohlcv.AdjClose = adjCloses[i]
+		}
 
 		// Calculate percentage change if possible
 		if i > 0 && quotes.Close[i-1] != 0 {
@@ -156,6 +158,96 @@ func (yp *YahooProvider) GetHistoricalData(symbol string, startDate, endDate tim
 	return ohlcvData, nil
 }
 
+// parseDividends extracts the cash dividends of a chart response in date order
+func parseDividends(yahooResp *YahooResponse) []models.CorporateAction {
+	var actions []models.CorporateAction
+	for _, dividend := range yahooResp.Chart.Result[0].Events.Dividends {
+		if dividend.Amount <= 0 {
+			continue
+		}
+		actions = append(actions, models.CorporateAction{
+			Date:   time.Unix(dividend.Date, 0),
+			Type:   models.CorporateActionDividend,
+			Amount: dividend.Amount,
+		})
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Date.Before(actions[j].Date)
+	})
+	return actions
+}
+
+// GetRiskFreeRates fetches the daily 13-week Treasury bill yield (^IRX) as
+// annual rates
+func (yp *YahooProvider) GetRiskFreeRates(source models.RiskFreeSource, startDate, endDate time.Time) ([]models.InterestRate, error) {
+	if source != models.RiskFreeSourceUSTBill3M {
+		return nil, fmt.Errorf("unsupported risk-free source for Yahoo Finance: %s", source)
+	}
+
+	data, err := yp.GetHistoricalData("%5EIRX", startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]models.InterestRate, 0, len(data))
+	for _, bar := range data {
+		rates = append(rates, models.InterestRate{Date: bar.Date, Rate: bar.Close / 100}) // Yield is quoted in percent
+	}
+	return rates, nil
+}
+
+// fetchChart requests the daily chart of a symbol with its dividend events
+func (yp *YahooProvider) fetchChart(symbol string, startDate, endDate time.Time) (*YahooResponse, error) {
+	// Rate limiting
+	if time.Since(yp.lastCall) < yp.rateLimit {
+		time.Sleep(yp.rateLimit - time.Since(yp.lastCall))
+	}
+	yp.lastCall = time.Now()
+
+	// Convert dates to Unix timestamps
+	startTimestamp := startDate.Unix()
+	endTimestamp := endDate.Unix()
+
+	// Build API URL
+	url := fmt.Sprintf("%s/%s?period1=%d&period2=%d&interval=1d&includePrePost=false&events=div",
+		yp.baseURL, symbol, startTimestamp, endTimestamp)
+
+	// Make HTTP request
+	resp, err := yp.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data from Yahoo Finance: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Yahoo Finance API returned status %d", resp.StatusCode)
+	}
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Parse JSON response
+	var yahooResp YahooResponse
+	if err := json.Unmarshal(body, &yahooResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Yahoo Finance response: %w", err)
+	}
+
+	// Check for API errors
+	if yahooResp.Chart.Error != nil {
+		return nil, fmt.Errorf("Yahoo Finance API error: %v", yahooResp.Chart.Error)
+	}
+
+	// Check if we have results
+	if len(yahooResp.Chart.Result) == 0 || len(yahooResp.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, fmt.Errorf("no data found for symbol %s", symbol)
+	}
+
+	return &yahooResp, nil
+}
+
 // GetLatestPrice fetches the latest price for a symbol
 func (yp *YahooProvider) GetLatestPrice(symbol string) (float64, error) {
 	// Rate limiting
@@ -201,7 +201,7 @@ var CryptoAssets = []Index{
 		Symbol:      "BTC/USDT",
 		MarketType:  MarketTypeCrypto,
 		AssetClass:  AssetClassCrypto,
-		Currency:    CurrencyBTC,
+		Currency:    CurrencyUSDT, // 计价货币
 		Description: "Bitcoin is the first decentralized digital currency",
 		TradingHours: &TradingHours{
 			Timezone:    "UTC",
@@ -220,7 +220,7 @@ var CryptoAssets = []Index{
 		Symbol:      "ETH/USDT",
 		MarketType:  MarketTypeCrypto,
 		AssetClass:  AssetClassCrypto,
-		Currency:    CurrencyETH,
+		Currency:    CurrencyUSDT, // 计价货币
 		Description: "Ethereum is a decentralized platform for smart contracts",
 		TradingHours: &TradingHours{
 			Timezone:    "UTC",
@@ -352,6 +352,9 @@ var HKAssets = []Index{
 			CloseTime:   "16:00",
 			WeekendDays: []int{0, 6},
 		},
+		TradingRules: &TradingRules{
+			LotSize: 100, // 每手100股
+		},
 		Metadata: map[string]interface{}{
 			"sector":   "Technology",
 			"industry": "Internet",
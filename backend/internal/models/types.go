@@ -18,6 +18,7 @@ const (
 	MarketTypeFuture      MarketType = "future"        // 期货
 	MarketTypeOption      MarketType = "option"        // 期权
 	MarketTypeCommodity   MarketType = "commodity"     // 大宗商品
+	MarketTypeFX          MarketType = "fx"            // 外汇
 )
 
 // AssetClass represents different asset classes
@@ -64,9 +65,18 @@ type Index struct {
 	Currency     Currency               `json:"currency"`
 	Description  string                 `json:"description"`
 	TradingHours *TradingHours          `json:"trading_hours,omitempty"`
+	TradingRules *TradingRules          `json:"trading_rules,omitempty"` // 交易单位规则 (覆盖市场默认)
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// TradingRules describes order quantity and price constraints for an asset
+type TradingRules struct {
+	LotSize     float64 `json:"lot_size"`               // 最小交易单位 (A股100股, 加密货币按步长)
+	MinQuantity float64 `json:"min_quantity,omitempty"` // 最小下单数量
+	TickSize    float64 `json:"tick_size,omitempty"`    // 最小价格变动单位 (0 = 不取整)
+	MinNotional float64 `json:"min_notional,omitempty"` // 最小下单金额
+}
+
 // TradingHours represents trading hours for different markets
 type TradingHours struct {
 	Timezone    string `json:"timezone"`
@@ -83,21 +93,50 @@ type OHLCV struct {
 	Low      float64   `json:"low"`
 	Close    float64   `json:"close"`
 	Volume   int64     `json:"volume"`
-	Amount   float64   `json:"amount,omitempty"`   // 成交额
-	Turnover float64   `json:"turnover,omitempty"` // 换手率
-	PctChg   float64   `json:"pct_chg,omitempty"`  // 涨跌幅
+	Amount   float64   `json:"amount,omitempty"`    // 成交额
+	Turnover float64   `json:"turnover,omitempty"`  // 换手率
+	PctChg   float64   `json:"pct_chg,omitempty"`   // 涨跌幅
+	AdjClose float64   `json:"adj_close,omitempty"` // 复权收盘价 (拆股与分红调整)
+}
+
+// CorporateActionType represents the kind of a corporate action
+type CorporateActionType string
+
+const (
+	CorporateActionDividend CorporateActionType = "dividend" // 现金分红
+	CorporateActionSplit    CorporateActionType = "split"    // 拆股/送转
+)
+
+// CorporateAction is a dividend or split taking effect on its ex-date. Amounts
+// are per share on the same basis as the asset's prices.
+type CorporateAction struct {
+	Date   time.Time           `json:"date"` // 除权除息日
+	Type   CorporateActionType `json:"type"`
+	Amount float64             `json:"amount,omitempty"` // 每股现金分红
+	Ratio  float64             `json:"ratio,omitempty"`  // 每股拆分后股数, 如 2 = 一拆二
 }
 
 // MarketData represents historical market data for an asset with enhanced metadata
 type MarketData struct {
-	AssetID    string                 `json:"asset_id"`
-	Symbol     string                 `json:"symbol"`
-	MarketType MarketType             `json:"market_type"`
-	AssetClass AssetClass             `json:"asset_class"`
-	Currency   Currency               `json:"currency"`
-	Data       []OHLCV                `json:"data"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
-	LastUpdate time.Time              `json:"last_update"`
+	AssetID          string                 `json:"asset_id"`
+	Symbol           string                 `json:"symbol"`
+	MarketType       MarketType             `json:"market_type"`
+	AssetClass       AssetClass             `json:"asset_class"`
+	Currency         Currency               `json:"currency"`
+	Data             []OHLCV                `json:"data"`
+	TradingRules     *TradingRules          `json:"trading_rules,omitempty"`     // 交易单位规则
+	TradingHours     *TradingHours          `json:"trading_hours,omitempty"`     // 交易时间 (交易日历)
+	CorporateActions []CorporateAction      `json:"corporate_actions,omitempty"` // 分红与拆股
+	FXRates          []FXRate               `json:"fx_rates,omitempty"`          // 资产货币兑报告货币汇率
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	LastUpdate       time.Time              `json:"last_update"`
+}
+
+// FXRate is the value of one unit of an asset's currency in the backtest's
+// base currency on a date
+type FXRate struct {
+	Date time.Time `json:"date"`
+	Rate float64   `json:"rate"`
 }
 
 // StrategyType represents different strategy types
@@ -122,6 +161,8 @@ const (
 	StrategyTypePortfolio   StrategyType = "portfolio"    // 组合策略
 	StrategyTypeRiskParity  StrategyType = "risk_parity"  // 风险平价策略
 	StrategyTypeMinVariance StrategyType = "min_variance" // 最小方差策略
+	// 轮动策略
+	StrategyTypeIndexRotation StrategyType = "index_rotation" // 指数截面轮动策略
 	// 机器学习策略
 	StrategyTypeML            StrategyType = "ml"            // 机器学习策略
 	StrategyTypeReinforcement StrategyType = "reinforcement" // 强化学习策略
@@ -139,12 +180,64 @@ type StrategyConfig struct {
 
 // RiskManagementConfig represents risk management settings
 type RiskManagementConfig struct {
-	MaxPositionSize float64 `json:"max_position_size,omitempty"` // 最大仓位比例
-	StopLoss        float64 `json:"stop_loss,omitempty"`         // 止损比例
-	TakeProfit      float64 `json:"take_profit,omitempty"`       // 止盈比例
-	MaxDrawdown     float64 `json:"max_drawdown,omitempty"`      // 最大回撤限制
-	CommissionRate  float64 `json:"commission_rate,omitempty"`   // 手续费率
-	SlippageRate    float64 `json:"slippage_rate,omitempty"`     // 滑点率
+	MaxPositionSize  float64 `json:"max_position_size,omitempty"` // 最大仓位比例
+	StopLoss         float64 `json:"stop_loss,omitempty"`         // 止损比例
+	TakeProfit       float64 `json:"take_profit,omitempty"`       // 止盈比例
+	MaxDrawdown      float64 `json:"max_drawdown,omitempty"`      // 最大回撤限制 (触发熔断清仓)
+	DrawdownCooldown int     `json:"drawdown_cooldown,omitempty"` // 熔断后冷却期(交易日)
+	DrawdownReentry  string  `json:"drawdown_reentry,omitempty"`  // 熔断后重新入场规则: "never", "after_cooldown", "above_trip_price"
+	CommissionRate   float64 `json:"commission_rate,omitempty"`   // 手续费率
+	SlippageRate     float64 `json:"slippage_rate,omitempty"`     // 滑点率
+}
+
+// MarginConfig turns the cash account into a margin account that can sell
+// short and carry a negative cash balance
+type MarginConfig struct {
+	AllowShort        bool    `json:"allow_short"`                  // 允许卖空
+	InitialMargin     float64 `json:"initial_margin,omitempty"`     // 初始保证金比例 (默认0.5)
+	MaintenanceMargin float64 `json:"maintenance_margin,omitempty"` // 维持保证金比例 (默认0.3), 低于则强制平仓
+	BorrowRate        float64 `json:"borrow_rate,omitempty"`        // 融券年化费率, 按空头市值计提
+	MarginRate        float64 `json:"margin_rate,omitempty"`        // 融资年化利率, 按负现金计提
+}
+
+// RiskFreeConfig sets the risk-free rate Sharpe, Sortino and alpha are
+// measured against: a fixed annual rate, or a series of annual rates loaded
+// from a source or given inline. Without it a fixed 3% applies.
+type RiskFreeConfig struct {
+	Rate   float64        `json:"rate,omitempty"`   // 固定年化无风险利率, 如 0.02
+	Source RiskFreeSource `json:"source,omitempty"` // 利率序列来源
+	Rates  []InterestRate `json:"rates,omitempty"`  // 年化利率序列, 非空时优先于 rate
+}
+
+// RiskFreeSource names a provider series of risk-free rates
+type RiskFreeSource string
+
+const (
+	RiskFreeSourceShibor3M  RiskFreeSource = "shibor_3m"   // 3个月上海银行间同业拆放利率
+	RiskFreeSourceUSTBill3M RiskFreeSource = "us_tbill_3m" // 3个月美国国债收益率
+)
+
+// InterestRate is an annual interest rate in effect from a date
+type InterestRate struct {
+	Date time.Time `json:"date"`
+	Rate float64   `json:"rate"` // 年化利率, 如 0.02 = 2%
+}
+
+// CostConfig overrides the market's default commission and slippage models
+type CostConfig struct {
+	CommissionModel      string  `json:"commission_model,omitempty"`      // 佣金模型: "percentage", "per_share", "binance", "none"
+	CommissionRate       float64 `json:"commission_rate,omitempty"`       // 佣金费率 (percentage)
+	PerShareFee          float64 `json:"per_share_fee,omitempty"`         // 每股佣金 (per_share)
+	MinCommission        float64 `json:"min_commission,omitempty"`        // 单笔最低佣金
+	StampDutyRate        float64 `json:"stamp_duty_rate,omitempty"`       // 印花税率 (仅卖出)
+	MakerRate            float64 `json:"maker_rate,omitempty"`            // Maker费率 (binance)
+	TakerRate            float64 `json:"taker_rate,omitempty"`            // Taker费率 (binance)
+	OrderLiquidity       string  `json:"order_liquidity,omitempty"`       // 成交方式: "taker", "maker"
+	SlippageModel        string  `json:"slippage_model,omitempty"`        // 滑点模型: "none", "fixed_bps", "volatility", "volume_participation"
+	SlippageBps          float64 `json:"slippage_bps,omitempty"`          // 固定滑点(基点)
+	VolatilityMultiplier float64 `json:"volatility_multiplier,omitempty"` // 波动率滑点倍数
+	VolatilityWindow     int     `json:"volatility_window,omitempty"`     // 波动率窗口
+	ImpactCoefficient    float64 `json:"impact_coefficient,omitempty"`    // 成交量冲击系数
 }
 
 // MonthlyRotationParams represents parameters for monthly rotation strategy
@@ -156,17 +249,17 @@ type MonthlyRotationParams struct {
 // BuyAndHoldParams represents parameters for buy and hold strategy
 type BuyAndHoldParams struct {
 	RebalanceFrequency string  `json:"rebalance_frequency,omitempty"` // "monthly", "quarterly", "yearly", "never"
-	DividendReinvest   bool    `json:"dividend_reinvest,omitempty"`   // 股息再投资
+	DividendReinvest   bool    `json:"dividend_reinvest,omitempty"`   // 股息再投资 (否则分红留存为现金)
 	TargetAllocation   float64 `json:"target_allocation,omitempty"`   // 目标仓位比例
 }
 
 // GridTradingParams represents parameters for grid trading strategy
 type GridTradingParams struct {
 	GridCount    int     `json:"grid_count"`    // 网格数量
-	GridSpacing  float64 `json:"grid_spacing"`  // 网格间距(百分比)
-	BasePrice    float64 `json:"base_price"`    // 基准价格
-	MaxPosition  float64 `json:"max_position"`  // 最大仓位
-	ProfitTarget float64 `json:"profit_target"` // 止盈目标
+	GridSpacing  float64 `json:"grid_spacing"`  // 网格间距(百分比, 0.02 = 2%)
+	BasePrice    float64 `json:"base_price"`    // 基准价格 (0 = 首日收盘价)
+	MaxPosition  float64 `json:"max_position"`  // 最大仓位(占初始资金比例)
+	ProfitTarget float64 `json:"profit_target"` // 单格止盈目标 (0 = 使用网格间距)
 }
 
 // MomentumParams represents parameters for momentum strategy
@@ -177,6 +270,76 @@ type MomentumParams struct {
 	RebalanceFreq     string  `json:"rebalance_freq"`     // 再平衡频率
 }
 
+// MeanReversionParams represents parameters for mean reversion strategy
+type MeanReversionParams struct {
+	Window         int     `json:"window"`           // 滚动窗口
+	EntryThreshold float64 `json:"entry_threshold"`  // 入场Z值 (等价于布林带标准差倍数)
+	ExitThreshold  float64 `json:"exit_threshold"`   // 出场Z值
+	MaxHoldingDays int     `json:"max_holding_days"` // 最长持有天数 (0 = 不限)
+	AllowShort     bool    `json:"allow_short"`      // 高于均值时卖空
+}
+
+// BreakoutParams represents parameters for Donchian channel breakout strategy
+type BreakoutParams struct {
+	EntryWindow   int     `json:"entry_window"`   // 突破通道周期 (N日最高价)
+	ExitWindow    int     `json:"exit_window"`    // 离场通道周期 (N日最低价)
+	ATRPeriod     int     `json:"atr_period"`     // ATR周期
+	ATRMultiplier float64 `json:"atr_multiplier"` // ATR跟踪止损倍数 (0 = 关闭)
+}
+
+// RebalanceParams represents parameters for fixed-weight portfolio rebalancing
+type RebalanceParams struct {
+	TargetWeights      map[string]float64 `json:"target_weights,omitempty"`  // 各资产目标权重 (空 = 等权)
+	RebalanceFrequency string             `json:"rebalance_frequency"`       // 再平衡频率: "daily", "weekly", "monthly", "quarterly", "yearly"
+	DriftThreshold     float64            `json:"drift_threshold,omitempty"` // 权重偏离阈值 (0 = 按频率总是再平衡)
+}
+
+// IndexRotationParams represents parameters for cross-sectional index rotation
+type IndexRotationParams struct {
+	LookbackPeriod     int    `json:"lookback_period"`     // 回望周期
+	SkipPeriod         int    `json:"skip_period"`         // 跳过最近N个交易日 (如12-1动量)
+	RankingMethod      string `json:"ranking_method"`      // 排名方式: "return", "risk_adjusted"
+	TopK               int    `json:"top_k"`               // 持有排名前K的资产
+	RebalanceFrequency string `json:"rebalance_frequency"` // 调仓频率: "weekly", "monthly", "quarterly"
+	AbsoluteMomentum   bool   `json:"absolute_momentum"`   // 绝对动量过滤: 回望收益为负则持有现金
+}
+
+// MultiFactorParams represents parameters for the multi-factor scoring strategy
+type MultiFactorParams struct {
+	Factors            []FactorDefinition `json:"factors"`             // 因子定义
+	Buckets            int                `json:"buckets"`             // 分组数, 持有得分最高的一组
+	RebalanceFrequency string             `json:"rebalance_frequency"` // 调仓频率
+}
+
+// FactorDefinition is one weighted factor of a multi-factor model
+type FactorDefinition struct {
+	Name     string  `json:"name"`     // 因子名称, 默认为 type_lookback
+	Type     string  `json:"type"`     // 因子类型: "momentum", "volatility", "volume_trend", "turnover"
+	Lookback int     `json:"lookback"` // 回望周期
+	Weight   float64 `json:"weight"`   // 综合得分权重, 负值偏好因子值低的资产
+}
+
+// AllocationParams represents parameters for the risk parity and minimum
+// variance allocation strategies
+type AllocationParams struct {
+	LookbackPeriod     int     `json:"lookback_period"`     // 协方差估计回望周期
+	RebalanceFrequency string  `json:"rebalance_frequency"` // 调仓频率
+	LongOnly           bool    `json:"long_only"`           // 仅做多
+	MinWeight          float64 `json:"min_weight"`          // 单资产权重下限 (0 = 不限, 仅做多时为0)
+	MaxWeight          float64 `json:"max_weight"`          // 单资产权重上限 (0 = 不限)
+}
+
+// PairsParams represents parameters for the pairs trading strategy
+type PairsParams struct {
+	Window         int     `json:"window"`           // 对冲比例与Z值滚动窗口
+	EntryThreshold float64 `json:"entry_threshold"`  // 入场Z值
+	ExitThreshold  float64 `json:"exit_threshold"`   // 出场Z值
+	StopThreshold  float64 `json:"stop_threshold"`   // 止损Z值 (0 = 关闭)
+	PositionSize   float64 `json:"position_size"`    // 第一腿占组合价值比例
+	MaxHoldingDays int     `json:"max_holding_days"` // 最长持有天数 (0 = 不限)
+	UseLogPrices   bool    `json:"use_log_prices"`   // 使用对数价格估计对冲比例
+}
+
 // DCAParams represents parameters for Dollar Cost Averaging strategy
 type DCAParams struct {
 	InvestmentAmount float64 `json:"investment_amount"` // 每次投资金额
@@ -187,31 +350,85 @@ type DCAParams struct {
 
 // BacktestRequest represents a backtest request with enhanced configuration
 type BacktestRequest struct {
-	AssetID       string                 `json:"asset_id"`           // 兼容原 IndexID
-	IndexID       string                 `json:"index_id,omitempty"` // 向后兼容
-	Strategy      StrategyConfig         `json:"strategy"`
-	StartDate     time.Time              `json:"start_date"`
-	EndDate       time.Time              `json:"end_date"`
-	InitialCash   float64                `json:"initial_cash"`
-	Benchmark     string                 `json:"benchmark,omitempty"`      // 基准指数
-	RebalanceFreq string                 `json:"rebalance_freq,omitempty"` // 再平衡频率
-	DataSource    string                 `json:"data_source,omitempty"`    // 数据源
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	AssetID         string                 `json:"asset_id"`                 // 兼容原 IndexID
+	IndexID         string                 `json:"index_id,omitempty"`       // 向后兼容
+	AssetIDs        []string               `json:"asset_ids,omitempty"`      // 组合回测资产列表 (非空时为组合模式)
+	DateAlignment   DateAlignment          `json:"date_alignment,omitempty"` // 组合模式日期对齐方式, 默认并集
+	Strategy        StrategyConfig         `json:"strategy"`
+	StartDate       time.Time              `json:"start_date"`
+	EndDate         time.Time              `json:"end_date"`
+	InitialCash     float64                `json:"initial_cash"`
+	Benchmark       string                 `json:"benchmark,omitempty"`        // 基准指数
+	RebalanceFreq   string                 `json:"rebalance_freq,omitempty"`   // 再平衡频率
+	DataSource      string                 `json:"data_source,omitempty"`      // 数据源
+	Costs           *CostConfig            `json:"costs,omitempty"`            // 交易成本配置 (覆盖市场默认)
+	ExecutionTiming ExecutionTiming        `json:"execution_timing,omitempty"` // 成交时点, 默认当根收盘
+	Margin          *MarginConfig          `json:"margin,omitempty"`           // 保证金账户 (卖空/融资)
+	PriceAdjustment PriceAdjustment        `json:"price_adjustment,omitempty"` // 价格复权方式, 默认原始价格加公司行为
+	BaseCurrency    Currency               `json:"base_currency,omitempty"`    // 报告货币, 默认为第一个资产的计价货币
+	RiskFree        *RiskFreeConfig        `json:"risk_free,omitempty"`        // 无风险利率, 默认固定 3%
+	VaRConfidence   float64                `json:"var_confidence,omitempty"`   // VaR/CVaR 置信水平, 默认 0.95
+	DrawdownTopN    int                    `json:"drawdown_top_n,omitempty"`   // 返回最深的 N 个回撤区间, 默认 10
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// PriceAdjustment controls whether a backtest trades on raw prices with
+// explicit corporate actions or on the provider's adjusted series
+type PriceAdjustment string
+
+const (
+	PriceAdjustmentNone     PriceAdjustment = "none"     // 原始价格, 分红入账、拆股调整持仓
+	PriceAdjustmentAdjusted PriceAdjustment = "adjusted" // 按复权收盘价调整OHLC, 分红隐含再投资
+)
+
+// ExecutionTiming controls on which price a signal computed on bar t is filled
+type ExecutionTiming string
+
+const (
+	ExecutionTimingClose     ExecutionTiming = "close"      // 信号当根收盘价成交
+	ExecutionTimingNextOpen  ExecutionTiming = "next_open"  // 下一根开盘价成交
+	ExecutionTimingNextClose ExecutionTiming = "next_close" // 下一根收盘价成交
+	ExecutionTimingNextVWAP  ExecutionTiming = "next_vwap"  // 下一根OHLC均价成交 (VWAP近似)
+)
+
+// DateAlignment controls how the trading days of assets with different
+// calendars are merged into one portfolio timeline
+type DateAlignment string
+
+const (
+	DateAlignmentUnion        DateAlignment = "union"        // 任一资产交易的日期, 休市资产沿用前收盘价且不可交易
+	DateAlignmentIntersection DateAlignment = "intersection" // 所有资产均交易的日期
+)
+
 // Trade represents a single trade
 type Trade struct {
 	Date       time.Time `json:"date"`
-	Action     string    `json:"action"` // "buy" or "sell"
+	AssetID    string    `json:"asset_id,omitempty"`
+	Action     string    `json:"action"` // "buy", "sell", "short" (卖空) or "cover" (买券还券)
 	Price      float64   `json:"price"`
 	Quantity   float64   `json:"quantity"`
 	Amount     float64   `json:"amount"`
 	Commission float64   `json:"commission"`
+	Slippage   float64   `json:"slippage,omitempty"`   // 滑点成本
+	GridLevel  int       `json:"grid_level,omitempty"` // 网格层级 (1 = 基准价下方第一格)
+	Reason     string    `json:"reason,omitempty"`     // 交易原因: signal, stop_loss, take_profit, end_of_backtest
+	FXRate     float64   `json:"fx_rate,omitempty"`    // 成交时资产货币兑报告货币汇率 (同币种为空)
 }
 
+// Trade reasons
+const (
+	TradeReasonSignal           = "signal"            // 策略信号
+	TradeReasonStopLoss         = "stop_loss"         // 止损
+	TradeReasonTakeProfit       = "take_profit"       // 止盈
+	TradeReasonEndOfBacktest    = "end_of_backtest"   // 回测结束平仓
+	TradeReasonCircuitBreaker   = "circuit_breaker"   // 回撤熔断清仓
+	TradeReasonMarginCall       = "margin_call"       // 追加保证金强制平仓
+	TradeReasonDividendReinvest = "dividend_reinvest" // 股息再投资
+)
+
 // Position represents current position
 type Position struct {
-	Quantity     float64 `json:"quantity"`
+	Quantity     float64 `json:"quantity"` // 负数为空头持仓
 	AvgPrice     float64 `json:"avg_price"`
 	MarketValue  float64 `json:"market_value"`
 	UnrealizedPL float64 `json:"unrealized_pl"`
@@ -237,28 +454,172 @@ type PerformanceMetrics struct {
 	MaxLosingTrade    float64 `json:"max_losing_trade"`
 	MaxDrawdownPeriod int     `json:"max_drawdown_period"`
 	RecoveryPeriod    int     `json:"recovery_period"`
+	// 资金投入 (定投等有外部现金流的策略)
+	ContributedCapital float64 `json:"contributed_capital"`  // 累计投入本金
+	NetProfit          float64 `json:"net_profit"`           // 期末价值 - 累计投入
+	TimeWeightedReturn float64 `json:"time_weighted_return"` // 时间加权收益率
+
+	// 年化基准
+	RiskFreeRate   float64 `json:"risk_free_rate"`   // 回测期间平均年化无风险利率
+	PeriodsPerYear float64 `json:"periods_per_year"` // 年化因子: 交易日历每年交易日数
+
+	// 尾部风险与收益分布 (日收益)
+	VaRConfidence       float64 `json:"var_confidence"`        // VaR/CVaR 置信水平
+	HistoricalVaR       float64 `json:"historical_var"`        // 历史模拟法单日 VaR (损失为正)
+	HistoricalCVaR      float64 `json:"historical_cvar"`       // 历史模拟法单日 CVaR (尾部平均损失)
+	ParametricVaR       float64 `json:"parametric_var"`        // 正态参数法单日 VaR
+	ParametricCVaR      float64 `json:"parametric_cvar"`       // 正态参数法单日 CVaR
+	Skewness            float64 `json:"skewness"`              // 偏度
+	Kurtosis            float64 `json:"kurtosis"`              // 超额峰度
+	OmegaRatio          float64 `json:"omega_ratio"`           // Omega 比率 (以无风险收益为阈值)
+	UlcerIndex          float64 `json:"ulcer_index"`           // 溃疡指数 (回撤均方根)
+	TailRatio           float64 `json:"tail_ratio"`            // 尾部比率 (95分位收益 / 5分位损失)
+	LongestLosingStreak int     `json:"longest_losing_streak"` // 最长连续亏损天数
+
+	// 融资融券
+	FinancingCost float64 `json:"financing_cost,omitempty"` // 融券费用与融资利息合计
+
+	// 公司行为
+	DividendIncome float64 `json:"dividend_income,omitempty"` // 累计分红收入 (扣除空头应付)
+
+	// 相对基准
+	Relative *RelativeMetrics `json:"relative,omitempty"` // 相对基准的超额表现, 未指定基准时为空
+}
+
+// RelativeMetrics compares a backtest against a benchmark's buy and hold
+// over their common trading dates
+type RelativeMetrics struct {
+	Benchmark           string  `json:"benchmark"`             // 基准资产
+	BenchmarkReturn     float64 `json:"benchmark_return"`      // 基准同期累计收益率
+	ExcessReturn        float64 `json:"excess_return"`         // 累计超额收益率 (策略 - 基准)
+	Alpha               float64 `json:"alpha"`                 // 年化詹森阿尔法
+	Beta                float64 `json:"beta"`                  // 贝塔
+	Correlation         float64 `json:"correlation"`           // 日收益相关系数
+	TrackingError       float64 `json:"tracking_error"`        // 年化跟踪误差
+	InformationRatio    float64 `json:"information_ratio"`     // 信息比率
+	UpCapture           float64 `json:"up_capture"`            // 上行捕获率
+	DownCapture         float64 `json:"down_capture"`          // 下行捕获率
+	MaxRelativeDrawdown float64 `json:"max_relative_drawdown"` // 超额收益最大回撤 (相对净值)
 }
 
 // BacktestResult represents the complete backtest result
 type BacktestResult struct {
-	ID                 string             `json:"id"`
-	Request            BacktestRequest    `json:"request"`
-	Trades             []Trade            `json:"trades"`
-	DailyReturns       []DailyReturn      `json:"daily_returns"`
-	PerformanceMetrics PerformanceMetrics `json:"performance_metrics"`
-	CreatedAt          time.Time          `json:"created_at"`
-	Duration           time.Duration      `json:"duration"`
+	ID                   string                `json:"id"`
+	Request              BacktestRequest       `json:"request"`
+	Trades               []Trade               `json:"trades"`
+	DailyReturns         []DailyReturn         `json:"daily_returns"`
+	PerformanceMetrics   PerformanceMetrics    `json:"performance_metrics"`
+	BaseCurrency         Currency              `json:"base_currency,omitempty"`          // 组合价值与指标的计价货币
+	CircuitBreakerEvents []CircuitBreakerEvent `json:"circuit_breaker_events,omitempty"` // 回撤熔断记录
+	Holdings             []AssetHoldings       `json:"holdings,omitempty"`               // 组合模式各资产持仓时间序列
+	Rankings             []RankingSnapshot     `json:"rankings,omitempty"`               // 截面策略每次调仓的资产排名
+	Pairs                *PairsAnalysis        `json:"pairs,omitempty"`                  // 配对交易价差分析
+	WeightHistory        []WeightSnapshot      `json:"weight_history,omitempty"`         // 配置策略每次调仓的目标权重
+	DrawdownEpisodes     []DrawdownEpisode     `json:"drawdown_episodes,omitempty"`      // 按深度排序的最大回撤区间
+	ExecutionTiming      ExecutionTiming       `json:"execution_timing"`                 // 实际使用的成交时点
+	CreatedAt            time.Time             `json:"created_at"`
+	Duration             time.Duration         `json:"duration"`
+}
+
+// AssetHoldings is the daily holdings time series of one asset in a portfolio backtest
+type AssetHoldings struct {
+	AssetID string         `json:"asset_id"`
+	Series  []HoldingPoint `json:"series"`
+}
+
+// HoldingPoint is an asset's holding at the close of a day
+type HoldingPoint struct {
+	Date        time.Time `json:"date"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"` // 收盘价 (休市沿用前收盘价)
+	MarketValue float64   `json:"market_value"`
+	Weight      float64   `json:"weight"`   // 占组合价值比例
+	Tradable    bool      `json:"tradable"` // 当日是否开市
+}
+
+// RankingSnapshot is the cross-sectional ranking of the universe at a rebalance
+type RankingSnapshot struct {
+	Date  time.Time   `json:"date"`
+	Ranks []AssetRank `json:"ranks"` // 按得分从高到低
+}
+
+// AssetRank is one asset's place in a ranking
+type AssetRank struct {
+	AssetID    string             `json:"asset_id"`
+	Rank       int                `json:"rank"` // 1 = 最高
+	Score      float64            `json:"score"`
+	Return     float64            `json:"return,omitempty"`     // 回望期收益
+	Volatility float64            `json:"volatility,omitempty"` // 回望期年化波动率
+	Factors    map[string]float64 `json:"factors,omitempty"`    // 各因子截面Z值
+	Selected   bool               `json:"selected"`             // 是否入选持仓
+}
+
+// WeightSnapshot records the target weights an allocation strategy set at a
+// rebalance, with the ex-ante risk they carry
+type WeightSnapshot struct {
+	Date              time.Time          `json:"date"`
+	Weights           map[string]float64 `json:"weights"`            // 目标权重
+	RiskContributions map[string]float64 `json:"risk_contributions"` // 各资产风险贡献占比
+	Volatility        float64            `json:"volatility"`         // 事前年化波动率
+}
+
+// PairsAnalysis describes the spread traded by a pairs strategy. The spread is
+// y - hedge_ratio * x - intercept, in log prices unless configured otherwise.
+type PairsAnalysis struct {
+	AssetY     string       `json:"asset_y"`     // 第一腿 (做多价差时买入)
+	AssetX     string       `json:"asset_x"`     // 对冲腿
+	HedgeRatio float64      `json:"hedge_ratio"` // 全样本对冲比例
+	Intercept  float64      `json:"intercept"`   // 全样本截距
+	HalfLife   float64      `json:"half_life"`   // 全样本价差均值回归半衰期(交易日), 0 = 不回归
+	Series     []PairsPoint `json:"series"`      // 滚动估计序列
+}
+
+// PairsPoint is the rolling spread estimate on one day
+type PairsPoint struct {
+	Date       time.Time `json:"date"`
+	Spread     float64   `json:"spread"`
+	ZScore     float64   `json:"z_score"`
+	HedgeRatio float64   `json:"hedge_ratio"`
+	HalfLife   float64   `json:"half_life"` // 滚动窗口半衰期, 0 = 不回归
+	Position   int       `json:"position"`  // 价差持仓: 1 = 做多, -1 = 做空, 0 = 空仓
+}
+
+// CircuitBreakerEvent records a portfolio drawdown circuit breaker trip
+type CircuitBreakerEvent struct {
+	TripDate       time.Time  `json:"trip_date"`             // 熔断日期
+	Drawdown       float64    `json:"drawdown"`              // 触发时回撤
+	PortfolioValue float64    `json:"portfolio_value"`       // 触发时组合价值
+	ResumeDate     *time.Time `json:"resume_date,omitempty"` // 恢复交易日期 (空 = 未恢复)
+}
+
+// DrawdownEpisode is one fall of the time-weighted wealth index below its
+// running peak, from the peak to the day it regains it. Durations count
+// trading days on the backtest timeline, not calendar days.
+type DrawdownEpisode struct {
+	PeakDate     time.Time  `json:"peak_date"`               // 回撤前高点日期
+	TroughDate   time.Time  `json:"trough_date"`             // 谷底日期
+	RecoveryDate *time.Time `json:"recovery_date,omitempty"` // 收复高点日期 (空 = 未恢复)
+	Depth        float64    `json:"depth"`                   // 最大回撤幅度
+	DeclineDays  int        `json:"decline_days"`            // 高点至谷底交易日数
+	RecoveryDays int        `json:"recovery_days"`           // 谷底至收复交易日数 (未恢复时为 0)
+	TotalDays    int        `json:"total_days"`              // 高点至收复交易日数 (未恢复时至回测结束)
 }
 
 // DailyReturn represents daily portfolio value and returns
 type DailyReturn struct {
-	Date             time.Time `json:"date"`
-	PortfolioValue   float64   `json:"portfolio_value"`
-	DailyReturn      float64   `json:"daily_return"`
-	CumulativeReturn float64   `json:"cumulative_return"`
-	Drawdown         float64   `json:"drawdown"`
-	Cash             float64   `json:"cash"`
-	Position         Position  `json:"position"`
+	Date               time.Time           `json:"date"`
+	PortfolioValue     float64             `json:"portfolio_value"`
+	DailyReturn        float64             `json:"daily_return"`
+	CumulativeReturn   float64             `json:"cumulative_return"`
+	Drawdown           float64             `json:"drawdown"`
+	Cash               float64             `json:"cash"`
+	Position           Position            `json:"position"`            // 单资产持仓 (组合模式为空)
+	Positions          map[string]Position `json:"positions,omitempty"` // 组合模式各资产持仓
+	CashFlow           float64             `json:"cash_flow,omitempty"` // 当日外部资金流入
+	ContributedCapital float64             `json:"contributed_capital"` // 累计投入本金
+	GrossExposure      float64             `json:"gross_exposure"`      // 总敞口 (多空绝对值之和 / 组合价值)
+	NetExposure        float64             `json:"net_exposure"`        // 净敞口 ((多头 - 空头) / 组合价值)
+	Dividends          float64             `json:"dividends,omitempty"` // 当日分红现金 (空头为支付)
 }
 
 // DataSourceConfig represents data source configuration
@@ -274,15 +635,23 @@ type DataSourceConfig struct {
 
 // MultiStrategyBacktestRequest represents a request to compare multiple strategies
 type MultiStrategyBacktestRequest struct {
-	AssetID       string                 `json:"asset_id"`
-	Strategies    []StrategyConfig       `json:"strategies"` // 多个策略配置
-	StartDate     time.Time              `json:"start_date"`
-	EndDate       time.Time              `json:"end_date"`
-	InitialCash   float64                `json:"initial_cash"`
-	Benchmark     string                 `json:"benchmark,omitempty"`      // 基准指数
-	DataSource    string                 `json:"data_source,omitempty"`    // 数据源
-	ComparisonOpt *ComparisonOptions     `json:"comparison_opt,omitempty"` // 对比选项
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	AssetID         string                 `json:"asset_id"`
+	Strategies      []StrategyConfig       `json:"strategies"` // 多个策略配置
+	StartDate       time.Time              `json:"start_date"`
+	EndDate         time.Time              `json:"end_date"`
+	InitialCash     float64                `json:"initial_cash"`
+	Benchmark       string                 `json:"benchmark,omitempty"`        // 基准指数
+	DataSource      string                 `json:"data_source,omitempty"`      // 数据源
+	Costs           *CostConfig            `json:"costs,omitempty"`            // 交易成本配置 (覆盖市场默认)
+	ExecutionTiming ExecutionTiming        `json:"execution_timing,omitempty"` // 成交时点, 默认当根收盘
+	Margin          *MarginConfig          `json:"margin,omitempty"`           // 保证金账户 (卖空/融资)
+	PriceAdjustment PriceAdjustment        `json:"price_adjustment,omitempty"` // 价格复权方式
+	BaseCurrency    Currency               `json:"base_currency,omitempty"`    // 报告货币, 默认为资产的计价货币
+	RiskFree        *RiskFreeConfig        `json:"risk_free,omitempty"`        // 无风险利率, 默认固定 3%
+	VaRConfidence   float64                `json:"var_confidence,omitempty"`   // VaR/CVaR 置信水平, 默认 0.95
+	DrawdownTopN    int                    `json:"drawdown_top_n,omitempty"`   // 返回最深的 N 个回撤区间, 默认 10
+	ComparisonOpt   *ComparisonOptions     `json:"comparison_opt,omitempty"`   // 对比选项
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // ComparisonOptions represents options for strategy comparison
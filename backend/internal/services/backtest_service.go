@@ -51,8 +51,16 @@ func (bs *BacktestService) GetMarketData(indexID string, startDate, endDate time
 	return marketData, nil
 }
 
-// RunBacktest executes a backtest and returns the results
+// RunBacktest executes a backtest and returns the results. Requests with
+// AssetIDs run in portfolio mode over all listed assets.
 func (bs *BacktestService) RunBacktest(request models.BacktestRequest) (*models.BacktestResult, error) {
+	if err := bs.dataManager.LoadRiskFreeRates(request.RiskFree, request.StartDate, request.EndDate); err != nil {
+		return nil, err
+	}
+	if len(request.AssetIDs) > 0 {
+		return bs.runPortfolioBacktest(request)
+	}
+
 	// Support both AssetID and IndexID for backward compatibility
 	assetID := request.AssetID
 	if assetID == "" {
@@ -70,12 +78,18 @@ func (bs *BacktestService) RunBacktest(request models.BacktestRequest) (*models.
 	if err != nil {
 		return nil, fmt.Errorf("failed to get market data: %w", err)
 	}
+	if err := bs.dataManager.AttachFXRates(marketData, request.BaseCurrency, request.StartDate, request.EndDate); err != nil {
+		return nil, fmt.Errorf("failed to get FX rates: %w", err)
+	}
 
 	// Run the backtest
 	result, err := bs.backtestEngine.RunBacktest(request, marketData)
 	if err != nil {
 		return nil, fmt.Errorf("backtest execution failed: %w", err)
 	}
+	if err := bs.compareToBenchmark(request, result); err != nil {
+		return nil, err
+	}
 
 	// Cache the result
 	bs.cacheMutex.Lock()
@@ -85,6 +99,61 @@ func (bs *BacktestService) RunBacktest(request models.BacktestRequest) (*models.
 	return result, nil
 }
 
+// runPortfolioBacktest fetches the market data of every asset and runs a
+// portfolio backtest over them. Assets quoted outside the base currency, by
+// default the first asset's, come with the FX rates converting them.
+func (bs *BacktestService) runPortfolioBacktest(request models.BacktestRequest) (*models.BacktestResult, error) {
+	base := request.BaseCurrency
+	marketData := make([]*models.MarketData, 0, len(request.AssetIDs))
+	for _, assetID := range request.AssetIDs {
+		index := models.GetIndexByID(assetID)
+		if index == nil {
+			return nil, fmt.Errorf("asset not found: %s", assetID)
+		}
+		if base == "" {
+			base = index.Currency
+		}
+
+		md, err := bs.dataManager.GetMarketData(index, request.StartDate, request.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get market data for %s: %w", assetID, err)
+		}
+		if err := bs.dataManager.AttachFXRates(md, base, request.StartDate, request.EndDate); err != nil {
+			return nil, fmt.Errorf("failed to get FX rates for %s: %w", assetID, err)
+		}
+		marketData = append(marketData, md)
+	}
+
+	result, err := bs.backtestEngine.RunPortfolioBacktest(request, marketData)
+	if err != nil {
+		return nil, fmt.Errorf("backtest execution failed: %w", err)
+	}
+	if err := bs.compareToBenchmark(request, result); err != nil {
+		return nil, err
+	}
+
+	bs.cacheMutex.Lock()
+	bs.resultCache[result.ID] = result
+	bs.cacheMutex.Unlock()
+
+	return result, nil
+}
+
+// compareToBenchmark runs buy and hold on the request's benchmark, if any, and
+// adds the result's benchmark-relative metrics. A benchmark that cannot be
+// run fails the backtest, which was asked to be measured against it.
+func (bs *BacktestService) compareToBenchmark(request models.BacktestRequest, result *models.BacktestResult) error {
+	if request.Benchmark == "" {
+		return nil
+	}
+	benchmark, err := runBenchmarkBacktest(bs.dataManager, bs.backtestEngine, request.Benchmark, request.StartDate, request.EndDate, request.InitialCash, result.BaseCurrency, request.RiskFree)
+	if err != nil {
+		return fmt.Errorf("failed to run benchmark %s: %w", request.Benchmark, err)
+	}
+	bs.backtestEngine.CompareToBenchmark(result, benchmark, request.Benchmark)
+	return nil
+}
+
 // GetBacktestResult retrieves a backtest result by ID
 func (bs *BacktestService) GetBacktestResult(backtestID string) (*models.BacktestResult, error) {
 	bs.cacheMutex.RLock()
@@ -121,15 +190,24 @@ func (bs *BacktestService) ClearCache() {
 // ValidateBacktestRequest validates a backtest request
 func (bs *BacktestService) ValidateBacktestRequest(request models.BacktestRequest) error {
 	// Support both AssetID and IndexID for backward compatibility
-	assetID := request.AssetID
-	if assetID == "" {
-		assetID = request.IndexID
+	assetIDs := request.AssetIDs
+	if len(assetIDs) == 0 {
+		assetID := request.AssetID
+		if assetID == "" {
+			assetID = request.IndexID
+		}
+		assetIDs = []string{assetID}
 	}
 
-	// Check if asset exists
-	index := models.GetIndexByID(assetID)
-	if index == nil {
-		return fmt.Errorf("invalid asset ID: %s", assetID)
+	// Check if the assets exist
+	for _, assetID := range assetIDs {
+		if models.GetIndexByID(assetID) == nil {
+			return fmt.Errorf("invalid asset ID: %s", assetID)
+		}
+	}
+
+	if request.Benchmark != "" && models.GetIndexByID(request.Benchmark) == nil {
+		return fmt.Errorf("invalid benchmark: %s", request.Benchmark)
 	}
 
 	// Validate dates
@@ -153,58 +231,9 @@ func (bs *BacktestService) ValidateBacktestRequest(request models.BacktestReques
 		return fmt.Errorf("initial cash must be positive")
 	}
 
-	// Validate strategy
-	if request.Strategy.Type == "" {
-		return fmt.Errorf("strategy type is required")
-	}
-
-	// Strategy-specific validations
-	switch request.Strategy.Type {
-	case models.StrategyTypeMonthlyRotation:
-		if err := bs.validateMonthlyRotationStrategy(request.Strategy); err != nil {
-			return fmt.Errorf("invalid monthly rotation strategy: %w", err)
-		}
-	default:
-		return fmt.Errorf("unsupported strategy type: %s", request.Strategy.Type)
-	}
-
-	return nil
-}
-
-// validateMonthlyRotationStrategy validates monthly rotation strategy parameters
-func (bs *BacktestService) validateMonthlyRotationStrategy(strategy models.StrategyConfig) error {
-	if strategy.Parameters == nil {
-		return fmt.Errorf("strategy parameters are required")
-	}
-
-	// Check buy days parameter
-	buyDaysRaw, ok := strategy.Parameters["buy_days_before_month_end"]
-	if !ok {
-		return fmt.Errorf("buy_days_before_month_end parameter is required")
-	}
-
-	buyDays, ok := buyDaysRaw.(float64)
-	if !ok {
-		return fmt.Errorf("buy_days_before_month_end must be a number")
-	}
-
-	if buyDays < 1 || buyDays > 20 {
-		return fmt.Errorf("buy_days_before_month_end must be between 1 and 20")
-	}
-
-	// Check sell days parameter
-	sellDaysRaw, ok := strategy.Parameters["sell_days_after_month_start"]
-	if !ok {
-		return fmt.Errorf("sell_days_after_month_start parameter is required")
-	}
-
-	sellDays, ok := sellDaysRaw.(float64)
-	if !ok {
-		return fmt.Errorf("sell_days_after_month_start must be a number")
-	}
-
-	if sellDays < 1 || sellDays > 20 {
-		return fmt.Errorf("sell_days_after_month_start must be between 1 and 20")
+	// Validate strategy against the registered strategy catalog
+	if err := backtesting.ValidateStrategyConfig(request.Strategy); err != nil {
+		return fmt.Errorf("invalid %s strategy: %w", request.Strategy.Type, err)
 	}
 
 	return nil
@@ -239,51 +268,19 @@ func (bs *BacktestService) GetMultiStrategyResult(backtestID string) (*models.Mu
 	return result, nil
 }
 
-// GetSupportedStrategies returns a list of supported strategy types with their parameters
+// GetSupportedStrategies returns a list of supported strategy types with their parameters.
+// The catalog is built from the engine's strategy registry so it always matches what can run.
 func (bs *BacktestService) GetSupportedStrategies() map[string]interface{} {
-	return map[string]interface{}{
-		"monthly_rotation": map[string]interface{}{
-			"name":        "Monthly Rotation Strategy",
-			"description": "Buy before month-end, sell after month-start",
-			"parameters": map[string]interface{}{
-				"buy_days_before_month_end": map[string]interface{}{
-					"type":        "integer",
-					"default":     1,
-					"range":       []int{1, 20},
-					"description": "Number of days before month-end to buy",
-				},
-				"sell_days_after_month_start": map[string]interface{}{
-					"type":        "integer",
-					"default":     1,
-					"range":       []int{1, 20},
-					"description": "Number of days after month-start to sell",
-				},
-			},
-		},
-		"buy_and_hold": map[string]interface{}{
-			"name":        "Buy and Hold Strategy",
-			"description": "Buy and hold with optional rebalancing",
-			"parameters": map[string]interface{}{
-				"target_allocation": map[string]interface{}{
-					"type":        "float",
-					"default":     1.0,
-					"range":       []float64{0.1, 1.0},
-					"description": "Target allocation percentage (0.1 = 10%, 1.0 = 100%)",
-				},
-				"rebalance_frequency": map[string]interface{}{
-					"type":        "string",
-					"default":     "never",
-					"options":     []string{"never", "monthly", "quarterly", "yearly"},
-					"description": "How often to rebalance the portfolio",
-				},
-				"dividend_reinvest": map[string]interface{}{
-					"type":        "boolean",
-					"default":     false,
-					"description": "Whether to reinvest dividends (future feature)",
-				},
-			},
-		},
+	strategies := make(map[string]interface{})
+	for _, def := range backtesting.SupportedStrategies() {
+		strategies[string(def.Type)] = map[string]interface{}{
+			"name":        def.Name,
+			"description": def.Description,
+			"parameters":  def.Parameters,
+			"multi_asset": def.MultiAsset,
+		}
 	}
+	return strategies
 }
 
 // GetSupportedMarkets returns a list of supported markets with their assets
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"macro_strategy/internal/backtesting"
+	"macro_strategy/internal/data"
+	"macro_strategy/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newMockService returns a backtest service on simulated data for every
+// market, without FX rates
+func newMockService() *BacktestService {
+	dataManager := data.NewDataSourceManager()
+	mock := data.NewMockDataProvider()
+	for _, marketType := range []models.MarketType{
+		models.MarketTypeAShareIndex, models.MarketTypeAShareStock, models.MarketTypeUSIndex,
+		models.MarketTypeUSStock, models.MarketTypeETF, models.MarketTypeHKIndex,
+		models.MarketTypeHKStock, models.MarketTypeCrypto, models.MarketTypeFX,
+	} {
+		dataManager.RegisterProvider(marketType, mock)
+	}
+	return NewBacktestService(dataManager, backtesting.NewBacktestEngine())
+}
+
+func buyAndHoldRequest(assetID, benchmark string) models.BacktestRequest {
+	return models.BacktestRequest{
+		IndexID: assetID,
+		Strategy: models.StrategyConfig{
+			Type:       models.StrategyTypeBuyAndHold,
+			Parameters: map[string]interface{}{"target_allocation": 1.0, "rebalance_frequency": "never"},
+		},
+		StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+		InitialCash: 100000,
+		Benchmark:   benchmark,
+	}
+}
+
+func TestValidateBacktestRequestBenchmark(t *testing.T) {
+	bs := newMockService()
+	if err := bs.ValidateBacktestRequest(buyAndHoldRequest("spy", "qqq")); err != nil {
+		t.Errorf("valid benchmark rejected: %v", err)
+	}
+	if err := bs.ValidateBacktestRequest(buyAndHoldRequest("spy", "")); err != nil {
+		t.Errorf("request without a benchmark rejected: %v", err)
+	}
+	if err := bs.ValidateBacktestRequest(buyAndHoldRequest("spy", "nope")); err == nil {
+		t.Error("unknown benchmark accepted")
+	}
+}
+
+func TestRunBacktestBenchmark(t *testing.T) {
+	tests := []struct {
+		name      string
+		benchmark string
+		wantErr   string
+	}{
+		{"same currency", "qqq", ""},
+		{"unknown benchmark", "nope", "benchmark not found"},
+		{"benchmark without FX rates", "csi300", "failed to run benchmark csi300"},
+	}
+
+	bs := newMockService()
+	for _, tt := range tests {
+		result, err := bs.RunBacktest(buyAndHoldRequest("spy", tt.benchmark))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if result.PerformanceMetrics.Relative == nil || result.PerformanceMetrics.Relative.Benchmark != tt.benchmark {
+			t.Errorf("%s: relative metrics = %+v, want them against %s", tt.name, result.PerformanceMetrics.Relative, tt.benchmark)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	"macro_strategy/internal/backtesting"
+	"macro_strategy/internal/data"
+	"macro_strategy/internal/models"
+	"time"
+)
+
+// runBenchmarkBacktest runs buy and hold on the benchmark asset over a
+// backtest's period, valued in the backtest's base currency and measured
+// against its risk-free rate
+func runBenchmarkBacktest(dataManager *data.DataSourceManager, backtestEngine *backtesting.BacktestEngine, benchmarkID string, startDate, endDate time.Time, initialCash float64, baseCurrency models.Currency, riskFree *models.RiskFreeConfig) (*models.BacktestResult, error) {
+	benchmarkAsset := models.GetIndexByID(benchmarkID)
+	if benchmarkAsset == nil {
+		return nil, fmt.Errorf("benchmark not found: %s", benchmarkID)
+	}
+
+	benchmarkData, err := dataManager.GetMarketData(benchmarkAsset, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := dataManager.AttachFXRates(benchmarkData, baseCurrency, startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	// Create a simple buy-and-hold strategy for benchmark
+	benchmarkStrategy := models.StrategyConfig{
+		Type: models.StrategyTypeBuyAndHold,
+		Parameters: map[string]interface{}{
+			"target_allocation":   1.0,
+			"rebalance_frequency": "never",
+		},
+		Description: fmt.Sprintf("Benchmark: %s", benchmarkID),
+	}
+
+	benchmarkRequest := models.BacktestRequest{
+		AssetID:      benchmarkID,
+		IndexID:      benchmarkID,
+		Strategy:     benchmarkStrategy,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		InitialCash:  initialCash,
+		BaseCurrency: baseCurrency,
+		RiskFree:     riskFree,
+		Metadata: map[string]interface{}{
+			"is_benchmark": true,
+		},
+	}
+
+	return backtestEngine.RunBacktest(benchmarkRequest, benchmarkData)
+}
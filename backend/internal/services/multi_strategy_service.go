@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"log"
 	"macro_strategy/internal/backtesting"
 	"macro_strategy/internal/data"
 	"macro_strategy/internal/models"
@@ -33,6 +34,10 @@ func (mss *MultiStrategyService) RunMultiStrategyBacktest(request models.MultiSt
 		return nil, fmt.Errorf("invalid multi-strategy request: %w", err)
 	}
 
+	if err := mss.dataManager.LoadRiskFreeRates(request.RiskFree, request.StartDate, request.EndDate); err != nil {
+		return nil, err
+	}
+
 	// Get asset information
 	asset := models.GetIndexByID(request.AssetID)
 	if asset == nil {
@@ -45,19 +50,37 @@ func (mss *MultiStrategyService) RunMultiStrategyBacktest(request models.MultiSt
 		return nil, fmt.Errorf("failed to fetch market data: %w", err)
 	}
 
+	// Strategies and benchmark all report in the base currency, so a
+	// benchmark quoted in another currency is converted before comparison
+	baseCurrency := request.BaseCurrency
+	if baseCurrency == "" {
+		baseCurrency = asset.Currency
+	}
+	if err := mss.dataManager.AttachFXRates(marketData, baseCurrency, request.StartDate, request.EndDate); err != nil {
+		return nil, fmt.Errorf("failed to fetch FX rates: %w", err)
+	}
+
 	// Run backtests for each strategy
 	var results []models.BacktestResult
 	for i, strategy := range request.Strategies {
 		// Create individual backtest request
 		backtestRequest := models.BacktestRequest{
-			AssetID:     request.AssetID,
-			IndexID:     request.AssetID, // For backward compatibility
-			Strategy:    strategy,
-			StartDate:   request.StartDate,
-			EndDate:     request.EndDate,
-			InitialCash: request.InitialCash,
-			Benchmark:   request.Benchmark,
-			DataSource:  request.DataSource,
+			AssetID:         request.AssetID,
+			IndexID:         request.AssetID, // For backward compatibility
+			Strategy:        strategy,
+			StartDate:       request.StartDate,
+			EndDate:         request.EndDate,
+			InitialCash:     request.InitialCash,
+			Benchmark:       request.Benchmark,
+			DataSource:      request.DataSource,
+			Costs:           request.Costs,
+			ExecutionTiming: request.ExecutionTiming,
+			Margin:          request.Margin,
+			PriceAdjustment: request.PriceAdjustment,
+			BaseCurrency:    baseCurrency,
+			RiskFree:        request.RiskFree,
+			VaRConfidence:   request.VaRConfidence,
+			DrawdownTopN:    request.DrawdownTopN,
 			Metadata: map[string]interface{}{
 				"strategy_index": i,
 				"strategy_name":  fmt.Sprintf("%s_%d", strategy.Type, i+1),
@@ -73,39 +96,18 @@ func (mss *MultiStrategyService) RunMultiStrategyBacktest(request models.MultiSt
 		results = append(results, *result)
 	}
 
-	// Run benchmark backtest if specified
+	// Run benchmark backtest if specified and compare every strategy to it. A
+	// benchmark that cannot be run leaves the strategies compared on absolute
+	// metrics only.
 	var benchmarkResult *models.BacktestResult
 	if request.Benchmark != "" && request.Benchmark != request.AssetID {
-		benchmarkAsset := models.GetIndexByID(request.Benchmark)
-		if benchmarkAsset != nil {
-			benchmarkData, err := mss.dataManager.GetMarketData(benchmarkAsset, request.StartDate, request.EndDate)
-			if err == nil {
-				// Create a simple buy-and-hold strategy for benchmark
-				benchmarkStrategy := models.StrategyConfig{
-					Type: models.StrategyTypeBuyAndHold,
-					Parameters: map[string]interface{}{
-						"target_allocation":   1.0,
-						"rebalance_frequency": "never",
-					},
-					Description: fmt.Sprintf("Benchmark: %s", request.Benchmark),
-				}
-
-				benchmarkRequest := models.BacktestRequest{
-					AssetID:     request.Benchmark,
-					IndexID:     request.Benchmark,
-					Strategy:    benchmarkStrategy,
-					StartDate:   request.StartDate,
-					EndDate:     request.EndDate,
-					InitialCash: request.InitialCash,
-					Metadata: map[string]interface{}{
-						"is_benchmark": true,
-					},
-				}
-
-				benchmarkRes, err := mss.backtestEngine.RunBacktest(benchmarkRequest, benchmarkData)
-				if err == nil {
-					benchmarkResult = benchmarkRes
-				}
+		benchmarkRes, err := runBenchmarkBacktest(mss.dataManager, mss.backtestEngine, request.Benchmark, request.StartDate, request.EndDate, request.InitialCash, baseCurrency, request.RiskFree)
+		if err != nil {
+			log.Printf("benchmark %s unavailable, comparing strategies without it: %v", request.Benchmark, err)
+		} else {
+			benchmarkResult = benchmarkRes
+			for i := range results {
+				mss.backtestEngine.CompareToBenchmark(&results[i], benchmarkResult, request.Benchmark)
 			}
 		}
 	}
@@ -182,6 +184,9 @@ func (mss *MultiStrategyService) performStrategyComparison(results []models.Back
 	// Extract metrics for comparison
 	metrics := []string{"total_return", "annualized_return", "sharpe_ratio", "sortino_ratio",
 		"max_drawdown", "volatility", "win_rate", "profit_factor", "calmar_ratio"}
+	if len(results) > 0 && results[0].PerformanceMetrics.Relative != nil {
+		metrics = append(metrics, "alpha", "beta", "tracking_error", "information_ratio", "excess_return")
+	}
 
 	for _, metric := range metrics {
 		var values []float64
@@ -191,8 +196,13 @@ func (mss *MultiStrategyService) performStrategyComparison(results []models.Back
 		}
 		comparison.MetricsComparison[metric] = values
 
+		// Beta measures market exposure, which is neither better nor worse
+		if metric == "beta" {
+			continue
+		}
+
 		// Calculate rankings (higher is better for most metrics, except max_drawdown)
-		rankings := mss.calculateRankings(values, metric == "max_drawdown" || metric == "volatility")
+		rankings := mss.calculateRankings(values, metric == "max_drawdown" || metric == "volatility" || metric == "tracking_error")
 		comparison.Rankings[metric] = rankings
 	}
 
@@ -239,6 +249,23 @@ func (mss *MultiStrategyService) extractMetricValue(metrics models.PerformanceMe
 		return metrics.ProfitFactor
 	case "calmar_ratio":
 		return metrics.CalmarRatio
+	}
+
+	// Benchmark-relative metrics
+	if metrics.Relative == nil {
+		return 0.0
+	}
+	switch metricName {
+	case "alpha":
+		return metrics.Relative.Alpha
+	case "beta":
+		return metrics.Relative.Beta
+	case "tracking_error":
+		return metrics.Relative.TrackingError
+	case "information_ratio":
+		return metrics.Relative.InformationRatio
+	case "excess_return":
+		return metrics.Relative.ExcessReturn
 	default:
 		return 0.0
 	}
@@ -112,6 +112,40 @@ def get_stock_zh_index_daily(symbol, start_date, end_date):
         print(json.dumps(error_data, ensure_ascii=False))
         sys.exit(1)
 
+def get_shibor(indicator, start_date, end_date):
+    """
+    获取 SHIBOR 利率历史数据
+    
+    Args:
+        indicator: 期限（如：隔夜、1周、3月）
+        start_date: 开始日期（格式：20200101）
+        end_date: 结束日期（格式：20231231）
+    
+    Returns:
+        JSON 格式的利率数据, 利率单位为百分比
+    """
+    try:
+        df = ak.rate_interbank(market="上海银行同业拆借市场", symbol="Shibor人民币", indicator=indicator)
+        
+        # 过滤日期范围
+        if not df.empty:
+            df['报告日'] = pd.to_datetime(df['报告日'])
+            start_dt = datetime.strptime(start_date, '%Y%m%d')
+            end_dt = datetime.strptime(end_date, '%Y%m%d')
+            df = df[(df['报告日'] >= start_dt) & (df['报告日'] <= end_dt)]
+        
+        df = df.rename(columns={'报告日': '日期'})
+        if '日期' in df.columns:
+            df['日期'] = df['日期'].dt.strftime('%Y-%m-%d')
+        
+        data = df[['日期', '利率']].to_dict('records')
+        print(json.dumps(data, ensure_ascii=False, default=str))
+        
+    except Exception as e:
+        error_data = {"error": str(e)}
+        print(json.dumps(error_data, ensure_ascii=False))
+        sys.exit(1)
+
 def get_stock_info(symbol):
     """
     获取股票基本信息
@@ -189,6 +223,16 @@ def main():
         symbol = sys.argv[2]
         get_stock_info(symbol)
         
+    elif command == "get_shibor":
+        if len(sys.argv) != 5:
+            print(json.dumps({"error": "参数不正确：需要 indicator, start_date, end_date"}, ensure_ascii=False))
+            sys.exit(1)
+        
+        indicator = sys.argv[2]
+        start_date = sys.argv[3]
+        end_date = sys.argv[4]
+        get_shibor(indicator, start_date, end_date)
+        
     elif command == "get_index_list":
         get_index_list()
         
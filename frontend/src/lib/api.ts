@@ -6,7 +6,8 @@ import {
   MarketType,
   ApiResponse,
   MultiStrategyBacktestRequest,
-  MultiStrategyBacktestResult
+  MultiStrategyBacktestResult,
+  TradingCalendar
 } from '@/types';
 
 // Create axios instance with default config
@@ -56,6 +57,16 @@ export const indexService = {
   }
 };
 
+// Calendar service
+export const calendarService = {
+  async getTradingCalendar(marketType: MarketType, startDate?: string, endDate?: string): Promise<TradingCalendar> {
+    const response = await apiClient.get<ApiResponse<TradingCalendar>>(`/api/v1/calendar/${marketType}`, {
+      params: { start_date: startDate, end_date: endDate },
+    });
+    return response.data.data;
+  }
+};
+
 // Backtest service
 export const backtestService = {
   async runBacktest(request: BacktestRequest): Promise<BacktestResult> {
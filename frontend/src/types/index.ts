@@ -5,7 +5,7 @@ export type MarketType = 'a_share' | 'crypto' | 'hk_us' | 'etf' | 'bond' | 'futu
 export type AssetClass = 'equity' | 'crypto' | 'commodity' | 'bond' | 'derivative' | 'index';
 
 // Currencies
-export type Currency = 'CNY' | 'USD' | 'HKD' | 'BTC' | 'ETH';
+export type Currency = 'CNY' | 'USD' | 'HKD' | 'BTC' | 'ETH' | 'USDT';
 
 // Trading Hours
 export interface TradingHours {
@@ -15,6 +15,32 @@ export interface TradingHours {
   weekend_days: number[];
 }
 
+// Order quantity and price constraints
+export interface TradingRules {
+  lot_size: number;       // 最小交易单位
+  min_quantity?: number;  // 最小下单数量
+  tick_size?: number;     // 最小价格变动单位
+  min_notional?: number;  // 最小下单金额
+}
+
+// Exchange holiday
+export interface Holiday {
+  date: string;
+  name: string;
+}
+
+// Exchange trading calendar for a date range
+export interface TradingCalendar {
+  market_type: MarketType;
+  timezone: string;
+  weekend_days: number[];
+  start_date: string;
+  end_date: string;
+  holidays: Holiday[];
+  trading_days: string[];
+  trading_day_count: number;
+}
+
 // Index interface with enhanced metadata
 export interface Index {
   id: string;
@@ -25,6 +51,7 @@ export interface Index {
   currency: Currency;
   description: string;
   trading_hours?: TradingHours;
+  trading_rules?: TradingRules;
   metadata?: Record<string, unknown>;
 }
 
@@ -39,6 +66,21 @@ export interface OHLCV {
   amount?: number;   // 成交额
   turnover?: number; // 换手率
   pct_chg?: number;  // 涨跌幅
+  adj_close?: number; // 复权收盘价
+}
+
+// Dividend or split taking effect on its ex-date
+export interface CorporateAction {
+  date: string;      // 除权除息日
+  type: 'dividend' | 'split';
+  amount?: number;   // 每股现金分红
+  ratio?: number;    // 每股拆分后股数
+}
+
+// Value of one unit of an asset's currency in the base currency
+export interface FXRate {
+  date: string;
+  rate: number;
 }
 
 // Market data with enhanced metadata
@@ -49,12 +91,16 @@ export interface MarketData {
   asset_class: AssetClass;
   currency: Currency;
   data: OHLCV[];
+  trading_rules?: TradingRules;
+  trading_hours?: TradingHours;
+  corporate_actions?: CorporateAction[];
+  fx_rates?: FXRate[]; // 资产货币兑报告货币汇率
   metadata?: Record<string, unknown>;
   last_update: string;
 }
 
 // Strategy types with enhanced options
-export type StrategyType = 'monthly_rotation' | 'buy_and_hold' | 'grid_trading' | 'mean_reversion' | 'momentum';
+export type StrategyType = 'monthly_rotation' | 'buy_and_hold' | 'grid_trading' | 'mean_reversion' | 'momentum' | 'dca' | 'breakout' | 'rebalance' | 'index_rotation' | 'pairs' | 'risk_parity' | 'min_variance' | 'multi_factor';
 
 // Risk management configuration
 export interface RiskManagementConfig {
@@ -62,10 +108,29 @@ export interface RiskManagementConfig {
   stop_loss?: number;
   take_profit?: number;
   max_drawdown?: number;
+  drawdown_cooldown?: number;
+  drawdown_reentry?: 'never' | 'after_cooldown' | 'above_trip_price';
   commission_rate?: number;
   slippage_rate?: number;
 }
 
+// Trading cost overrides (commission and slippage models)
+export interface CostConfig {
+  commission_model?: 'percentage' | 'per_share' | 'binance' | 'none';
+  commission_rate?: number;
+  per_share_fee?: number;
+  min_commission?: number;
+  stamp_duty_rate?: number;
+  maker_rate?: number;
+  taker_rate?: number;
+  order_liquidity?: 'taker' | 'maker';
+  slippage_model?: 'none' | 'fixed_bps' | 'volatility' | 'volume_participation';
+  slippage_bps?: number;
+  volatility_multiplier?: number;
+  volatility_window?: number;
+  impact_coefficient?: number;
+}
+
 // Strategy configuration with enhanced flexibility
 export interface StrategyConfig {
   type: StrategyType;
@@ -81,10 +146,44 @@ export interface MonthlyRotationParams {
   sell_days_after_month_start: number;
 }
 
+// When a signal computed on bar t is filled
+export type ExecutionTiming = 'close' | 'next_open' | 'next_close' | 'next_vwap';
+
+// Margin account settings; omitting them keeps a long-only cash account
+export interface MarginConfig {
+  allow_short?: boolean;
+  initial_margin?: number;     // 初始保证金比例，默认 0.5
+  maintenance_margin?: number; // 维持保证金比例，默认 0.3
+  borrow_rate?: number;        // 融券年化费率
+  margin_rate?: number;        // 融资年化利率
+}
+
+// Risk-free rate metrics are measured against, a fixed 3% when omitted
+export type RiskFreeSource = 'shibor_3m' | 'us_tbill_3m';
+
+export interface RiskFreeConfig {
+  rate?: number;           // 固定年化无风险利率
+  source?: RiskFreeSource; // 利率序列来源
+  rates?: InterestRate[];  // 年化利率序列, 优先于 rate
+}
+
+export interface InterestRate {
+  date: string;
+  rate: number; // 年化利率
+}
+
+// How a portfolio backtest merges the trading days of its assets
+export type DateAlignment = 'union' | 'intersection';
+
+// Raw prices with explicit dividends and splits, or the adjusted series
+export type PriceAdjustment = 'none' | 'adjusted';
+
 // Backtest request with enhanced configuration
 export interface BacktestRequest {
   asset_id?: string;  // 新字段
   index_id?: string;  // 向后兼容
+  asset_ids?: string[];           // 组合回测资产列表
+  date_alignment?: DateAlignment; // 组合模式日期对齐, 默认 union
   strategy: StrategyConfig;
   start_date: string;
   end_date: string;
@@ -92,22 +191,36 @@ export interface BacktestRequest {
   benchmark?: string;
   rebalance_freq?: string;
   data_source?: string;
+  costs?: CostConfig;
+  execution_timing?: ExecutionTiming;
+  margin?: MarginConfig;
+  price_adjustment?: PriceAdjustment; // 默认 none: 原始价格加分红拆股
+  base_currency?: Currency; // 报告货币, 默认为第一个资产的计价货币
+  risk_free?: RiskFreeConfig;
+  var_confidence?: number; // VaR/CVaR 置信水平, 默认 0.95
+  drawdown_top_n?: number; // 返回最深的 N 个回撤区间, 默认 10
   metadata?: Record<string, unknown>;
 }
 
 // Trade record
 export interface Trade {
   date: string;
-  action: 'buy' | 'sell';
+  asset_id?: string;
+  action: 'buy' | 'sell' | 'short' | 'cover';
   price: number;
   quantity: number;
   amount: number;
   commission: number;
+  slippage?: number;   // 滑点成本
+  grid_level?: number; // 网格层级
+  fx_rate?: number;    // 成交时资产货币兑报告货币汇率
+  reason?: 'signal' | 'stop_loss' | 'take_profit' | 'end_of_backtest' | 'circuit_breaker' | 'margin_call' | 'dividend_reinvest'; // 交易原因
 }
 
 // Position
 export interface Position {
-  quantity: number;
+  quantity: number; // 负数表示空头
+
   avg_price: number;
   market_value: number;
   unrealized_pl: number;
@@ -133,6 +246,40 @@ export interface PerformanceMetrics {
   max_losing_trade: number;
   max_drawdown_period: number;
   recovery_period: number;
+  contributed_capital: number;  // 累计投入本金
+  net_profit: number;           // 期末价值 - 累计投入
+  time_weighted_return: number; // 时间加权收益率
+  financing_cost?: number;      // 融券费用与融资利息合计
+  dividend_income?: number;     // 累计分红收入
+  risk_free_rate: number;       // 平均年化无风险利率
+  periods_per_year: number;     // 年化因子 (每年交易日数)
+  var_confidence: number;       // VaR/CVaR 置信水平
+  historical_var: number;       // 历史模拟法单日 VaR (损失为正)
+  historical_cvar: number;      // 历史模拟法单日 CVaR
+  parametric_var: number;       // 正态参数法单日 VaR
+  parametric_cvar: number;      // 正态参数法单日 CVaR
+  skewness: number;             // 偏度
+  kurtosis: number;             // 超额峰度
+  omega_ratio: number;
+  ulcer_index: number;          // 溃疡指数
+  tail_ratio: number;           // 95分位收益 / 5分位损失
+  longest_losing_streak: number; // 最长连续亏损天数
+  relative?: RelativeMetrics;   // 相对基准的超额表现
+}
+
+// Metrics relative to a benchmark's buy and hold
+export interface RelativeMetrics {
+  benchmark: string;
+  benchmark_return: number;      // 基准同期累计收益率
+  excess_return: number;         // 累计超额收益率
+  alpha: number;                 // 年化詹森阿尔法
+  beta: number;
+  correlation: number;
+  tracking_error: number;        // 年化跟踪误差
+  information_ratio: number;
+  up_capture: number;            // 上行捕获率
+  down_capture: number;          // 下行捕获率
+  max_relative_drawdown: number; // 超额收益最大回撤
 }
 
 // Daily return
@@ -144,6 +291,12 @@ export interface DailyReturn {
   drawdown: number;
   cash: number;
   position: Position;
+  positions?: Record<string, Position>; // 组合模式各资产持仓
+  cash_flow?: number;          // 当日外部资金流入
+  contributed_capital: number; // 累计投入本金
+  gross_exposure: number;      // 总敞口
+  net_exposure: number;        // 净敞口
+  dividends?: number;          // 当日分红现金
 }
 
 // Backtest result
@@ -153,10 +306,95 @@ export interface BacktestResult {
   trades: Trade[];
   daily_returns: DailyReturn[];
   performance_metrics: PerformanceMetrics;
+  circuit_breaker_events?: CircuitBreakerEvent[];
+  holdings?: AssetHoldings[];
+  rankings?: RankingSnapshot[]; // 截面策略每次调仓的排名
+  pairs?: PairsAnalysis;        // 配对交易价差分析
+  weight_history?: WeightSnapshot[]; // 配置策略每次调仓的目标权重
+  drawdown_episodes?: DrawdownEpisode[]; // 按深度排序的最大回撤区间
+  execution_timing: ExecutionTiming;
+  base_currency?: Currency; // 组合价值与指标的计价货币
   created_at: string;
   duration: number;
 }
 
+// One fall of the wealth index from a peak to its recovery, for shading the underwater chart
+export interface DrawdownEpisode {
+  peak_date: string;
+  trough_date: string;
+  recovery_date?: string; // 未恢复时为空
+  depth: number;
+  decline_days: number;   // 高点至谷底交易日数
+  recovery_days: number;  // 谷底至收复交易日数, 未恢复时为 0
+  total_days: number;     // 高点至收复交易日数, 未恢复时至回测结束
+}
+
+// Daily holdings of one asset in a portfolio backtest
+export interface AssetHoldings {
+  asset_id: string;
+  series: HoldingPoint[];
+}
+
+export interface HoldingPoint {
+  date: string;
+  quantity: number;
+  price: number;
+  market_value: number;
+  weight: number;
+  tradable: boolean;
+}
+
+// Cross-sectional ranking of the universe at a rebalance, best first
+export interface RankingSnapshot {
+  date: string;
+  ranks: AssetRank[];
+}
+
+export interface AssetRank {
+  asset_id: string;
+  rank: number;
+  score: number;
+  return?: number;
+  volatility?: number;
+  factors?: Record<string, number>; // 各因子截面Z值
+  selected: boolean;
+}
+
+// Target weights set by an allocation strategy at a rebalance
+export interface WeightSnapshot {
+  date: string;
+  weights: Record<string, number>;
+  risk_contributions: Record<string, number>; // 各资产风险贡献占比
+  volatility: number;                         // 事前年化波动率
+}
+
+// Spread traded by a pairs strategy: y - hedge_ratio * x - intercept
+export interface PairsAnalysis {
+  asset_y: string;
+  asset_x: string;
+  hedge_ratio: number;
+  intercept: number;
+  half_life: number; // 均值回归半衰期(交易日), 0 = 不回归
+  series: PairsPoint[];
+}
+
+export interface PairsPoint {
+  date: string;
+  spread: number;
+  z_score: number;
+  hedge_ratio: number;
+  half_life: number;
+  position: number; // 1 = 做多价差, -1 = 做空价差, 0 = 空仓
+}
+
+// Drawdown circuit breaker trip
+export interface CircuitBreakerEvent {
+  trip_date: string;
+  drawdown: number;
+  portfolio_value: number;
+  resume_date?: string;
+}
+
 // Multi-strategy backtest request
 export interface MultiStrategyBacktestRequest {
   asset_id: string;
@@ -166,6 +404,14 @@ export interface MultiStrategyBacktestRequest {
   initial_cash: number;
   benchmark?: string;
   data_source?: string;
+  costs?: CostConfig;
+  execution_timing?: ExecutionTiming;
+  margin?: MarginConfig;
+  price_adjustment?: PriceAdjustment;
+  base_currency?: Currency;
+  risk_free?: RiskFreeConfig;
+  var_confidence?: number;
+  drawdown_top_n?: number;
   comparison_opt?: ComparisonOptions;
 }
 